@@ -0,0 +1,122 @@
+// Commande admin : opérations d'administration des comptes.
+//
+//	go run ./cmd/admin unlock <email>                lève le verrouillage d'un compte
+//	go run ./cmd/admin unlock-ip <ip>                lève le blocage d'une adresse IP
+//	go run ./cmd/admin grant-role <email> <rôle>     attribue un rôle (ex. admin)
+//	go run ./cmd/admin revoke-role <email> <rôle>    retire un rôle
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/arnaud-dars/collec-app/internal/config"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.SSLMode,
+	)), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	command, target := os.Args[1], os.Args[2]
+	switch command {
+	case "unlock", "unlock-ip":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		unlock(db, command, target)
+	case "grant-role", "revoke-role":
+		if len(os.Args) != 4 {
+			usage()
+		}
+		changeRole(db, command, target, os.Args[3])
+	default:
+		usage()
+	}
+}
+
+// unlock lève le verrouillage d'un compte ou le blocage d'une adresse IP
+func unlock(db *gorm.DB, command, target string) {
+	// Seules les opérations de déverrouillage sont utilisées : les seuils importent peu
+	lockoutService := service.NewLockoutService(repository.NewLoginThrottleRepository(db), service.LockoutConfig{})
+
+	var err error
+	if command == "unlock" {
+		err = lockoutService.Unlock(target)
+	} else {
+		err = lockoutService.UnlockIP(target)
+	}
+
+	if errors.Is(err, service.ErrNotLocked) {
+		fmt.Printf("%s is not locked\n", target)
+		return
+	}
+	if err != nil {
+		log.Fatal("Failed to unlock:", err)
+	}
+	fmt.Printf("✓ %s unlocked\n", target)
+}
+
+// changeRole attribue ou retire un rôle. Le changement prend effet au prochain
+// renouvellement des tokens de l'utilisateur.
+func changeRole(db *gorm.DB, command, email, role string) {
+	roleService := service.NewRoleService(repository.NewRoleRepository(db), repository.NewUserRepository(db))
+	if err := roleService.EnsureDefaults(); err != nil {
+		log.Fatal("Failed to initialize roles:", err)
+	}
+
+	var err error
+	if command == "grant-role" {
+		err = roleService.Grant(email, role)
+	} else {
+		err = roleService.Revoke(email, role)
+	}
+
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		log.Fatalf("No account for %s", email)
+	case errors.Is(err, service.ErrUnknownRole):
+		log.Fatalf("Unknown role %s", role)
+	case errors.Is(err, service.ErrRoleNotGranted):
+		fmt.Printf("%s does not have role %s\n", email, role)
+		return
+	case err != nil:
+		log.Fatal("Failed to update roles:", err)
+	}
+
+	if command == "grant-role" {
+		fmt.Printf("✓ Role %s granted to %s\n", role, email)
+	} else {
+		fmt.Printf("✓ Role %s revoked from %s\n", role, email)
+	}
+}
+
+// usage affiche la syntaxe de la commande et termine le programme
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin unlock <email> | unlock-ip <ip> | grant-role <email> <role> | revoke-role <email> <role>")
+	os.Exit(2)
+}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
+	"github.com/arnaud-dars/collec-app/internal/blobstore"
 	"github.com/arnaud-dars/collec-app/internal/config"
 	"github.com/arnaud-dars/collec-app/internal/handler"
+	"github.com/arnaud-dars/collec-app/internal/mailer"
 	"github.com/arnaud-dars/collec-app/internal/middleware"
 	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/oidc"
+	"github.com/arnaud-dars/collec-app/internal/password"
 	"github.com/arnaud-dars/collec-app/internal/repository"
 	"github.com/arnaud-dars/collec-app/internal/service"
 	"gorm.io/driver/postgres"
@@ -27,6 +34,9 @@ func main() {
 	if err != nil {
 		log.Fatal("Failed to load configuration:", err)
 	}
+	if err := cfg.CheckSecrets(); err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
 
 	// Connexion à la base de données
 	db, err := initDatabase(cfg)
@@ -36,39 +46,299 @@ func main() {
 	fmt.Println("✓ Database connected")
 
 	// Auto-migration (pour le développement)
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Session{}, &models.PasswordResetToken{}, &models.MagicLinkToken{}, &models.RecoveryCode{}, &models.UserIdentity{}, &models.OIDCAuthRequest{}, &models.SigningKey{}, &models.LoginThrottle{}, &models.RateLimitCounter{}, &models.DataExport{}, &models.Role{}, &models.Permission{}, &models.RolePermission{}, &models.UserRole{}, &models.PersonalAccessToken{}, &models.AuditEvent{}); err != nil {
 		log.Fatal("Failed to run migrations:", err)
 	}
 	fmt.Println("✓ Migrations completed")
 
 	// Initialiser les repositories
 	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	magicLinkRepo := repository.NewMagicLinkRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	userIdentityRepo := repository.NewUserIdentityRepository(db)
+	oidcAuthRequestRepo := repository.NewOIDCAuthRequestRepository(db)
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	dataExportRepo := repository.NewDataExportRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	personalAccessTokenRepo := repository.NewPersonalAccessTokenRepository(db)
+	auditEventRepo := repository.NewAuditEventRepository(db)
+
+	// Initialiser l'envoi d'emails
+	mail, err := initMailer(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize mailer:", err)
+	}
+
+	// Initialiser le stockage des fichiers
+	blobStore, err := initBlobStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize blob storage:", err)
+	}
+	fmt.Printf("✓ Blob storage initialized (%s)\n", cfg.Blob.Driver)
+
+	// Mode d'authentification par cookies
+	cookies, err := cookieConfig(cfg)
+	if err != nil {
+		log.Fatal("Invalid cookie configuration:", err)
+	}
+
+	// Initialiser le trousseau de clés de signature des JWT
+	keyRing, err := service.NewKeyRing(signingKeyRepo, keyRingConfig(cfg))
+	if err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+	fmt.Println("✓ Signing keys loaded")
+
+	// Initialiser la politique et le hachage des mots de passe
+	passwordPolicy, err := initPasswordPolicy(cfg)
+	if err != nil {
+		log.Fatal("Failed to load breached password list:", err)
+	}
+	passwordHasher := password.NewHasher(argon2Params(cfg))
+
+	// Initialiser les rôles et, sur une nouvelle installation, le premier administrateur
+	roleService := service.NewRoleService(roleRepo, userRepo)
+	if err := roleService.EnsureDefaults(); err != nil {
+		log.Fatal("Failed to initialize roles:", err)
+	}
+	bootstrapAdmin(roleService, cfg.Auth.BootstrapAdminEmail)
+	fmt.Println("✓ Roles initialized")
 
 	// Initialiser les services
+	auditLogger := service.NewAuditLogger(auditEventRepo)
+	sessionService := service.NewSessionService(sessionRepo, refreshTokenRepo, time.Duration(cfg.JWT.RefreshIdleTimeout)*time.Minute)
+	verificationService := service.NewEmailVerificationService(
+		userRepo,
+		mail,
+		cfg.Auth.LinkSigningSecret,
+		cfg.Server.FrontendURL,
+		time.Duration(cfg.Auth.EmailVerificationTTL)*time.Hour,
+		time.Duration(cfg.Auth.VerificationResendInterval)*time.Second,
+	)
+	mfaService := service.NewMFAService(
+		userRepo,
+		recoveryCodeRepo,
+		cfg.Auth.MFAIssuer,
+		cfg.Auth.MFAEncryptionKey,
+		passwordHasher,
+	)
+	lockoutService := service.NewLockoutService(loginThrottleRepo, lockoutConfig(cfg))
 	authService := service.NewAuthService(
 		userRepo,
-		cfg.JWT.Secret,
-		time.Duration(cfg.JWT.AccessTokenTTL)*time.Minute,
-		time.Duration(cfg.JWT.RefreshTokenTTL)*time.Hour,
+		refreshTokenRepo,
+		revokedTokenRepo,
+		sessionService,
+		verificationService,
+		mfaService,
+		lockoutService,
+		service.AuthConfig{
+			KeyRing:              keyRing,
+			LegacySecret:         legacySecret(cfg),
+			PasswordPolicy:       passwordPolicy,
+			PasswordHasher:       passwordHasher,
+			Roles:                roleService,
+			Audit:                auditLogger,
+			Issuer:               cfg.JWT.Issuer,
+			Audience:             cfg.JWT.Audience,
+			AccessTokenTTL:       time.Duration(cfg.JWT.AccessTokenTTL) * time.Minute,
+			RefreshTokenTTL:      time.Duration(cfg.JWT.RefreshTokenTTL) * time.Hour,
+			RememberMeTTL:        time.Duration(cfg.JWT.RememberMeTTL) * time.Hour,
+			MFAPendingTTL:        time.Duration(cfg.Auth.MFAPendingTTL) * time.Minute,
+			DeletionGracePeriod:  deletionGracePeriod(cfg),
+			RequireVerifiedEmail: cfg.Auth.RequireEmailVerification,
+		},
+	)
+
+	passwordResetService := service.NewPasswordResetService(
+		userRepo,
+		passwordResetRepo,
+		sessionService,
+		mail,
+		passwordPolicy,
+		passwordHasher,
+		cfg.Server.FrontendURL,
+		time.Duration(cfg.Auth.PasswordResetTTL)*time.Minute,
+	)
+	magicLinkService := service.NewMagicLinkService(
+		userRepo,
+		magicLinkRepo,
+		authService,
+		lockoutService,
+		mail,
+		cfg.Server.FrontendURL,
+		time.Duration(cfg.Auth.MagicLinkTTL)*time.Minute,
+	)
+
+	exportService := service.NewExportService(
+		dataExportRepo,
+		userRepo,
+		mail,
+		[]service.ExportSource{
+			service.NewAccountExportSource(sessionRepo, userIdentityRepo, personalAccessTokenRepo, blobStore),
+			service.NewAuditExportSource(auditEventRepo),
+		},
+		service.ExportConfig{
+			Dir:        cfg.Export.Dir,
+			LinkSecret: cfg.Auth.LinkSigningSecret,
+			PublicURL:  cfg.Server.PublicURL,
+			Retention:  time.Duration(cfg.Export.Retention) * time.Hour,
+		},
+	)
+	startExportWorker(exportService, time.Duration(cfg.Export.WorkerInterval)*time.Second)
+
+	avatarService := service.NewAvatarService(userRepo, blobStore, avatarMaxSize(cfg))
+	accountService := service.NewAccountService(
+		userRepo,
+		sessionService,
+		lockoutService,
+		avatarService,
+		exportService,
+		mail,
+		passwordPolicy,
+		passwordHasher,
+		service.AccountConfig{
+			LinkSecret:          cfg.Auth.LinkSigningSecret,
+			FrontendURL:         cfg.Server.FrontendURL,
+			EmailChangeTTL:      time.Duration(cfg.Auth.EmailChangeTTL) * time.Hour,
+			DeletionGracePeriod: deletionGracePeriod(cfg),
+		},
+	)
+	startAccountPurge(accountService, time.Duration(cfg.Auth.DeletionPurgeInterval)*time.Minute)
+	startExpiredTokenPurge(map[string]func() error{
+		"tokens révoqués":                 revokedTokenRepo.DeleteExpired,
+		"refresh tokens expirés":          refreshTokenRepo.DeleteExpired,
+		"tokens de réinitialisation":      passwordResetRepo.DeleteExpired,
+		"liens de connexion":              magicLinkRepo.DeleteExpired,
+		"compteurs d'échecs de connexion": lockoutService.PurgeStale,
+	})
+
+	personalAccessTokenService := service.NewPersonalAccessTokenService(personalAccessTokenRepo, userRepo, roleService)
+	adminService := service.NewAdminService(userRepo, sessionService, roleService, passwordResetService)
+	profileService := service.NewProfileService(userRepo)
+
+	oidcService := service.NewOIDCService(
+		initOIDCProviders(cfg),
+		userRepo,
+		userIdentityRepo,
+		oidcAuthRequestRepo,
+		authService,
+		time.Duration(cfg.OIDC.RequestTTL)*time.Minute,
 	)
 
 	// Initialiser les handlers
-	authHandler := handler.NewAuthHandler(authService)
+	authHandler := handler.NewAuthHandler(authService, profileService, auditLogger, cookies)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+	passwordHandler := handler.NewPasswordHandler(passwordResetService)
+	magicLinkHandler := handler.NewMagicLinkHandler(magicLinkService, cookies)
+	verificationHandler := handler.NewEmailVerificationHandler(verificationService)
+	accountHandler := handler.NewAccountHandler(accountService)
+	exportHandler := handler.NewExportHandler(exportService)
+	mfaHandler := handler.NewMFAHandler(mfaService)
+	oidcHandler := handler.NewOIDCHandler(oidcService, cookies)
+	adminHandler := handler.NewAdminHandler(adminService)
+	personalAccessTokenHandler := handler.NewPersonalAccessTokenHandler(personalAccessTokenService)
+	auditHandler := handler.NewAuditHandler(auditLogger)
+	profileHandler := handler.NewProfileHandler(profileService)
+	avatarHandler := handler.NewAvatarHandler(avatarService, avatarMaxSize(cfg))
+	blobHandler := handler.NewBlobHandler(blobStore)
+	// Le JWKS est mis en cache moins longtemps que le délai de propagation des
+	// nouvelles clés, pour que les vérificateurs les connaissent avant leur usage
+	jwksHandler := handler.NewJWKSHandler(keyRing, cfg.JWT.KeyPropagationDelay*60/2)
 
 	// Initialiser les middlewares
-	authMiddleware := middleware.NewAuthMiddleware(authService)
+	rateLimitStore := initRateLimitStore(cfg, db)
+	authMiddleware := middleware.NewAuthMiddleware(authService, personalAccessTokenService, auditLogger, rateLimitStore, cookies)
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, cfg.RateLimit.Enabled)
+
+	// Politiques de limitation de débit
+	registerLimit := rateLimitPolicy("register", cfg.RateLimit.Register, middleware.KeyByIP)
+	loginLimit := rateLimitPolicy("login", cfg.RateLimit.Login, middleware.KeyByIP)
+	recoveryLimit := rateLimitPolicy("recovery", cfg.RateLimit.Recovery, middleware.KeyByIP)
+	publicLimit := rateLimitPolicy("public", cfg.RateLimit.Public, middleware.KeyByIP)
+	authLimit := rateLimitPolicy("auth", cfg.RateLimit.Auth, middleware.KeyByIP)
+	apiLimit := rateLimitPolicy("api", cfg.RateLimit.API, middleware.KeyByUser)
+
+	// protected limite par IP les requêtes avant l'authentification, pour que
+	// les tokens invalides soient aussi limités, puis exige un utilisateur
+	// authentifié et applique le quota par utilisateur
+	protected := func(next http.HandlerFunc) http.HandlerFunc {
+		return rateLimiter.Limit(authLimit, authMiddleware.RequireAuth(rateLimiter.Limit(apiLimit, next)))
+	}
+
+	// session refuse en plus les personal access tokens
+	session := func(next http.HandlerFunc) http.HandlerFunc {
+		return protected(authMiddleware.RequireSession(next))
+	}
+
+	// admin exige en plus la permission donnée
+	admin := func(permission string, next http.HandlerFunc) http.HandlerFunc {
+		return protected(authMiddleware.RequirePermission(permission, next))
+	}
 
 	// Configurer les routes
 	mux := http.NewServeMux()
 
 	// Routes publiques
-	mux.HandleFunc("/api/auth/register", authHandler.Register)
-	mux.HandleFunc("/api/auth/login", authHandler.Login)
-	mux.HandleFunc("/api/auth/refresh", authHandler.RefreshToken)
-	mux.HandleFunc("/api/auth/logout", authHandler.Logout)
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler.JWKS)
+	// Les fichiers du stockage local sont servis par l'API ; en s3, par le bucket
+	if cfg.Blob.Driver == "local" {
+		mux.HandleFunc("GET /blobs/{key...}", blobHandler.Serve)
+	}
+	mux.HandleFunc("/api/auth/register", rateLimiter.Limit(registerLimit, authHandler.Register))
+	mux.HandleFunc("/api/auth/login", rateLimiter.Limit(loginLimit, authHandler.Login))
+	mux.HandleFunc("POST /api/auth/login/mfa", rateLimiter.Limit(loginLimit, authHandler.LoginMFA))
+	mux.HandleFunc("/api/auth/refresh", rateLimiter.Limit(publicLimit, authHandler.RefreshToken))
+	mux.HandleFunc("POST /api/auth/password/forgot", rateLimiter.Limit(recoveryLimit, passwordHandler.Forgot))
+	mux.HandleFunc("POST /api/auth/password/reset", rateLimiter.Limit(publicLimit, passwordHandler.Reset))
+	mux.HandleFunc("POST /api/auth/magic-link", rateLimiter.Limit(recoveryLimit, magicLinkHandler.Request))
+	mux.HandleFunc("POST /api/auth/magic-link/login", rateLimiter.Limit(loginLimit, magicLinkHandler.Login))
+	mux.HandleFunc("POST /api/auth/email/verify", rateLimiter.Limit(publicLimit, verificationHandler.Verify))
+	mux.HandleFunc("POST /api/auth/email/resend", rateLimiter.Limit(recoveryLimit, verificationHandler.Resend))
+	mux.HandleFunc("POST /api/auth/email/change/confirm", rateLimiter.Limit(publicLimit, accountHandler.ConfirmEmailChange))
+	mux.HandleFunc("GET /api/users/me/export/download", rateLimiter.Limit(publicLimit, exportHandler.Download))
+	mux.HandleFunc("GET /api/auth/oidc/providers", rateLimiter.Limit(publicLimit, oidcHandler.Providers))
+	mux.HandleFunc("POST /api/auth/oidc/{provider}/authorize", rateLimiter.Limit(loginLimit, oidcHandler.Authorize))
+	mux.HandleFunc("POST /api/auth/oidc/{provider}/callback", rateLimiter.Limit(loginLimit, oidcHandler.Callback))
 
 	// Routes protégées
-	mux.HandleFunc("/api/auth/me", authMiddleware.RequireAuth(authHandler.GetMe))
+	mux.HandleFunc("GET /api/auth/me", protected(authHandler.GetMe))
+	mux.HandleFunc("DELETE /api/auth/me", session(accountHandler.DeleteAccount))
+	mux.HandleFunc("/api/auth/logout", session(authHandler.Logout))
+	mux.HandleFunc("/api/auth/logout-all", session(authHandler.LogoutEverywhere))
+	mux.HandleFunc("POST /api/auth/password/change", session(accountHandler.ChangePassword))
+	mux.HandleFunc("POST /api/auth/email/change", session(accountHandler.ChangeEmail))
+	mux.HandleFunc("GET /api/auth/sessions", session(sessionHandler.List))
+	mux.HandleFunc("DELETE /api/auth/sessions", session(sessionHandler.RevokeOthers))
+	mux.HandleFunc("DELETE /api/auth/sessions/{id}", session(sessionHandler.Revoke))
+	mux.HandleFunc("GET /api/auth/mfa", session(mfaHandler.Status))
+	mux.HandleFunc("POST /api/auth/mfa/totp", session(mfaHandler.Enroll))
+	mux.HandleFunc("POST /api/auth/mfa/totp/confirm", session(mfaHandler.Confirm))
+	mux.HandleFunc("POST /api/auth/mfa/totp/disable", session(mfaHandler.Disable))
+	mux.HandleFunc("POST /api/auth/mfa/recovery-codes", session(mfaHandler.RegenerateRecoveryCodes))
+	mux.HandleFunc("PATCH /api/users/me", protected(profileHandler.Update))
+	mux.HandleFunc("PUT /api/users/me/avatar", protected(avatarHandler.Upload))
+	mux.HandleFunc("DELETE /api/users/me/avatar", protected(avatarHandler.Delete))
+	mux.HandleFunc("POST /api/users/me/export", protected(exportHandler.Request))
+	mux.HandleFunc("GET /api/users/me/export", protected(exportHandler.Status))
+	mux.HandleFunc("POST /api/auth/tokens", session(personalAccessTokenHandler.Create))
+	mux.HandleFunc("GET /api/auth/tokens", session(personalAccessTokenHandler.List))
+	mux.HandleFunc("DELETE /api/auth/tokens/{id}", session(personalAccessTokenHandler.Revoke))
+	mux.HandleFunc("GET /api/auth/audit-events", session(auditHandler.ListMine))
+
+	// Routes d'administration
+	mux.HandleFunc("GET /api/admin/users", admin(service.PermissionUsersRead, adminHandler.ListUsers))
+	mux.HandleFunc("GET /api/admin/users/{id}", admin(service.PermissionUsersRead, adminHandler.GetUser))
+	mux.HandleFunc("GET /api/admin/users/{id}/sessions", admin(service.PermissionUsersRead, adminHandler.ListSessions))
+	mux.HandleFunc("POST /api/admin/users/{id}/disable", admin(service.PermissionUsersWrite, adminHandler.DisableUser))
+	mux.HandleFunc("POST /api/admin/users/{id}/enable", admin(service.PermissionUsersWrite, adminHandler.EnableUser))
+	mux.HandleFunc("POST /api/admin/users/{id}/password-reset", admin(service.PermissionUsersWrite, adminHandler.ForcePasswordReset))
+	mux.HandleFunc("POST /api/admin/users/{id}/revoke-tokens", admin(service.PermissionUsersWrite, adminHandler.RevokeTokens))
+	mux.HandleFunc("GET /api/admin/audit-events", admin(service.PermissionAuditRead, auditHandler.Query))
 
 	// Route de santé
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -80,14 +350,59 @@ func main() {
 	addr := ":" + cfg.Server.Port
 	fmt.Printf("✓ Server listening on http://localhost%s\n", addr)
 	fmt.Println("\nAvailable endpoints:")
+	fmt.Println("  GET    /.well-known/jwks.json")
+	if cfg.Blob.Driver == "local" {
+		fmt.Println("  GET    /blobs/{key...}")
+	}
 	fmt.Println("  POST   /api/auth/register")
 	fmt.Println("  POST   /api/auth/login")
+	fmt.Println("  POST   /api/auth/login/mfa")
 	fmt.Println("  POST   /api/auth/refresh")
-	fmt.Println("  POST   /api/auth/logout")
+	fmt.Println("  POST   /api/auth/password/forgot")
+	fmt.Println("  POST   /api/auth/password/reset")
+	fmt.Println("  POST   /api/auth/magic-link")
+	fmt.Println("  POST   /api/auth/magic-link/login")
+	fmt.Println("  POST   /api/auth/email/verify")
+	fmt.Println("  POST   /api/auth/email/resend")
+	fmt.Println("  POST   /api/auth/email/change/confirm")
+	fmt.Println("  GET    /api/users/me/export/download")
+	fmt.Println("  GET    /api/auth/oidc/providers")
+	fmt.Println("  POST   /api/auth/oidc/{provider}/authorize")
+	fmt.Println("  POST   /api/auth/oidc/{provider}/callback")
 	fmt.Println("  GET    /api/auth/me (protected)")
+	fmt.Println("  DELETE /api/auth/me (protected)")
+	fmt.Println("  POST   /api/auth/logout (protected)")
+	fmt.Println("  POST   /api/auth/logout-all (protected)")
+	fmt.Println("  POST   /api/auth/password/change (protected)")
+	fmt.Println("  POST   /api/auth/email/change (protected)")
+	fmt.Println("  GET    /api/auth/sessions (protected)")
+	fmt.Println("  DELETE /api/auth/sessions (protected)")
+	fmt.Println("  DELETE /api/auth/sessions/{id} (protected)")
+	fmt.Println("  GET    /api/auth/mfa (protected)")
+	fmt.Println("  POST   /api/auth/mfa/totp (protected)")
+	fmt.Println("  POST   /api/auth/mfa/totp/confirm (protected)")
+	fmt.Println("  POST   /api/auth/mfa/totp/disable (protected)")
+	fmt.Println("  POST   /api/auth/mfa/recovery-codes (protected)")
+	fmt.Println("  PATCH  /api/users/me (protected)")
+	fmt.Println("  PUT    /api/users/me/avatar (protected)")
+	fmt.Println("  DELETE /api/users/me/avatar (protected)")
+	fmt.Println("  POST   /api/users/me/export (protected)")
+	fmt.Println("  GET    /api/users/me/export (protected)")
+	fmt.Println("  POST   /api/auth/tokens (protected)")
+	fmt.Println("  GET    /api/auth/tokens (protected)")
+	fmt.Println("  DELETE /api/auth/tokens/{id} (protected)")
+	fmt.Println("  GET    /api/auth/audit-events (protected)")
+	fmt.Println("  GET    /api/admin/users (admin)")
+	fmt.Println("  GET    /api/admin/users/{id} (admin)")
+	fmt.Println("  GET    /api/admin/users/{id}/sessions (admin)")
+	fmt.Println("  POST   /api/admin/users/{id}/disable (admin)")
+	fmt.Println("  POST   /api/admin/users/{id}/enable (admin)")
+	fmt.Println("  POST   /api/admin/users/{id}/password-reset (admin)")
+	fmt.Println("  POST   /api/admin/users/{id}/revoke-tokens (admin)")
+	fmt.Println("  GET    /api/admin/audit-events (admin)")
 	fmt.Println("  GET    /health")
 
-	if err := http.ListenAndServe(addr, enableCORS(mux)); err != nil {
+	if err := http.ListenAndServe(addr, enableCORS(mux, cfg.Server.FrontendURL, cookies.Enabled)); err != nil {
 		log.Fatal("Server failed to start:", err)
 	}
 }
@@ -112,12 +427,278 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 	return db, nil
 }
 
-// enableCORS ajoute les headers CORS pour le développement
-func enableCORS(next http.Handler) http.Handler {
+// keyRingConfig construit la configuration du trousseau de clés. Une clé
+// remplacée vérifie encore les tokens pendant la durée de vie du plus long refresh token.
+func keyRingConfig(cfg *config.Config) service.KeyRingConfig {
+	return service.KeyRingConfig{
+		Algorithm:        cfg.JWT.SigningAlgorithm,
+		EncryptionKey:    cfg.JWT.KeyEncryptionKey,
+		PropagationDelay: time.Duration(cfg.JWT.KeyPropagationDelay) * time.Minute,
+		Overlap:          time.Duration(max(cfg.JWT.RefreshTokenTTL, cfg.JWT.RememberMeTTL)) * time.Hour,
+		ReloadInterval:   time.Duration(cfg.JWT.KeyReloadInterval) * time.Second,
+	}
+}
+
+// initRateLimitStore choisit le stockage des compteurs de limitation de débit.
+// Avec Postgres, les compteurs expirés sont purgés périodiquement.
+func initRateLimitStore(cfg *config.Config, db *gorm.DB) middleware.RateLimitStore {
+	if cfg.RateLimit.Store != "postgres" {
+		return middleware.NewMemoryRateLimitStore()
+	}
+
+	repo := repository.NewRateLimitRepository(db)
+	go func() {
+		for range time.Tick(10 * time.Minute) {
+			if err := repo.DeleteExpired(); err != nil {
+				log.Printf("purge des compteurs de limitation de débit impossible: %v", err)
+			}
+		}
+	}()
+	return repo
+}
+
+// bootstrapAdmin attribue le rôle admin au compte configuré tant qu'aucun
+// administrateur n'existe. Le compte doit avoir été créé au préalable.
+func bootstrapAdmin(roleService service.RoleService, email string) {
+	if email == "" {
+		return
+	}
+	err := roleService.BootstrapAdmin(email)
+	switch {
+	case err == nil:
+		fmt.Printf("✓ Admin role granted to %s\n", email)
+	case errors.Is(err, service.ErrAdminBootstrapped):
+		// Cas normal après le premier démarrage
+	case errors.Is(err, service.ErrUserNotFound):
+		log.Printf("BOOTSTRAP_ADMIN_EMAIL: aucun compte %s, inscrivez-vous puis redémarrez l'API", email)
+	default:
+		log.Fatal("Failed to bootstrap admin:", err)
+	}
+}
+
+// deletionGracePeriod convertit le délai de grâce de suppression des comptes
+func deletionGracePeriod(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Auth.DeletionGracePeriod) * 24 * time.Hour
+}
+
+// startAccountPurge supprime périodiquement et définitivement les comptes dont
+// le délai de grâce est écoulé
+func startAccountPurge(accountService service.AccountService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			count, err := accountService.PurgeDeletedAccounts()
+			if err != nil {
+				log.Printf("purge des comptes supprimés impossible: %v", err)
+			}
+			if count > 0 {
+				log.Printf("%d compte(s) supprimé(s) définitivement", count)
+			}
+		}
+	}()
+}
+
+// startExpiredTokenPurge supprime périodiquement les tokens expirés et les
+// compteurs d'échecs oubliés, qui s'accumuleraient sinon à chaque connexion
+func startExpiredTokenPurge(purges map[string]func() error) {
+	go func() {
+		for range time.Tick(10 * time.Minute) {
+			for name, purge := range purges {
+				if err := purge(); err != nil {
+					log.Printf("purge des %s impossible: %v", name, err)
+				}
+			}
+		}
+	}()
+}
+
+// startExportWorker construit en tâche de fond les archives d'export demandées
+// et supprime celles dont le lien a expiré
+func startExportWorker(exportService service.ExportService, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := exportService.ProcessPendingExports(); err != nil {
+				log.Printf("construction des exports de données impossible: %v", err)
+			}
+			if _, err := exportService.PurgeExpiredExports(); err != nil {
+				log.Printf("suppression des exports de données expirés impossible: %v", err)
+			}
+		}
+	}()
+}
+
+// rateLimitPolicy construit une politique de limitation de débit à partir de la configuration
+func rateLimitPolicy(name string, rule config.RateLimitRule, key middleware.KeyFunc) middleware.RateLimitPolicy {
+	return middleware.RateLimitPolicy{
+		Name:   name,
+		Limit:  rule.Limit,
+		Window: rule.Window,
+		Key:    key,
+	}
+}
+
+// lockoutConfig construit la configuration de la protection contre la force brute
+func lockoutConfig(cfg *config.Config) service.LockoutConfig {
+	return service.LockoutConfig{
+		MaxAccountFailures: cfg.Lockout.MaxAccountFailures,
+		MaxIPFailures:      cfg.Lockout.MaxIPFailures,
+		FreeAttempts:       cfg.Lockout.FreeAttempts,
+		BaseDelay:          time.Duration(cfg.Lockout.BaseDelay) * time.Second,
+		MaxDelay:           time.Duration(cfg.Lockout.MaxDelay) * time.Second,
+		Window:             time.Duration(cfg.Lockout.Window) * time.Minute,
+		LockoutDuration:    time.Duration(cfg.Lockout.Duration) * time.Minute,
+	}
+}
+
+// cookieConfig construit la configuration du mode d'authentification par cookies
+func cookieConfig(cfg *config.Config) (authcookie.Config, error) {
+	sameSite, err := authcookie.ParseSameSite(cfg.Cookie.SameSite)
+	if err != nil {
+		return authcookie.Config{}, err
+	}
+	if sameSite == http.SameSiteNoneMode && !cfg.Cookie.Secure {
+		return authcookie.Config{}, errors.New("AUTH_COOKIE_SAMESITE=none exige AUTH_COOKIE_SECURE=true")
+	}
+	if cfg.Cookie.Enabled {
+		fmt.Println("✓ Cookie auth mode enabled")
+	}
+	return authcookie.Config{
+		Enabled:  cfg.Cookie.Enabled,
+		Domain:   cfg.Cookie.Domain,
+		Secure:   cfg.Cookie.Secure,
+		SameSite: sameSite,
+		CSRF:     cfg.Cookie.CSRFProtection,
+	}, nil
+}
+
+// initPasswordPolicy construit la politique de mots de passe et charge la
+// liste de mots de passe compromis si elle est configurée
+func initPasswordPolicy(cfg *config.Config) (*password.Policy, error) {
+	policyConfig := password.PolicyConfig{
+		MinLength:        cfg.Password.MinLength,
+		MaxLength:        cfg.Password.MaxLength,
+		RequireLowercase: cfg.Password.RequireLowercase,
+		RequireUppercase: cfg.Password.RequireUppercase,
+		RequireDigit:     cfg.Password.RequireDigit,
+		RequireSymbol:    cfg.Password.RequireSymbol,
+		ForbidEmail:      cfg.Password.ForbidEmail,
+	}
+
+	if cfg.Password.BreachedList != "" {
+		breached, err := password.NewBreachedList(cfg.Password.BreachedList)
+		if err != nil {
+			return nil, err
+		}
+		policyConfig.Breached = breached
+		fmt.Println("✓ Breached password list loaded")
+	}
+
+	return password.NewPolicy(policyConfig), nil
+}
+
+// argon2Params construit les paramètres de coût du hachage des mots de passe
+func argon2Params(cfg *config.Config) password.Argon2Params {
+	params := password.DefaultArgon2Params
+	params.Memory = uint32(cfg.Password.Argon2Memory)
+	params.Iterations = uint32(cfg.Password.Argon2Iterations)
+	params.Parallelism = uint8(cfg.Password.Argon2Parallelism)
+	return params
+}
+
+// legacySecret retourne le secret HS256 accepté pendant la migration vers les
+// clés asymétriques, ou une chaîne vide une fois la migration terminée
+func legacySecret(cfg *config.Config) string {
+	if !cfg.JWT.AcceptLegacyHS256 {
+		return ""
+	}
+	return cfg.JWT.Secret
+}
+
+// initMailer choisit l'implémentation d'envoi d'emails selon la configuration
+func initMailer(cfg *config.Config) (mailer.Mailer, error) {
+	if cfg.Mail.Driver == "smtp" {
+		return mailer.NewSMTPMailer(
+			cfg.Mail.SMTPHost,
+			cfg.Mail.SMTPPort,
+			cfg.Mail.SMTPUsername,
+			cfg.Mail.SMTPPassword,
+			cfg.Mail.From,
+		)
+	}
+	return mailer.NewFileMailer(cfg.Mail.OutboxDir, cfg.Mail.From), nil
+}
+
+// initBlobStore choisit l'implémentation du stockage des fichiers selon la configuration
+func initBlobStore(cfg *config.Config) (blobstore.BlobStore, error) {
+	switch cfg.Blob.Driver {
+	case "local":
+		publicURL := cfg.Blob.PublicURL
+		if publicURL == "" {
+			publicURL = cfg.Server.PublicURL + "/blobs"
+		}
+		return blobstore.NewLocalStore(cfg.Blob.Dir, publicURL)
+	case "s3":
+		if cfg.Blob.S3Endpoint == "" || cfg.Blob.S3Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT et S3_BUCKET sont requis avec BLOB_DRIVER=s3")
+		}
+		return blobstore.NewS3Store(blobstore.S3Config{
+			Endpoint:  cfg.Blob.S3Endpoint,
+			Region:    cfg.Blob.S3Region,
+			Bucket:    cfg.Blob.S3Bucket,
+			AccessKey: cfg.Blob.S3AccessKey,
+			SecretKey: cfg.Blob.S3SecretKey,
+			PublicURL: cfg.Blob.PublicURL,
+		}), nil
+	}
+	return nil, fmt.Errorf("BLOB_DRIVER inconnu : %q (local ou s3)", cfg.Blob.Driver)
+}
+
+// avatarMaxSize convertit la taille maximale des avatars en octets
+func avatarMaxSize(cfg *config.Config) int64 {
+	return int64(cfg.Avatar.MaxSize) * 1024
+}
+
+// initOIDCProviders crée les clients des fournisseurs d'identité configurés
+func initOIDCProviders(cfg *config.Config) []*oidc.Provider {
+	providers := make([]*oidc.Provider, 0, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		providers = append(providers, oidc.NewProvider(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  strings.TrimSuffix(cfg.OIDC.RedirectBaseURL, "/") + "/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+			AuthURL:      p.AuthURL,
+			TokenURL:     p.TokenURL,
+			UserInfoURL:  p.UserInfoURL,
+			JWKSURL:      p.JWKSURL,
+			TrustEmail:   p.TrustEmail,
+		}, nil))
+	}
+	return providers
+}
+
+// enableCORS ajoute les headers CORS pour le développement. En mode cookies,
+// le navigateur n'envoie les cookies qu'à une origine explicitement autorisée :
+// seul le frontend l'est alors, avec les credentials.
+func enableCORS(next http.Handler, frontendURL string, withCredentials bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if withCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", frontendURL)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Add("Vary", "Origin")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+authcookie.CSRFHeader)
+		w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -0,0 +1,95 @@
+// Commande keys : gestion des clés de signature des JWT.
+//
+//	go run ./cmd/keys list    liste les clés publiées
+//	go run ./cmd/keys rotate  crée une nouvelle clé ; elle signera après le
+//	                          délai de propagation, les anciennes clés restant
+//	                          valides pendant la période de recouvrement
+//
+// La rotation se fait sans interruption : les instances de l'API chargent la
+// nouvelle clé depuis la base sans redémarrage.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/config"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "list" && os.Args[1] != "rotate") {
+		fmt.Fprintln(os.Stderr, "usage: keys list|rotate")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+	if err := cfg.JWT.CheckKeyEncryptionKey(); err != nil {
+		log.Fatal("Invalid configuration:", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.DBName,
+		cfg.Database.SSLMode,
+	)), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := db.AutoMigrate(&models.SigningKey{}); err != nil {
+		log.Fatal("Failed to run migrations:", err)
+	}
+
+	keyRing, err := service.NewKeyRing(repository.NewSigningKeyRepository(db), service.KeyRingConfig{
+		Algorithm:        cfg.JWT.SigningAlgorithm,
+		EncryptionKey:    cfg.JWT.KeyEncryptionKey,
+		PropagationDelay: time.Duration(cfg.JWT.KeyPropagationDelay) * time.Minute,
+		Overlap:          time.Duration(max(cfg.JWT.RefreshTokenTTL, cfg.JWT.RememberMeTTL)) * time.Hour,
+	})
+	if err != nil {
+		log.Fatal("Failed to load signing keys:", err)
+	}
+
+	if os.Args[1] == "rotate" {
+		key, err := keyRing.Rotate()
+		if err != nil {
+			log.Fatal("Failed to rotate signing keys:", err)
+		}
+		fmt.Printf("✓ Key %s (%s) created, signing from %s\n", key.ID, key.Algorithm, key.ActivatesAt.Format(time.RFC3339))
+	}
+
+	var keys []models.SigningKey
+	if err := db.Order("activates_at DESC").Find(&keys).Error; err != nil {
+		log.Fatal("Failed to list signing keys:", err)
+	}
+	now := time.Now()
+	for _, key := range keys {
+		status := "active"
+		switch {
+		case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+			status = "expired"
+		case key.ActivatesAt.After(now):
+			status = "pending"
+		case key.ExpiresAt != nil:
+			status = "retiring"
+		}
+		expires := "-"
+		if key.ExpiresAt != nil {
+			expires = key.ExpiresAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%-18s %-6s %-9s activates=%s expires=%s\n", key.ID, key.Algorithm, status, key.ActivatesAt.Format(time.RFC3339), expires)
+	}
+}
@@ -0,0 +1,176 @@
+// Package authcookie implémente le mode d'authentification par cookies : les
+// tokens sont posés dans des cookies HttpOnly, inaccessibles au JavaScript de
+// la page, et les requêtes modifiantes sont protégées par un token CSRF en
+// double soumission (cookie lisible par la page, recopié dans un en-tête).
+package authcookie
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Noms des cookies et de l'en-tête CSRF
+const (
+	AccessTokenCookie  = "collec_access"
+	RefreshTokenCookie = "collec_refresh"
+	CSRFCookie         = "collec_csrf"
+	CSRFHeader         = "X-CSRF-Token"
+	OIDCStateCookie    = "collec_oidc_state"
+)
+
+// refreshTokenPath limite l'envoi du refresh token aux routes qui l'utilisent
+// (renouvellement et déconnexion)
+const refreshTokenPath = "/api/auth"
+
+// oidcStatePath limite l'envoi du state OIDC aux routes de connexion externe
+const oidcStatePath = "/api/auth/oidc"
+
+// csrfTokenSize est la taille en octets des tokens CSRF
+const csrfTokenSize = 32
+
+// Config décrit le mode d'authentification par cookies
+type Config struct {
+	Enabled  bool   // tokens posés en cookies au lieu d'être retournés dans les réponses
+	Domain   string // vide : cookies limités à l'hôte de l'API
+	Secure   bool   // cookies transmis uniquement en HTTPS
+	SameSite http.SameSite
+	CSRF     bool // exiger le token CSRF sur les requêtes modifiantes authentifiées par cookie
+}
+
+// ParseSameSite convertit la valeur de configuration (strict, lax ou none)
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	}
+	return 0, fmt.Errorf("valeur SameSite inconnue : %q (strict, lax ou none)", value)
+}
+
+// SetTokens pose les cookies des tokens et un nouveau token CSRF. Chaque
+// cookie expire avec le token qu'il porte, le token CSRF avec le refresh token.
+func (c Config) SetTokens(w http.ResponseWriter, accessToken, refreshToken string) error {
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	refreshExpiry := tokenExpiry(refreshToken)
+	http.SetCookie(w, c.cookie(AccessTokenCookie, accessToken, "/", tokenExpiry(accessToken), true))
+	http.SetCookie(w, c.cookie(RefreshTokenCookie, refreshToken, refreshTokenPath, refreshExpiry, true))
+	http.SetCookie(w, c.cookie(CSRFCookie, csrfToken, "/", refreshExpiry, false))
+	return nil
+}
+
+// Clear supprime les cookies posés par SetTokens
+func (c Config) Clear(w http.ResponseWriter) {
+	for _, cookie := range []*http.Cookie{
+		c.cookie(AccessTokenCookie, "", "/", time.Time{}, true),
+		c.cookie(RefreshTokenCookie, "", refreshTokenPath, time.Time{}, true),
+		c.cookie(CSRFCookie, "", "/", time.Time{}, false),
+	} {
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+	}
+}
+
+// AccessToken retourne l'access token présenté en cookie, vide s'il est absent
+func AccessToken(r *http.Request) string {
+	return cookieValue(r, AccessTokenCookie)
+}
+
+// RefreshToken retourne le refresh token présenté en cookie, vide s'il est absent
+func RefreshToken(r *http.Request) string {
+	return cookieValue(r, RefreshTokenCookie)
+}
+
+// SetOIDCState lie une connexion externe au navigateur qui l'a démarrée : le
+// state est posé dans un cookie HttpOnly, quel que soit le mode
+// d'authentification, et doit être présenté au retour du fournisseur
+func (c Config) SetOIDCState(w http.ResponseWriter, state string, expires time.Time) {
+	http.SetCookie(w, c.cookie(OIDCStateCookie, state, oidcStatePath, expires, true))
+}
+
+// VerifyOIDCState indique si le state reçu au retour du fournisseur est celui
+// posé dans ce navigateur par SetOIDCState
+func VerifyOIDCState(r *http.Request, state string) bool {
+	expected := cookieValue(r, OIDCStateCookie)
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(state)) == 1
+}
+
+// ClearOIDCState supprime le cookie posé par SetOIDCState
+func (c Config) ClearOIDCState(w http.ResponseWriter) {
+	cookie := c.cookie(OIDCStateCookie, "", oidcStatePath, time.Time{}, true)
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+}
+
+// VerifyCSRF indique si une requête authentifiée par cookie peut être
+// traitée : les méthodes sûres passent toujours ; les autres, si la protection
+// est activée, doivent recopier dans l'en-tête X-CSRF-Token la valeur du cookie CSRF.
+func (c Config) VerifyCSRF(r *http.Request) bool {
+	if !c.CSRF {
+		return true
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	expected := cookieValue(r, CSRFCookie)
+	presented := r.Header.Get(CSRFHeader)
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(presented)) == 1
+}
+
+// cookie construit un cookie avec les attributs configurés. Une date
+// d'expiration nulle donne un cookie de session.
+func (c Config) cookie(name, value, path string, expires time.Time, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.Domain,
+		Expires:  expires,
+		Secure:   c.Secure,
+		HttpOnly: httpOnly,
+		SameSite: c.SameSite,
+	}
+}
+
+// cookieValue retourne la valeur d'un cookie, vide s'il est absent
+func cookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// tokenExpiry lit la date d'expiration d'un JWT que l'API vient d'émettre ;
+// la signature n'est pas vérifiée. Retourne une date nulle si elle est illisible.
+func tokenExpiry(token string) time.Time {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return time.Time{}
+	}
+	return claims.ExpiresAt.Time
+}
+
+// newCSRFToken génère un token CSRF aléatoire
+func newCSRFToken() (string, error) {
+	buf := make([]byte, csrfTokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
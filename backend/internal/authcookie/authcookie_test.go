@@ -0,0 +1,145 @@
+package authcookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function pour construire un JWT expirant à la date donnée
+func signedToken(t *testing.T, expiresAt time.Time) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+	return token
+}
+
+func TestSetTokens(t *testing.T) {
+	// Arrange
+	config := Config{Enabled: true, Secure: true, SameSite: http.SameSiteStrictMode, Domain: "api.example.com"}
+	accessExpiry := time.Now().Add(15 * time.Minute).Truncate(time.Second)
+	refreshExpiry := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	rec := httptest.NewRecorder()
+
+	// Act
+	require.NoError(t, config.SetTokens(rec, signedToken(t, accessExpiry), signedToken(t, refreshExpiry)))
+
+	// Assert
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+	require.Len(t, cookies, 3)
+
+	access := cookies[AccessTokenCookie]
+	assert.True(t, access.HttpOnly)
+	assert.True(t, access.Secure)
+	assert.Equal(t, http.SameSiteStrictMode, access.SameSite)
+	assert.Equal(t, "/", access.Path)
+	assert.Equal(t, "api.example.com", access.Domain)
+	assert.True(t, accessExpiry.Equal(access.Expires))
+
+	refresh := cookies[RefreshTokenCookie]
+	assert.True(t, refresh.HttpOnly)
+	assert.Equal(t, "/api/auth", refresh.Path)
+	assert.True(t, refreshExpiry.Equal(refresh.Expires))
+
+	// Le token CSRF doit rester lisible par la page pour être recopié dans l'en-tête
+	csrf := cookies[CSRFCookie]
+	assert.False(t, csrf.HttpOnly)
+	assert.NotEmpty(t, csrf.Value)
+	assert.True(t, refreshExpiry.Equal(csrf.Expires))
+}
+
+func TestClear(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	Config{Enabled: true}.Clear(rec)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 3)
+	for _, cookie := range cookies {
+		assert.Empty(t, cookie.Value)
+		assert.Equal(t, -1, cookie.MaxAge, cookie.Name)
+	}
+}
+
+func TestOIDCState(t *testing.T) {
+	// Arrange
+	config := Config{Secure: true, SameSite: http.SameSiteLaxMode}
+	expiresAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	rec := httptest.NewRecorder()
+
+	// Act
+	config.SetOIDCState(rec, "state-123", expiresAt)
+
+	// Assert : le cookie est posé même sans authentification par cookies
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	state := cookies[0]
+	assert.Equal(t, OIDCStateCookie, state.Name)
+	assert.True(t, state.HttpOnly)
+	assert.Equal(t, "/api/auth/oidc", state.Path)
+	assert.True(t, expiresAt.Equal(state.Expires))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/oidc/google/callback", nil)
+	assert.False(t, VerifyOIDCState(req, "state-123"), "sans cookie")
+	req.AddCookie(state)
+	assert.True(t, VerifyOIDCState(req, "state-123"))
+	assert.False(t, VerifyOIDCState(req, "state-456"))
+	assert.False(t, VerifyOIDCState(req, ""))
+
+	rec = httptest.NewRecorder()
+	config.ClearOIDCState(rec)
+	cleared := rec.Result().Cookies()
+	require.Len(t, cleared, 1)
+	assert.Empty(t, cleared[0].Value)
+	assert.Equal(t, -1, cleared[0].MaxAge)
+}
+
+func TestVerifyCSRF(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   Config
+		method   string
+		cookie   string
+		header   string
+		expected bool
+	}{
+		{"méthode sûre", Config{CSRF: true}, http.MethodGet, "", "", true},
+		{"protection désactivée", Config{}, http.MethodPost, "", "", true},
+		{"en-tête identique au cookie", Config{CSRF: true}, http.MethodPost, "abc", "abc", true},
+		{"en-tête manquant", Config{CSRF: true}, http.MethodDelete, "abc", "", false},
+		{"en-tête différent", Config{CSRF: true}, http.MethodPut, "abc", "abd", false},
+		{"cookie manquant", Config{CSRF: true}, http.MethodPost, "", "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/api/auth/logout", nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(CSRFHeader, tc.header)
+			}
+
+			assert.Equal(t, tc.expected, tc.config.VerifyCSRF(req))
+		})
+	}
+}
+
+func TestParseSameSite(t *testing.T) {
+	mode, err := ParseSameSite("Lax")
+	require.NoError(t, err)
+	assert.Equal(t, http.SameSiteLaxMode, mode)
+
+	_, err = ParseSameSite("sometimes")
+	assert.Error(t, err)
+}
@@ -0,0 +1,204 @@
+// Package avatar prépare les images de profil : détection du format à partir
+// du contenu, limites de dimensions, recadrage carré, redimensionnement aux
+// tailles standard et ré-encodage en JPEG, qui élimine les métadonnées EXIF
+// après application de leur orientation.
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/image/draw"
+
+	// Décodeurs des formats acceptés
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Sizes liste les côtés en pixels des images générées, de la plus petite à la plus grande
+var Sizes = []int{64, 128, 256}
+
+// DefaultSize est la taille exposée comme URL principale de l'avatar
+const DefaultSize = 256
+
+// ContentType est le type des images générées
+const ContentType = "image/jpeg"
+
+// MaxPixels borne la surface des images acceptées, vérifiée avant le
+// décodage pour refuser les images qui occuperaient trop de mémoire
+const MaxPixels = 5000 * 5000
+
+// jpegQuality est la qualité d'encodage des images générées
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("format d'image non supporté (JPEG, PNG, GIF ou WebP)")
+	ErrImageTooLarge     = errors.New("dimensions de l'image trop grandes")
+)
+
+// acceptedTypes liste les types détectés à partir du contenu
+var acceptedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// FileName retourne le nom du fichier d'une taille (ex. 128.jpg)
+func FileName(size int) string {
+	return strconv.Itoa(size) + ".jpg"
+}
+
+// Process décode l'image envoyée et retourne une image JPEG carrée par taille
+// de Sizes. Le type est détecté à partir du contenu, sans se fier au nom du
+// fichier ni à l'en-tête Content-Type de la requête.
+func Process(data []byte) (map[int][]byte, error) {
+	contentType := http.DetectContentType(data)
+	if !acceptedTypes[contentType] {
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedFormat, err)
+	}
+
+	orientation := 1
+	if contentType == "image/jpeg" {
+		orientation = exifOrientation(data)
+	}
+
+	crop := squareCrop(src.Bounds())
+	images := make(map[int][]byte, len(Sizes))
+	for _, size := range Sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		// Fond blanc sous les zones transparentes, absentes du JPEG
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, orient(dst, orientation), &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		images[size] = buf.Bytes()
+	}
+	return images, nil
+}
+
+// squareCrop retourne le plus grand carré centré dans les limites données
+func squareCrop(bounds image.Rectangle) image.Rectangle {
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// orient applique l'orientation EXIF (1 à 8) à une image carrée, dont les
+// dimensions sont inchangées par les rotations
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	n := src.Bounds().Dx()
+	dst := image.NewRGBA(src.Bounds())
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // miroir horizontal
+				dx, dy = n-1-x, y
+			case 3: // rotation de 180°
+				dx, dy = n-1-x, n-1-y
+			case 4: // miroir vertical
+				dx, dy = x, n-1-y
+			case 5: // transposition
+				dx, dy = y, x
+			case 6: // rotation de 90° dans le sens horaire
+				dx, dy = n-1-y, x
+			case 7: // transposition inverse
+				dx, dy = n-1-y, n-1-x
+			case 8: // rotation de 90° dans le sens antihoraire
+				dx, dy = y, n-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
+
+// exifOrientation lit le tag Orientation (0x0112) du segment EXIF d'un JPEG.
+// Retourne 1 (orientation normale) si le segment est absent ou illisible.
+func exifOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Parcours des segments jusqu'au début des données d'image (SOS)
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if marker == 0xDA || length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// tiffOrientation lit le tag Orientation dans le premier IFD d'un en-tête TIFF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			// Valeur SHORT stockée dans les deux premiers octets du champ valeur
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,118 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function pour créer une image dont la moitié haute est rouge et la
+// moitié basse bleue
+func twoToneImage(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if y >= height/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// Helper function pour insérer un segment EXIF portant l'orientation donnée
+// juste après le marqueur SOI d'un JPEG
+func withEXIFOrientation(t *testing.T, jpegData []byte, orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8)) // offset du premier IFD
+	binary.Write(&tiff, binary.BigEndian, uint16(1)) // une entrée
+	binary.Write(&tiff, binary.BigEndian, uint16(0x0112))
+	binary.Write(&tiff, binary.BigEndian, uint16(3)) // SHORT
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, orientation)
+	binary.Write(&tiff, binary.BigEndian, uint16(0))
+	binary.Write(&tiff, binary.BigEndian, uint32(0)) // pas d'IFD suivant
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var out bytes.Buffer
+	out.Write(jpegData[:2])
+	out.Write([]byte{0xFF, 0xE1})
+	require.NoError(t, binary.Write(&out, binary.BigEndian, uint16(len(payload)+2)))
+	out.Write(payload)
+	out.Write(jpegData[2:])
+	return out.Bytes()
+}
+
+// Helper function pour décoder une image générée
+func decodeJPEG(t *testing.T, data []byte) image.Image {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+func TestProcess_GeneratesSquareSizes(t *testing.T) {
+	// Arrange : image PNG rectangulaire
+	var src bytes.Buffer
+	require.NoError(t, png.Encode(&src, twoToneImage(300, 200)))
+
+	// Act
+	images, err := Process(src.Bytes())
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, images, len(Sizes))
+	for _, size := range Sizes {
+		img := decodeJPEG(t, images[size])
+		assert.Equal(t, image.Rect(0, 0, size, size), img.Bounds(), "taille %d", size)
+	}
+}
+
+func TestProcess_AppliesAndStripsEXIFOrientation(t *testing.T) {
+	// Arrange : orientation 6, l'image doit être tournée de 90° dans le sens horaire
+	var src bytes.Buffer
+	require.NoError(t, jpeg.Encode(&src, twoToneImage(100, 100), &jpeg.Options{Quality: 95}))
+	data := withEXIFOrientation(t, src.Bytes(), 6)
+	require.Equal(t, 6, exifOrientation(data))
+
+	// Act
+	images, err := Process(data)
+
+	// Assert : le haut rouge se retrouve à droite, le bas bleu à gauche
+	require.NoError(t, err)
+	out := images[64]
+	assert.NotContains(t, string(out), "Exif", "les métadonnées EXIF doivent être supprimées")
+	img := decodeJPEG(t, out)
+	left, _, leftBlue, _ := img.At(10, 32).RGBA()
+	right, _, rightBlue, _ := img.At(54, 32).RGBA()
+	assert.Greater(t, leftBlue, left, "la gauche doit être bleue")
+	assert.Greater(t, right, rightBlue, "la droite doit être rouge")
+}
+
+func TestProcess_RejectsUnsupportedContent(t *testing.T) {
+	_, err := Process([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+
+	// Un en-tête d'image valide suivi de données corrompues
+	_, err = Process([]byte("\x89PNG\r\n\x1a\n corrompu"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestProcess_RejectsOversizedDimensions(t *testing.T) {
+	// En-tête GIF déclarant une image de 65535 x 65535 pixels : refusée sans être décodée
+	header := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	_, err := Process(header)
+
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
@@ -0,0 +1,45 @@
+// Package blobstore stocke des fichiers binaires (avatars...) derrière une
+// interface commune, sur le système de fichiers local ou dans un bucket
+// compatible S3.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotFound est retourné par Get lorsque la clé n'existe pas
+var ErrNotFound = errors.New("blob introuvable")
+
+// ErrInvalidKey est retourné pour une clé vide, absolue ou contenant ".."
+var ErrInvalidKey = errors.New("clé de blob invalide")
+
+// BlobStore définit l'interface de stockage des fichiers. Les clés sont des
+// chemins relatifs séparés par des "/" (ex. avatars/<id>/128.jpg).
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// URL retourne l'adresse publique à laquelle le blob est servi
+	URL(key string) string
+}
+
+// validateKey refuse les clés qui sortiraient du répertoire ou du bucket
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// joinURL concatène une URL de base et une clé
+func joinURL(baseURL, key string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + key
+}
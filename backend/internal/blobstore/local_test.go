@@ -0,0 +1,43 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore_PutGetDelete(t *testing.T) {
+	// Arrange
+	store, err := NewLocalStore(t.TempDir(), "http://localhost:8080/blobs/")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, store.Put(ctx, "avatars/42/128.jpg", []byte("jpeg"), "image/jpeg"))
+	body, err := store.Get(ctx, "avatars/42/128.jpg")
+
+	// Assert
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jpeg"), data)
+	assert.Equal(t, "http://localhost:8080/blobs/avatars/42/128.jpg", store.URL("avatars/42/128.jpg"))
+
+	require.NoError(t, store.Delete(ctx, "avatars/42/128.jpg"))
+	_, err = store.Get(ctx, "avatars/42/128.jpg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, store.Delete(ctx, "avatars/42/128.jpg"), "supprimer une clé absente n'est pas une erreur")
+}
+
+func TestLocalStore_RejectsInvalidKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost:8080/blobs")
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "avatars/../../secret", "avatars//x", `avatars\x`} {
+		assert.ErrorIs(t, store.Put(context.Background(), key, []byte("x"), "text/plain"), ErrInvalidKey, key)
+	}
+}
@@ -1,22 +1,37 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config contient toute la configuration de l'application
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Kafka    KafkaConfig
+	Server    ServerConfig
+	Database  DatabaseConfig
+	JWT       JWTConfig
+	Auth      AuthConfig
+	Cookie    CookieConfig
+	Lockout   LockoutConfig
+	Password  PasswordConfig
+	RateLimit RateLimitConfig
+	Mail      MailConfig
+	Export    ExportConfig
+	Blob      BlobConfig
+	Avatar    AvatarConfig
+	OIDC      OIDCConfig
+	Kafka     KafkaConfig
 }
 
 // ServerConfig contient la configuration du serveur HTTP
 type ServerConfig struct {
-	Port string
-	Env  string // development, staging, production
+	Port        string
+	Env         string // development, staging, production
+	FrontendURL string // URL publique du frontend, utilisée dans les liens envoyés par email
+	PublicURL   string // URL publique de l'API, pour les liens qui y mènent directement (téléchargements)
 }
 
 // DatabaseConfig contient la configuration de la base de données
@@ -31,9 +46,192 @@ type DatabaseConfig struct {
 
 // JWTConfig contient la configuration JWT
 type JWTConfig struct {
-	Secret           string
-	AccessTokenTTL   int // en minutes
-	RefreshTokenTTL  int // en heures
+	Secret              string
+	Issuer              string // claim iss des tokens émis
+	Audience            string // claim aud des tokens émis
+	AccessTokenTTL      int    // en minutes
+	RefreshTokenTTL     int    // en heures, sessions ouvertes sans "se souvenir de moi"
+	RememberMeTTL       int    // en heures, sessions ouvertes avec "se souvenir de moi"
+	RefreshIdleTimeout  int    // en minutes, inactivité au-delà de laquelle une session sans "se souvenir de moi" expire
+	SigningAlgorithm    string // RS256 ou EdDSA
+	KeyEncryptionKey    string // clé de chiffrement des clés privées en base
+	KeyPropagationDelay int    // en minutes, délai avant qu'une nouvelle clé ne signe
+	KeyReloadInterval   int    // en secondes, fréquence de rechargement des clés
+	AcceptLegacyHS256   bool   // accepter les tokens HS256 émis avant la migration vers les clés asymétriques
+}
+
+// ErrPlaceholderKeyEncryptionKey est retournée lorsque les clés privées de
+// signature seraient chiffrées avec une valeur d'exemple connue de tous
+var ErrPlaceholderKeyEncryptionKey = errors.New("JWT_KEY_ENCRYPTION_KEY doit être définie avec une valeur secrète (JWT_SECRET par défaut, ici absent ou laissé à sa valeur d'exemple)")
+
+// CheckKeyEncryptionKey refuse une clé de chiffrement des clés privées vide ou
+// restée à une valeur d'exemple ("change-me...")
+func (c JWTConfig) CheckKeyEncryptionKey() error {
+	if isPlaceholderSecret(c.KeyEncryptionKey) {
+		return ErrPlaceholderKeyEncryptionKey
+	}
+	return nil
+}
+
+// ErrInvalidLinkSigningSecret est retournée lorsque les liens envoyés par email
+// pourraient être forgés : secret absent, d'exemple ou partagé avec JWT
+var ErrInvalidLinkSigningSecret = errors.New("LINK_SIGNING_SECRET doit être définie avec une valeur secrète propre, distincte de JWT_SECRET et de JWT_KEY_ENCRYPTION_KEY")
+
+// ErrPlaceholderMFAEncryptionKey est retournée lorsque les secrets TOTP
+// seraient chiffrés avec une valeur d'exemple connue de tous
+var ErrPlaceholderMFAEncryptionKey = errors.New("MFA_ENCRYPTION_KEY doit être définie avec une valeur secrète (JWT_SECRET par défaut, ici absent ou laissé à sa valeur d'exemple)")
+
+// CheckSecrets vérifie les secrets au démarrage de l'API : chacun doit être
+// défini, différent des valeurs d'exemple et, pour le secret des liens,
+// réservé à son usage. La clé TOTP peut rester celle de JWT_SECRET, avec
+// laquelle les secrets déjà enregistrés ont été chiffrés.
+func (c *Config) CheckSecrets() error {
+	if err := c.JWT.CheckKeyEncryptionKey(); err != nil {
+		return err
+	}
+	if isPlaceholderSecret(c.Auth.MFAEncryptionKey) {
+		return ErrPlaceholderMFAEncryptionKey
+	}
+	link := c.Auth.LinkSigningSecret
+	if isPlaceholderSecret(link) || link == c.JWT.Secret || link == c.JWT.KeyEncryptionKey {
+		return ErrInvalidLinkSigningSecret
+	}
+	return nil
+}
+
+// isPlaceholderSecret indique si un secret est vide ou resté à une valeur
+// d'exemple ("change-me...")
+func isPlaceholderSecret(secret string) bool {
+	return secret == "" || strings.HasPrefix(secret, "change-me")
+}
+
+// AuthConfig contient la configuration des flux d'authentification
+type AuthConfig struct {
+	PasswordResetTTL           int    // en minutes
+	MagicLinkTTL               int    // en minutes, validité d'un lien de connexion sans mot de passe
+	RequireEmailVerification   bool   // refuser la connexion des comptes non vérifiés
+	EmailVerificationTTL       int    // en heures
+	EmailChangeTTL             int    // en heures, validité du lien de confirmation d'une nouvelle adresse
+	DeletionGracePeriod        int    // en jours, délai avant la suppression définitive d'un compte
+	DeletionPurgeInterval      int    // en minutes, fréquence de la purge des comptes supprimés
+	VerificationResendInterval int    // en secondes
+	LinkSigningSecret          string // secret HMAC des liens envoyés par email
+	MFAIssuer                  string // nom affiché dans les applications d'authentification
+	MFAPendingTTL              int    // en minutes, délai pour saisir le code après le mot de passe
+	MFAEncryptionKey           string // clé de chiffrement des secrets TOTP en base
+	BootstrapAdminEmail        string // compte promu administrateur au démarrage tant qu'il n'en existe aucun
+}
+
+// CookieConfig contient la configuration du mode d'authentification par cookies
+type CookieConfig struct {
+	Enabled        bool   // tokens posés en cookies HttpOnly au lieu d'être retournés dans les réponses
+	Domain         string // domaine des cookies, vide pour les limiter à l'hôte de l'API
+	Secure         bool   // cookies transmis uniquement en HTTPS
+	SameSite       string // strict, lax ou none
+	CSRFProtection bool   // exiger le token CSRF en double soumission sur les requêtes modifiantes
+}
+
+// LockoutConfig contient la configuration de la protection contre la force brute
+type LockoutConfig struct {
+	MaxAccountFailures int // échecs avant verrouillage du compte
+	MaxIPFailures      int // échecs avant blocage de l'adresse IP
+	FreeAttempts       int // échecs tolérés avant les délais progressifs
+	BaseDelay          int // en secondes, premier délai imposé
+	MaxDelay           int // en secondes, plafond des délais progressifs
+	Window             int // en minutes, fenêtre d'observation des échecs
+	Duration           int // en minutes, durée du verrouillage
+}
+
+// PasswordConfig contient la politique de mots de passe
+type PasswordConfig struct {
+	MinLength         int
+	MaxLength         int
+	RequireLowercase  bool
+	RequireUppercase  bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	ForbidEmail       bool
+	BreachedList      string // fichier ou répertoire de plages au format HIBP, vide pour ne pas vérifier
+	Argon2Memory      int    // en Kio
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+// RateLimitConfig contient la configuration de la limitation de débit de l'API
+type RateLimitConfig struct {
+	Enabled  bool
+	Store    string        // memory ou postgres (compteurs partagés entre instances)
+	Register RateLimitRule // par IP, sur /api/auth/register
+	Login    RateLimitRule // par IP, sur la connexion (mot de passe, 2FA, fournisseurs)
+	Recovery RateLimitRule // par IP, sur les liens envoyés par email
+	Public   RateLimitRule // par IP, sur les autres routes publiques
+	Auth     RateLimitRule // par IP, sur les routes protégées, avant l'authentification
+	API      RateLimitRule // par utilisateur, sur les routes protégées
+}
+
+// RateLimitRule est un nombre de requêtes autorisées par fenêtre, au format
+// "<limite>/<durée>" dans l'environnement (ex. "10/1m")
+type RateLimitRule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// MailConfig contient la configuration d'envoi des emails
+type MailConfig struct {
+	Driver       string // smtp ou file
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	OutboxDir    string // dossier des emails pour le driver file
+}
+
+// ExportConfig contient la configuration de l'export des données personnelles
+type ExportConfig struct {
+	Dir            string // dossier de stockage des archives
+	Retention      int    // en heures, validité du lien de téléchargement
+	WorkerInterval int    // en secondes, fréquence de construction des archives demandées
+}
+
+// BlobConfig contient la configuration du stockage des fichiers (avatars)
+type BlobConfig struct {
+	Driver      string // local ou s3
+	Dir         string // dossier de stockage du driver local
+	PublicURL   string // base des URL publiques des fichiers, vide pour la valeur par défaut du driver
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// AvatarConfig contient la configuration des avatars
+type AvatarConfig struct {
+	MaxSize int // en Kio, taille maximale de l'image envoyée
+}
+
+// OIDCConfig contient la configuration de la connexion via des fournisseurs d'identité
+type OIDCConfig struct {
+	RedirectBaseURL string // les fournisseurs redirigent vers {RedirectBaseURL}/{provider}/callback
+	RequestTTL      int    // en minutes, durée de validité d'une demande d'autorisation
+	Providers       []OIDCProviderConfig
+}
+
+// OIDCProviderConfig décrit un fournisseur d'identité. Issuer suffit pour un
+// fournisseur OpenID Connect ; les endpoints explicites permettent de configurer
+// un fournisseur OAuth2 sans découverte (GitHub).
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	TrustEmail   bool // le fournisseur ne communique que des adresses vérifiées
 }
 
 // KafkaConfig contient la configuration Kafka
@@ -44,10 +242,16 @@ type KafkaConfig struct {
 
 // Load charge la configuration depuis les variables d'environnement
 func Load() (*Config, error) {
+	jwtSecret := getEnv("JWT_SECRET", "change-me-in-production")
+	frontendURL := getEnv("FRONTEND_URL", "http://localhost:3000")
+	port := getEnv("PORT", "8080")
+
 	config := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:        port,
+			Env:         getEnv("ENV", "development"),
+			FrontendURL: frontendURL,
+			PublicURL:   getEnv("API_PUBLIC_URL", "http://localhost:"+port),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -58,9 +262,104 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret:          getEnv("JWT_SECRET", "change-me-in-production"),
-			AccessTokenTTL:  getEnvAsInt("JWT_ACCESS_TTL", 15),
-			RefreshTokenTTL: getEnvAsInt("JWT_REFRESH_TTL", 168), // 7 jours
+			Secret:              jwtSecret,
+			Issuer:              getEnv("JWT_ISSUER", "collec-app"),
+			Audience:            getEnv("JWT_AUDIENCE", "collec-app-api"),
+			AccessTokenTTL:      getEnvAsInt("JWT_ACCESS_TTL", 15),
+			RefreshTokenTTL:     getEnvAsInt("JWT_REFRESH_TTL", 24),
+			RememberMeTTL:       getEnvAsInt("JWT_REFRESH_TTL_REMEMBER_ME", 720), // 30 jours
+			RefreshIdleTimeout:  getEnvAsInt("JWT_REFRESH_IDLE_TIMEOUT", 120),
+			SigningAlgorithm:    getEnv("JWT_SIGNING_ALG", "RS256"),
+			KeyEncryptionKey:    getEnv("JWT_KEY_ENCRYPTION_KEY", jwtSecret),
+			KeyPropagationDelay: getEnvAsInt("JWT_KEY_PROPAGATION_DELAY", 15),
+			KeyReloadInterval:   getEnvAsInt("JWT_KEY_RELOAD_INTERVAL", 60),
+			AcceptLegacyHS256:   getEnvAsBool("JWT_ACCEPT_LEGACY_HS256", false),
+		},
+		Auth: AuthConfig{
+			PasswordResetTTL:           getEnvAsInt("PASSWORD_RESET_TTL", 60),
+			MagicLinkTTL:               getEnvAsInt("MAGIC_LINK_TTL", 15),
+			RequireEmailVerification:   getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationTTL:       getEnvAsInt("EMAIL_VERIFICATION_TTL", 48),
+			EmailChangeTTL:             getEnvAsInt("EMAIL_CHANGE_TTL", 24),
+			DeletionGracePeriod:        getEnvAsInt("ACCOUNT_DELETION_GRACE_PERIOD", 30),
+			DeletionPurgeInterval:      getEnvAsInt("ACCOUNT_DELETION_PURGE_INTERVAL", 60),
+			VerificationResendInterval: getEnvAsInt("EMAIL_VERIFICATION_RESEND_INTERVAL", 60),
+			LinkSigningSecret:          getEnv("LINK_SIGNING_SECRET", ""),
+			MFAIssuer:                  getEnv("MFA_ISSUER", "Collec-App"),
+			MFAPendingTTL:              getEnvAsInt("MFA_PENDING_TTL", 5),
+			MFAEncryptionKey:           getEnv("MFA_ENCRYPTION_KEY", jwtSecret),
+			BootstrapAdminEmail:        getEnv("BOOTSTRAP_ADMIN_EMAIL", ""),
+		},
+		Cookie: CookieConfig{
+			Enabled:        getEnvAsBool("AUTH_COOKIE_MODE", false),
+			Domain:         getEnv("AUTH_COOKIE_DOMAIN", ""),
+			Secure:         getEnvAsBool("AUTH_COOKIE_SECURE", true),
+			SameSite:       getEnv("AUTH_COOKIE_SAMESITE", "strict"),
+			CSRFProtection: getEnvAsBool("AUTH_CSRF_PROTECTION", true),
+		},
+		Lockout: LockoutConfig{
+			MaxAccountFailures: getEnvAsInt("LOGIN_MAX_ACCOUNT_FAILURES", 10),
+			MaxIPFailures:      getEnvAsInt("LOGIN_MAX_IP_FAILURES", 50),
+			FreeAttempts:       getEnvAsInt("LOGIN_FREE_ATTEMPTS", 3),
+			BaseDelay:          getEnvAsInt("LOGIN_BASE_DELAY", 1),
+			MaxDelay:           getEnvAsInt("LOGIN_MAX_DELAY", 60),
+			Window:             getEnvAsInt("LOGIN_FAILURE_WINDOW", 15),
+			Duration:           getEnvAsInt("LOGIN_LOCKOUT_DURATION", 15),
+		},
+		Password: PasswordConfig{
+			MinLength:         getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			MaxLength:         getEnvAsInt("PASSWORD_MAX_LENGTH", 128),
+			RequireLowercase:  getEnvAsBool("PASSWORD_REQUIRE_LOWERCASE", false),
+			RequireUppercase:  getEnvAsBool("PASSWORD_REQUIRE_UPPERCASE", false),
+			RequireDigit:      getEnvAsBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:     getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			ForbidEmail:       getEnvAsBool("PASSWORD_FORBID_EMAIL", true),
+			BreachedList:      getEnv("PASSWORD_BREACHED_LIST", ""),
+			Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 4),
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:  getEnvAsBool("RATE_LIMIT_ENABLED", true),
+			Store:    getEnv("RATE_LIMIT_STORE", "memory"),
+			Register: getEnvAsRateLimit("RATE_LIMIT_REGISTER", RateLimitRule{Limit: 5, Window: time.Hour}),
+			Login:    getEnvAsRateLimit("RATE_LIMIT_LOGIN", RateLimitRule{Limit: 20, Window: time.Minute}),
+			Recovery: getEnvAsRateLimit("RATE_LIMIT_RECOVERY", RateLimitRule{Limit: 5, Window: 15 * time.Minute}),
+			Public:   getEnvAsRateLimit("RATE_LIMIT_PUBLIC", RateLimitRule{Limit: 60, Window: time.Minute}),
+			Auth:     getEnvAsRateLimit("RATE_LIMIT_AUTH", RateLimitRule{Limit: 600, Window: time.Minute}),
+			API:      getEnvAsRateLimit("RATE_LIMIT_API", RateLimitRule{Limit: 300, Window: time.Minute}),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "file"),
+			From:         getEnv("MAIL_FROM", "Collec-App <no-reply@collec-app.local>"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			OutboxDir:    getEnv("MAIL_OUTBOX_DIR", "tmp/outbox"),
+		},
+		Export: ExportConfig{
+			Dir:            getEnv("EXPORT_DIR", "tmp/exports"),
+			Retention:      getEnvAsInt("EXPORT_RETENTION", 72),
+			WorkerInterval: getEnvAsInt("EXPORT_WORKER_INTERVAL", 30),
+		},
+		Blob: BlobConfig{
+			Driver:      getEnv("BLOB_DRIVER", "local"),
+			Dir:         getEnv("BLOB_DIR", "tmp/blobs"),
+			PublicURL:   getEnv("BLOB_PUBLIC_URL", ""),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+		},
+		Avatar: AvatarConfig{
+			MaxSize: getEnvAsInt("AVATAR_MAX_SIZE", 5*1024),
+		},
+		OIDC: OIDCConfig{
+			RedirectBaseURL: getEnv("OIDC_REDIRECT_BASE_URL", frontendURL+"/auth/oidc"),
+			RequestTTL:      getEnvAsInt("OIDC_REQUEST_TTL", 10),
+			Providers:       loadOIDCProviders(),
 		},
 		Kafka: KafkaConfig{
 			Brokers: []string{getEnv("KAFKA_BROKER", "localhost:9092")},
@@ -71,6 +370,28 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// loadOIDCProviders lit les fournisseurs listés dans OIDC_PROVIDERS. Chaque
+// fournisseur est configuré par les variables OIDC_<NOM>_*.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvAsList("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnvAsList(prefix+"SCOPES", nil),
+			AuthURL:      getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  getEnv(prefix+"USERINFO_URL", ""),
+			JWKSURL:      getEnv(prefix+"JWKS_URL", ""),
+			TrustEmail:   getEnvAsBool(prefix+"TRUST_EMAIL", false),
+		})
+	}
+	return providers
+}
+
 // Helper functions pour lire les variables d'environnement
 
 func getEnv(key, defaultValue string) string {
@@ -95,3 +416,29 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	return strings.FieldsFunc(valueStr, func(r rune) bool { return r == ',' || r == ' ' })
+}
+
+func getEnvAsRateLimit(key string, defaultValue RateLimitRule) RateLimitRule {
+	limitStr, windowStr, found := strings.Cut(os.Getenv(key), "/")
+	if !found {
+		return defaultValue
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil {
+		return defaultValue
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+	if err != nil || window <= 0 {
+		return defaultValue
+	}
+	return RateLimitRule{Limit: limit, Window: window}
+}
@@ -0,0 +1,23 @@
+package dto
+
+// ChangePasswordRequest représente le changement de mot de passe d'un utilisateur connecté
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+// ChangeEmailRequest représente une demande de changement d'adresse email
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"newEmail" validate:"required,email"`
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
+
+// DeleteAccountRequest représente la suppression du compte de l'utilisateur connecté
+type DeleteAccountRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+}
+
+// ConfirmEmailChangeRequest représente la validation d'un lien de confirmation de nouvelle adresse
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
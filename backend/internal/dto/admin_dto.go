@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+)
+
+// AdminUserDTO représente un utilisateur tel qu'exposé aux administrateurs
+type AdminUserDTO struct {
+	ID                    uuid.UUID  `json:"id"`
+	Email                 string     `json:"email"`
+	EmailVerified         bool       `json:"emailVerified"`
+	MFAEnabled            bool       `json:"mfaEnabled"`
+	Disabled              bool       `json:"disabled"`
+	DisabledAt            *time.Time `json:"disabledAt,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+	DeletionRequestedAt   *time.Time `json:"deletionRequestedAt,omitempty"`
+	Roles                 []string   `json:"roles,omitempty"` // renseignés dans le détail d'un utilisateur
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             time.Time  `json:"updatedAt"`
+}
+
+// ToAdminUserDTO convertit un modèle User en AdminUserDTO
+func ToAdminUserDTO(user *models.User, roles []string) AdminUserDTO {
+	return AdminUserDTO{
+		ID:                    user.ID,
+		Email:                 user.Email,
+		EmailVerified:         user.EmailVerifiedAt != nil,
+		MFAEnabled:            user.TOTPEnabledAt != nil,
+		Disabled:              user.DisabledAt != nil,
+		DisabledAt:            user.DisabledAt,
+		PasswordResetRequired: user.PasswordResetRequired,
+		DeletionRequestedAt:   user.DeletionRequestedAt,
+		Roles:                 roles,
+		CreatedAt:             user.CreatedAt,
+		UpdatedAt:             user.UpdatedAt,
+	}
+}
+
+// AdminUserListResponse représente une page de la liste des utilisateurs
+type AdminUserListResponse struct {
+	Users    []AdminUserDTO `json:"users"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"pageSize"`
+}
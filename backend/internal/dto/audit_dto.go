@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+)
+
+// AuditEventDTO représente un événement du journal d'audit
+type AuditEventDTO struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"userId,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	Event     string     `json:"event"`
+	Outcome   string     `json:"outcome"`
+	ErrorCode string     `json:"errorCode,omitempty"`
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"userAgent"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// ToAuditEventDTO convertit un modèle AuditEvent en AuditEventDTO
+func ToAuditEventDTO(event *models.AuditEvent) AuditEventDTO {
+	return AuditEventDTO{
+		ID:        event.ID,
+		UserID:    event.UserID,
+		Email:     event.Email,
+		Event:     event.Event,
+		Outcome:   event.Outcome,
+		ErrorCode: event.ErrorCode,
+		IP:        event.IP,
+		UserAgent: event.UserAgent,
+		CreatedAt: event.CreatedAt,
+	}
+}
+
+// AuditEventListResponse représente une page du journal d'audit
+type AuditEventListResponse struct {
+	Events   []AuditEventDTO `json:"events"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+}
@@ -1,8 +1,10 @@
 package dto
 
 import (
+	"strconv"
 	"time"
 
+	"github.com/arnaud-dars/collec-app/internal/avatar"
 	"github.com/arnaud-dars/collec-app/internal/models"
 	"github.com/google/uuid"
 )
@@ -10,39 +12,140 @@ import (
 // RegisterRequest représente les données d'inscription
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	Password string `json:"password" validate:"required"`
 }
 
 // LoginRequest représente les données de connexion
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	RememberMe bool   `json:"rememberMe"` // session longue, sans expiration après inactivité
+}
+
+// MFALoginRequest représente la seconde étape d'une connexion avec double authentification
+type MFALoginRequest struct {
+	MFAToken string `json:"mfaToken" validate:"required"`
+	Code     string `json:"code" validate:"required"`
 }
 
-// RefreshTokenRequest représente la demande de refresh token
+// RefreshTokenRequest représente la demande de refresh token.
+// En mode cookies, le body peut être omis : le refresh token est lu dans son cookie.
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refreshToken" validate:"required"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// LogoutRequest représente la demande de déconnexion.
+// Le refresh token est optionnel : s'il est fourni, sa session est aussi révoquée.
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshTokenResponse représente la nouvelle paire de tokens après rotation.
+// Les tokens sont absents en mode cookies, où ils sont posés en cookies HttpOnly.
+type RefreshTokenResponse struct {
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+// ForgotPasswordRequest représente une demande de lien de réinitialisation
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest représente la réinitialisation du mot de passe
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// MagicLinkRequest représente une demande de lien de connexion sans mot de passe
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// MagicLinkLoginRequest représente la connexion par un lien reçu par email
+type MagicLinkLoginRequest struct {
+	Token      string `json:"token" validate:"required"`
+	RememberMe bool   `json:"rememberMe"` // session longue, sans expiration après inactivité
+}
+
+// VerifyEmailRequest représente la validation d'un lien de vérification
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest représente une demande de renvoi du lien de vérification
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
 }
 
 // AuthResponse représente la réponse après inscription ou connexion réussie
+// Les tokens sont absents après une inscription lorsque la vérification de l'email est exigée,
+// et en mode cookies, où ils sont posés en cookies HttpOnly
 type AuthResponse struct {
-	AccessToken  string  `json:"accessToken"`
-	RefreshToken string  `json:"refreshToken"`
+	AccessToken  string  `json:"accessToken,omitempty"`
+	RefreshToken string  `json:"refreshToken,omitempty"`
 	User         UserDTO `json:"user"`
 }
 
+// MFARequiredResponse représente la réponse d'une connexion dont le second facteur reste à fournir
+type MFARequiredResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
+
 // UserDTO représente les données publiques d'un utilisateur
 type UserDTO struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID            uuid.UUID         `json:"id"`
+	Email         string            `json:"email"`
+	EmailVerified bool              `json:"emailVerified"`
+	MFAEnabled    bool              `json:"mfaEnabled"`
+	DisplayName   string            `json:"displayName"`
+	Username      *string           `json:"username"`
+	Bio           string            `json:"bio"`
+	Locale        string            `json:"locale"`
+	Timezone      string            `json:"timezone"`
+	Currency      string            `json:"currency"`
+	AvatarURL     string            `json:"avatarUrl,omitempty"`  // image de taille avatar.DefaultSize
+	AvatarURLs    map[string]string `json:"avatarUrls,omitempty"` // toutes les tailles, indexées par côté en pixels
+	CreatedAt     time.Time         `json:"createdAt"`
 }
 
 // ToUserDTO convertit un modèle User en UserDTO
 func ToUserDTO(user *models.User) UserDTO {
 	return UserDTO{
-		ID:        user.ID,
-		Email:     user.Email,
-		CreatedAt: user.CreatedAt,
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		MFAEnabled:    user.TOTPEnabledAt != nil,
+		DisplayName:   user.DisplayName,
+		Username:      user.Username,
+		Bio:           user.Bio,
+		Locale:        user.Locale,
+		Timezone:      user.Timezone,
+		Currency:      user.Currency,
+		AvatarURL:     avatarURL(user, avatar.DefaultSize),
+		AvatarURLs:    avatarURLs(user),
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+// avatarURL retourne l'URL d'une taille de l'avatar, vide sans avatar
+func avatarURL(user *models.User, size int) string {
+	if user.AvatarURL == "" {
+		return ""
+	}
+	return user.AvatarURL + "/" + avatar.FileName(size)
+}
+
+// avatarURLs retourne les URL de toutes les tailles de l'avatar, nil sans avatar
+func avatarURLs(user *models.User) map[string]string {
+	if user.AvatarURL == "" {
+		return nil
+	}
+	urls := make(map[string]string, len(avatar.Sizes))
+	for _, size := range avatar.Sizes {
+		urls[strconv.Itoa(size)] = avatarURL(user, size)
 	}
+	return urls
 }
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+)
+
+// DataExportDTO représente l'état d'une demande d'export des données personnelles
+type DataExportDTO struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+}
+
+// ToDataExportDTO convertit un modèle DataExport en DataExportDTO
+func ToDataExportDTO(export *models.DataExport, downloadURL string) DataExportDTO {
+	return DataExportDTO{
+		ID:          export.ID,
+		Status:      export.Status,
+		Size:        export.Size,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		DownloadURL: downloadURL,
+	}
+}
@@ -0,0 +1,29 @@
+package dto
+
+// MFACodeRequest représente une opération confirmée par un code de double authentification
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableMFARequest représente la désactivation de la double authentification
+type DisableMFARequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAStatusResponse représente l'état de la double authentification
+type MFAStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RemainingRecoveryCodes int64 `json:"remainingRecoveryCodes"`
+}
+
+// TOTPEnrollmentResponse contient le secret à configurer dans l'application d'authentification
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// RecoveryCodesResponse contient les codes de secours, affichés une seule fois
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// OIDCCallbackRequest représente le retour du fournisseur d'identité, transmis
+// par le frontend depuis son URL de redirection. Le state doit correspondre au
+// cookie posé par l'autorisation : la requête est envoyée avec les cookies.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// OIDCAuthorizationResponse contient l'URL d'autorisation du fournisseur. Le
+// state est aussi posé dans un cookie HttpOnly, vérifié par l'API au retour.
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+	State            string `json:"state"`
+}
+
+// OIDCProvidersResponse liste les fournisseurs d'identité disponibles
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+)
+
+// CreatePersonalAccessTokenRequest représente une demande de création d'un personal access token
+type CreatePersonalAccessTokenRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expiresAt"` // facultative : sans expiration si absente
+}
+
+// PersonalAccessTokenDTO représente un personal access token, sans sa valeur
+type PersonalAccessTokenDTO struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatePersonalAccessTokenResponse représente un token nouvellement créé.
+// La valeur du token n'est retournée qu'à cette occasion.
+type CreatePersonalAccessTokenResponse struct {
+	Token string `json:"token"`
+	PersonalAccessTokenDTO
+}
+
+// ToPersonalAccessTokenDTO convertit un modèle PersonalAccessToken en PersonalAccessTokenDTO
+func ToPersonalAccessTokenDTO(token *models.PersonalAccessToken) PersonalAccessTokenDTO {
+	return PersonalAccessTokenDTO{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.ScopeList(),
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
@@ -0,0 +1,13 @@
+package dto
+
+// UpdateProfileRequest représente une modification partielle du profil de
+// l'utilisateur connecté. Un champ absent est conservé, une chaîne vide
+// l'efface. Le format de l'identifiant est vérifié par le service.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"displayName" validate:"omitempty,max=100"`
+	Username    *string `json:"username" validate:"omitempty,max=30"`
+	Bio         *string `json:"bio" validate:"omitempty,max=500"`
+	Locale      *string `json:"locale" validate:"omitempty,bcp47_language_tag"`
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone"`
+	Currency    *string `json:"currency" validate:"omitempty,iso4217"`
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+)
+
+// SessionDTO représente une session active telle qu'exposée à l'utilisateur
+type SessionDTO struct {
+	ID         uuid.UUID `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	RememberMe bool      `json:"rememberMe"`
+	Current    bool      `json:"current"`
+}
+
+// ToSessionDTO convertit un modèle Session en SessionDTO
+func ToSessionDTO(session *models.Session, currentSessionID uuid.UUID) SessionDTO {
+	return SessionDTO{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		RememberMe: session.RememberMe,
+		Current:    session.ID == currentSessionID,
+	}
+}
@@ -55,6 +55,76 @@ var (
 		Message:    "Token expiré",
 		StatusCode: http.StatusUnauthorized,
 	}
+	ErrTokenRevoked = &AppError{
+		Code:       "ERR_AUTH_004",
+		Message:    "Token révoqué",
+		StatusCode: http.StatusUnauthorized,
+	}
+	ErrInvalidResetToken = &AppError{
+		Code:       "ERR_AUTH_005",
+		Message:    "Lien de réinitialisation invalide ou expiré",
+		StatusCode: http.StatusBadRequest,
+	}
+	ErrEmailNotVerified = &AppError{
+		Code:       "ERR_AUTH_006",
+		Message:    "Adresse email non vérifiée",
+		StatusCode: http.StatusForbidden,
+	}
+	ErrInvalidVerificationToken = &AppError{
+		Code:       "ERR_AUTH_007",
+		Message:    "Lien de vérification invalide ou expiré",
+		StatusCode: http.StatusBadRequest,
+	}
+	ErrInvalidMFACode = &AppError{
+		Code:       "ERR_AUTH_008",
+		Message:    "Code de double authentification invalide",
+		StatusCode: http.StatusUnauthorized,
+	}
+	ErrMFAConflict = &AppError{
+		Code:       "ERR_AUTH_009",
+		Message:    "Opération incompatible avec l'état de la double authentification",
+		StatusCode: http.StatusConflict,
+	}
+	ErrExternalLogin = &AppError{
+		Code:       "ERR_AUTH_010",
+		Message:    "Connexion via le fournisseur d'identité impossible",
+		StatusCode: http.StatusUnauthorized,
+	}
+	ErrAccountLocked = &AppError{
+		Code:       "ERR_AUTH_011",
+		Message:    "Compte temporairement verrouillé après trop d'échecs de connexion",
+		StatusCode: http.StatusLocked,
+	}
+	ErrTooManyLoginAttempts = &AppError{
+		Code:       "ERR_AUTH_012",
+		Message:    "Trop de tentatives de connexion, réessayez plus tard",
+		StatusCode: http.StatusTooManyRequests,
+	}
+	ErrInvalidEmailChangeToken = &AppError{
+		Code:       "ERR_AUTH_013",
+		Message:    "Lien de confirmation invalide ou expiré",
+		StatusCode: http.StatusBadRequest,
+	}
+	ErrAccountDisabled = &AppError{
+		Code:       "ERR_AUTH_014",
+		Message:    "Compte désactivé, contactez un administrateur",
+		StatusCode: http.StatusForbidden,
+	}
+	ErrPasswordResetRequired = &AppError{
+		Code:       "ERR_AUTH_015",
+		Message:    "Vous devez réinitialiser votre mot de passe avant de vous connecter",
+		StatusCode: http.StatusForbidden,
+	}
+	ErrInvalidCSRFToken = &AppError{
+		Code:       "ERR_AUTH_016",
+		Message:    "Token CSRF manquant ou invalide",
+		StatusCode: http.StatusForbidden,
+	}
+	ErrInvalidMagicLink = &AppError{
+		Code:       "ERR_AUTH_017",
+		Message:    "Lien de connexion invalide ou expiré",
+		StatusCode: http.StatusUnauthorized,
+	}
 )
 
 // Erreurs de validation
@@ -69,6 +139,11 @@ var (
 		Message:    "Données d'entrée invalides",
 		StatusCode: http.StatusBadRequest,
 	}
+	ErrWeakPassword = &AppError{
+		Code:       "ERR_VAL_003",
+		Message:    "Le mot de passe ne respecte pas la politique de sécurité",
+		StatusCode: http.StatusBadRequest,
+	}
 )
 
 // Erreurs de base de données
@@ -90,6 +165,57 @@ var (
 	}
 )
 
+// Erreurs d'export des données personnelles
+var (
+	ErrExportInProgress = &AppError{
+		Code:       "ERR_EXP_001",
+		Message:    "Un export de vos données est déjà en cours",
+		StatusCode: http.StatusConflict,
+	}
+	ErrInvalidExportLink = &AppError{
+		Code:       "ERR_EXP_002",
+		Message:    "Lien de téléchargement invalide ou expiré",
+		StatusCode: http.StatusGone,
+	}
+)
+
+// Erreurs de profil utilisateur
+var (
+	ErrUsernameTaken = &AppError{
+		Code:       "ERR_USR_001",
+		Message:    "Cet identifiant est déjà utilisé",
+		StatusCode: http.StatusConflict,
+	}
+	ErrAvatarTooLarge = &AppError{
+		Code:       "ERR_USR_002",
+		Message:    "Image trop volumineuse",
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+	ErrInvalidAvatar = &AppError{
+		Code:       "ERR_USR_003",
+		Message:    "Format d'image non supporté (JPEG, PNG, GIF ou WebP)",
+		StatusCode: http.StatusUnsupportedMediaType,
+	}
+)
+
+// Erreurs d'administration des comptes
+var (
+	ErrSelfAdministration = &AppError{
+		Code:       "ERR_ADM_001",
+		Message:    "Cette opération ne peut pas viser votre propre compte",
+		StatusCode: http.StatusConflict,
+	}
+)
+
+// Erreurs de limitation de débit
+var (
+	ErrRateLimited = &AppError{
+		Code:       "ERR_RATE_001",
+		Message:    "Trop de requêtes, réessayez plus tard",
+		StatusCode: http.StatusTooManyRequests,
+	}
+)
+
 // Erreurs génériques
 var (
 	ErrInternal = &AppError{
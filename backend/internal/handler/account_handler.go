@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// AccountHandler gère les endpoints de modification du mot de passe et de
+// l'adresse email, et de suppression du compte
+type AccountHandler struct {
+	accountService service.AccountService
+	validate       *validator.Validate
+}
+
+// NewAccountHandler crée une nouvelle instance de AccountHandler
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+		validate:       validator.New(),
+	}
+}
+
+// ChangePassword remplace le mot de passe de l'utilisateur connecté et
+// déconnecte ses autres appareils
+// POST /api/auth/password/change (route protégée)
+func (h *AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.accountService.ChangePassword(claims.UserID, claims.SessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		if respondWithPasswordPolicyError(w, err) {
+			return
+		}
+		h.respondWithAccountError(w, err, "Erreur lors du changement de mot de passe")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Mot de passe modifié, vos autres appareils ont été déconnectés",
+	})
+}
+
+// ChangeEmail envoie un lien de confirmation à la nouvelle adresse de
+// l'utilisateur connecté
+// POST /api/auth/email/change (route protégée)
+func (h *AccountHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.ChangeEmailRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.accountService.RequestEmailChange(claims.UserID, req.CurrentPassword, req.NewEmail); err != nil {
+		h.respondWithAccountError(w, err, "Erreur lors de la demande de changement d'email")
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Un lien de confirmation a été envoyé à la nouvelle adresse",
+	})
+}
+
+// ConfirmEmailChange applique le changement d'adresse confirmé par le lien
+// POST /api/auth/email/change/confirm
+func (h *AccountHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req dto.ConfirmEmailChangeRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	user, err := h.accountService.ConfirmEmailChange(req.Token)
+	if err != nil {
+		h.respondWithAccountError(w, err, "Erreur lors du changement d'email")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
+}
+
+// DeleteAccount programme la suppression du compte de l'utilisateur connecté et
+// le déconnecte de tous ses appareils
+// DELETE /api/auth/me (route protégée)
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.DeleteAccountRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	purgeAt, err := h.accountService.DeleteAccount(claims.UserID, req.CurrentPassword)
+	if err != nil {
+		h.respondWithAccountError(w, err, "Erreur lors de la suppression du compte")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"message":   "Compte supprimé, reconnectez-vous avant la date indiquée pour annuler la suppression",
+		"deletesAt": purgeAt,
+	})
+}
+
+// decode décode et valide le body JSON de la requête
+func (h *AccountHandler) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return false
+	}
+
+	return true
+}
+
+// respondWithAccountError convertit les erreurs du service de compte en réponses HTTP
+func (h *AccountHandler) respondWithAccountError(w http.ResponseWriter, err error, internalMessage string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidCredentials):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrInvalidCredentials.Code, "Mot de passe incorrect", err)
+	case errors.Is(err, service.ErrEmailAlreadyExists):
+		respondWithError(w, http.StatusConflict, appErrors.ErrDuplicate.Code, "Cet email est déjà utilisé", err)
+	case errors.Is(err, service.ErrEmailUnchanged):
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, err.Error(), err)
+	case errors.Is(err, service.ErrInvalidEmailChangeToken):
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidEmailChangeToken.Code, appErrors.ErrInvalidEmailChangeToken.Message, err)
+	case errors.Is(err, service.ErrInvalidToken):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", internalMessage, err)
+	}
+}
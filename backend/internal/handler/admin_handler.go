@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/google/uuid"
+)
+
+// AdminHandler gère les endpoints d'administration des comptes utilisateurs
+type AdminHandler struct {
+	adminService service.AdminService
+}
+
+// NewAdminHandler crée une nouvelle instance de AdminHandler
+func NewAdminHandler(adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// ListUsers retourne une page d'utilisateurs, filtrée par les paramètres
+// search (fragment d'email), status, role, page et pageSize
+// GET /api/admin/users (route d'administration)
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, ok := intQueryParam(w, query.Get("page"), "Numéro de page invalide")
+	if !ok {
+		return
+	}
+	pageSize, ok := intQueryParam(w, query.Get("pageSize"), "Taille de page invalide")
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.ListUsers(query.Get("search"), query.Get("status"), query.Get("role"), page, pageSize)
+	if err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la récupération des utilisateurs")
+		return
+	}
+
+	response := dto.AdminUserListResponse{
+		Users:    make([]dto.AdminUserDTO, 0, len(result.Users)),
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}
+	for i := range result.Users {
+		response.Users = append(response.Users, dto.ToAdminUserDTO(&result.Users[i], nil))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetUser retourne le détail d'un utilisateur et ses rôles
+// GET /api/admin/users/{id} (route d'administration)
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := h.adminService.GetUser(userID)
+	if err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la récupération de l'utilisateur")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToAdminUserDTO(details.User, details.Roles))
+}
+
+// ListSessions retourne les sessions actives d'un utilisateur
+// GET /api/admin/users/{id}/sessions (route d'administration)
+func (h *AdminHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.adminService.ListSessions(userID)
+	if err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la récupération des sessions")
+		return
+	}
+
+	response := make([]dto.SessionDTO, 0, len(sessions))
+	for i := range sessions {
+		response = append(response, dto.ToSessionDTO(&sessions[i], uuid.Nil))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// DisableUser désactive un compte et le déconnecte de tous ses appareils
+// POST /api/admin/users/{id}/disable (route d'administration)
+func (h *AdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.DisableUser(claims.UserID, userID); err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la désactivation du compte")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Compte désactivé",
+	})
+}
+
+// EnableUser réactive un compte désactivé
+// POST /api/admin/users/{id}/enable (route d'administration)
+func (h *AdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.EnableUser(userID); err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la réactivation du compte")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Compte réactivé",
+	})
+}
+
+// ForcePasswordReset impose la réinitialisation du mot de passe d'un
+// utilisateur et lui envoie un lien de réinitialisation
+// POST /api/admin/users/{id}/password-reset (route d'administration)
+func (h *AdminHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ForcePasswordReset(userID); err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la réinitialisation du mot de passe")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Réinitialisation du mot de passe imposée, un lien a été envoyé à l'utilisateur",
+	})
+}
+
+// RevokeTokens invalide tous les tokens et les sessions d'un utilisateur
+// POST /api/admin/users/{id}/revoke-tokens (route d'administration)
+func (h *AdminHandler) RevokeTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.RevokeTokens(userID); err != nil {
+		h.respondWithAdminError(w, err, "Erreur lors de la révocation des tokens")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Tokens et sessions de l'utilisateur révoqués",
+	})
+}
+
+// userID lit l'identifiant d'utilisateur du chemin de la requête
+func (h *AdminHandler) userID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Identifiant d'utilisateur invalide", err)
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// respondWithAdminError convertit les erreurs du service d'administration en réponses HTTP
+func (h *AdminHandler) respondWithAdminError(w http.ResponseWriter, err error, internalMessage string) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Utilisateur introuvable", err)
+	case errors.Is(err, service.ErrUnknownUserStatus):
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Statut de compte inconnu", err)
+	case errors.Is(err, service.ErrSelfAdministration):
+		respondWithError(w, appErrors.ErrSelfAdministration.StatusCode, appErrors.ErrSelfAdministration.Code, appErrors.ErrSelfAdministration.Message, err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", internalMessage, err)
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/google/uuid"
+)
+
+// AuditHandler gère la consultation du journal d'audit
+type AuditHandler struct {
+	auditLogger service.AuditLogger
+}
+
+// NewAuditHandler crée une nouvelle instance de AuditHandler
+func NewAuditHandler(auditLogger service.AuditLogger) *AuditHandler {
+	return &AuditHandler{
+		auditLogger: auditLogger,
+	}
+}
+
+// ListMine retourne l'historique de sécurité de l'utilisateur connecté,
+// paginé par les paramètres page et pageSize
+// GET /api/auth/audit-events (route protégée)
+func (h *AuditHandler) ListMine(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	page, pageSize, ok := h.pagination(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.auditLogger.ListForUser(claims.UserID, page, pageSize)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération de l'historique", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toAuditEventListResponse(result))
+}
+
+// Query recherche dans le journal d'audit, filtré par les paramètres userId,
+// email, event, outcome, ip, from et to (RFC 3339), page et pageSize
+// GET /api/admin/audit-events (route d'administration)
+func (h *AuditHandler) Query(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	page, pageSize, ok := h.pagination(w, r)
+	if !ok {
+		return
+	}
+
+	query := service.AuditQuery{
+		Email:   params.Get("email"),
+		Event:   params.Get("event"),
+		Outcome: params.Get("outcome"),
+		IP:      params.Get("ip"),
+	}
+	if value := params.Get("userId"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Identifiant d'utilisateur invalide", err)
+			return
+		}
+		query.UserID = &userID
+	}
+	if query.From, ok = h.timeParam(w, params.Get("from")); !ok {
+		return
+	}
+	if query.To, ok = h.timeParam(w, params.Get("to")); !ok {
+		return
+	}
+
+	result, err := h.auditLogger.Query(query, page, pageSize)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAuditFilter) {
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Filtre du journal d'audit invalide", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la recherche dans le journal d'audit", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, toAuditEventListResponse(result))
+}
+
+// pagination lit les paramètres page et pageSize de la query string
+func (h *AuditHandler) pagination(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	query := r.URL.Query()
+
+	page, ok := intQueryParam(w, query.Get("page"), "Numéro de page invalide")
+	if !ok {
+		return 0, 0, false
+	}
+	pageSize, ok := intQueryParam(w, query.Get("pageSize"), "Taille de page invalide")
+	if !ok {
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+// timeParam lit une date facultative au format RFC 3339 (nil si absente)
+func (h *AuditHandler) timeParam(w http.ResponseWriter, value string) (*time.Time, bool) {
+	if value == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Date invalide, format RFC 3339 attendu", err)
+		return nil, false
+	}
+	return &t, true
+}
+
+// toAuditEventListResponse convertit une page du journal en réponse
+func toAuditEventListResponse(result *service.AuditEventPage) dto.AuditEventListResponse {
+	response := dto.AuditEventListResponse{
+		Events:   make([]dto.AuditEventDTO, 0, len(result.Events)),
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}
+	for i := range result.Events {
+		response.Events = append(response.Events, dto.ToAuditEventDTO(&result.Events[i]))
+	}
+	return response
+}
@@ -3,25 +3,37 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
 	"github.com/arnaud-dars/collec-app/internal/dto"
 	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/models"
 	"github.com/arnaud-dars/collec-app/internal/service"
 	"github.com/go-playground/validator/v10"
 )
 
 // AuthHandler gère les endpoints d'authentification
 type AuthHandler struct {
-	authService service.AuthService
-	validate    *validator.Validate
+	authService    service.AuthService
+	profileService service.ProfileService
+	auditLogger    service.AuditLogger
+	cookies        authcookie.Config
+	validate       *validator.Validate
 }
 
-// NewAuthHandler crée une nouvelle instance de AuthHandler
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
+// NewAuthHandler crée une nouvelle instance de AuthHandler. En mode cookies,
+// les tokens sont posés en cookies HttpOnly au lieu d'être retournés dans les réponses.
+func NewAuthHandler(authService service.AuthService, profileService service.ProfileService, auditLogger service.AuditLogger, cookies authcookie.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		validate:    validator.New(),
+		authService:    authService,
+		profileService: profileService,
+		auditLogger:    auditLogger,
+		cookies:        cookies,
+		validate:       validator.New(),
 	}
 }
 
@@ -32,46 +44,45 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 	// Décoder le body JSON
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
 		return
 	}
 
 	// Valider les données
 	if err := h.validate.Struct(req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
 		return
 	}
 
 	// Créer l'utilisateur
-	user, err := h.authService.Register(req.Email, req.Password)
+	user, err := h.authService.Register(req.Email, req.Password, clientInfo(r))
 	if err != nil {
 		if errors.Is(err, service.ErrEmailAlreadyExists) {
-			h.respondWithError(w, http.StatusConflict, "ERR_AUTH_002", "Cet email est déjà utilisé", err)
+			respondWithError(w, http.StatusConflict, "ERR_AUTH_002", "Cet email est déjà utilisé", err)
 			return
 		}
-		if errors.Is(err, service.ErrWeakPassword) {
-			h.respondWithError(w, http.StatusBadRequest, "ERR_AUTH_003", err.Error(), err)
+		if respondWithPasswordPolicyError(w, err) {
 			return
 		}
-		h.respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la création du compte", err)
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la création du compte", err)
 		return
 	}
 
 	// Générer les tokens pour auto-login après inscription
-	accessToken, refreshToken, _, err := h.authService.Login(req.Email, req.Password)
-	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Compte créé mais erreur de connexion", err)
+	result, err := h.authService.Login(req.Email, req.Password, clientInfo(r))
+	var throttled *service.ThrottleError
+	if errors.Is(err, service.ErrEmailNotVerified) || errors.As(err, &throttled) {
+		// Pas de connexion automatique : l'utilisateur doit d'abord confirmer son
+		// email, ou son adresse IP est bloquée par la protection contre la force brute
+		respondWithJSON(w, http.StatusCreated, dto.AuthResponse{User: dto.ToUserDTO(user)})
 		return
 	}
-
-	// Réponse
-	response := dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         dto.ToUserDTO(user),
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Compte créé mais erreur de connexion", err)
+		return
 	}
 
-	h.respondWithJSON(w, http.StatusCreated, response)
+	respondWithTokens(w, h.cookies, http.StatusCreated, result.AccessToken, result.RefreshToken, user)
 }
 
 // Login gère la connexion d'un utilisateur
@@ -81,122 +92,305 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	// Décoder le body JSON
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
 		return
 	}
 
 	// Valider les données
 	if err := h.validate.Struct(req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
 		return
 	}
 
 	// Authentifier l'utilisateur
-	accessToken, refreshToken, user, err := h.authService.Login(req.Email, req.Password)
+	client := clientInfo(r)
+	client.RememberMe = req.RememberMe
+	result, err := h.authService.Login(req.Email, req.Password, client)
 	if err != nil {
+		if respondWithThrottleError(w, err) {
+			return
+		}
 		if errors.Is(err, service.ErrInvalidCredentials) {
-			h.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Email ou mot de passe incorrect", err)
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Email ou mot de passe incorrect", err)
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			respondWithError(w, http.StatusForbidden, appErrors.ErrEmailNotVerified.Code, "Veuillez confirmer votre adresse email avant de vous connecter", err)
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetRequired) {
+			respondWithError(w, appErrors.ErrPasswordResetRequired.StatusCode, appErrors.ErrPasswordResetRequired.Code, appErrors.ErrPasswordResetRequired.Message, err)
+			return
+		}
+		if respondWithAccountDisabledError(w, err) {
 			return
 		}
-		h.respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
 		return
 	}
 
-	// Réponse
-	response := dto.AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		User:         dto.ToUserDTO(user),
+	respondWithLoginResult(w, h.cookies, result)
+}
+
+// LoginMFA complète une connexion avec le code de double authentification
+// POST /api/auth/login/mfa
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req dto.MFALoginRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	result, err := h.authService.CompleteMFALogin(req.MFAToken, req.Code, clientInfo(r))
+	if err != nil {
+		if respondWithThrottleError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidMFACode) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrInvalidMFACode.Code, appErrors.ErrInvalidMFACode.Message, err)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenRevoked) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Connexion expirée, veuillez vous reconnecter", err)
+			return
+		}
+		if respondWithAccountDisabledError(w, err) {
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+		return
+	}
+
+	respondWithLoginResult(w, h.cookies, result)
+}
+
+// respondWithThrottleError répond aux tentatives refusées par la protection
+// contre la force brute, avec le délai à respecter dans Retry-After
+func respondWithThrottleError(w http.ResponseWriter, err error) bool {
+	var throttled *service.ThrottleError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	if errors.Is(err, service.ErrAccountLocked) {
+		respondWithError(w, appErrors.ErrAccountLocked.StatusCode, appErrors.ErrAccountLocked.Code, appErrors.ErrAccountLocked.Message, err)
+		return true
+	}
+	respondWithError(w, appErrors.ErrTooManyLoginAttempts.StatusCode, appErrors.ErrTooManyLoginAttempts.Code, appErrors.ErrTooManyLoginAttempts.Message, err)
+	return true
+}
+
+// respondWithAccountDisabledError répond aux connexions et aux tokens refusés
+// parce qu'un administrateur a désactivé le compte
+func respondWithAccountDisabledError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, service.ErrAccountDisabled) {
+		return false
+	}
+	respondWithError(w, appErrors.ErrAccountDisabled.StatusCode, appErrors.ErrAccountDisabled.Code, appErrors.ErrAccountDisabled.Message, err)
+	return true
+}
+
+// respondWithLoginResult envoie les tokens d'une connexion réussie, ou le token
+// "mfa pending" lorsque le second facteur est requis avant de les émettre
+func respondWithLoginResult(w http.ResponseWriter, cookies authcookie.Config, result *service.LoginResult) {
+	if result.MFARequired() {
+		respondWithJSON(w, http.StatusOK, dto.MFARequiredResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	respondWithTokens(w, cookies, http.StatusOK, result.AccessToken, result.RefreshToken, result.User)
+}
+
+// respondWithTokens envoie les tokens et l'utilisateur connecté. En mode
+// cookies, les tokens sont posés en cookies et absents du body.
+func respondWithTokens(w http.ResponseWriter, cookies authcookie.Config, status int, accessToken, refreshToken string, user *models.User) {
+	response := dto.AuthResponse{User: dto.ToUserDTO(user)}
+	if cookies.Enabled {
+		if err := cookies.SetTokens(w, accessToken, refreshToken); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+			return
+		}
+	} else {
+		response.AccessToken = accessToken
+		response.RefreshToken = refreshToken
 	}
 
-	h.respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, status, response)
 }
 
-// RefreshToken génère un nouveau access token
+// RefreshToken échange un refresh token contre une nouvelle paire de tokens
 // POST /api/auth/refresh
 func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req dto.RefreshTokenRequest
 
-	// Décoder le body JSON
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+	// Décoder le body JSON, optionnel en mode cookies
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !(h.cookies.Enabled && errors.Is(err, io.EOF)) {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
 		return
 	}
 
-	// Valider les données
-	if err := h.validate.Struct(req); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+	// À défaut de body, le refresh token est lu dans son cookie ; la requête
+	// doit alors porter le token CSRF
+	if req.RefreshToken == "" && h.cookies.Enabled {
+		req.RefreshToken = authcookie.RefreshToken(r)
+		if req.RefreshToken != "" && !h.cookies.VerifyCSRF(r) {
+			respondWithError(w, appErrors.ErrInvalidCSRFToken.StatusCode, appErrors.ErrInvalidCSRFToken.Code, appErrors.ErrInvalidCSRFToken.Message, nil)
+			return
+		}
+	}
+	if req.RefreshToken == "" {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Refresh token manquant", nil)
 		return
 	}
 
-	// Générer un nouveau access token
-	accessToken, err := h.authService.RefreshToken(req.RefreshToken)
+	// Rotation du refresh token
+	accessToken, refreshToken, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(r))
 	if err != nil {
+		if respondWithAccountDisabledError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrTokenReused) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrTokenRevoked.Code, "Token déjà utilisé, session révoquée", err)
+			return
+		}
+		if errors.Is(err, service.ErrTokenRevoked) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrTokenRevoked.Code, "Token révoqué", err)
+			return
+		}
 		if errors.Is(err, service.ErrInvalidToken) {
-			h.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token invalide ou expiré", err)
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token invalide ou expiré", err)
 			return
 		}
-		h.respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors du refresh du token", err)
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors du refresh du token", err)
 		return
 	}
 
 	// Réponse
-	response := map[string]string{
-		"accessToken": accessToken,
+	if h.cookies.Enabled {
+		if err := h.cookies.SetTokens(w, accessToken, refreshToken); err != nil {
+			respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors du refresh du token", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, dto.RefreshTokenResponse{})
+		return
+	}
+
+	response := dto.RefreshTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	}
 
-	h.respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, response)
 }
 
-// GetMe retourne les informations de l'utilisateur connecté
+// GetMe retourne le profil de l'utilisateur connecté
 // GET /api/auth/me (route protégée)
 func (h *AuthHandler) GetMe(w http.ResponseWriter, r *http.Request) {
-	// L'user ID est injecté dans le contexte par le middleware d'authentification
-	userID, ok := r.Context().Value("userID").(string)
+	// Les claims sont injectés dans le contexte par le middleware d'authentification
+	claims, ok := claimsFromContext(r)
 	if !ok {
-		h.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
 		return
 	}
 
-	// TODO: Récupérer l'utilisateur depuis le repository
-	// Pour l'instant, on retourne juste l'ID
-	response := map[string]string{
-		"userId": userID,
-		"message": "Endpoint GetMe - À implémenter avec le repository",
+	user, err := h.profileService.GetProfile(claims.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération du profil", err)
+		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
 }
 
-// Logout déconnecte l'utilisateur (côté client)
-// POST /api/auth/logout
+// Logout révoque l'access token courant et le refresh token fourni
+// POST /api/auth/logout (route protégée)
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// La déconnexion est gérée côté client (suppression des tokens)
-	// Ici on peut juste confirmer la déconnexion
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	// Le body est optionnel
+	var req dto.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+	if req.RefreshToken == "" && h.cookies.Enabled {
+		req.RefreshToken = authcookie.RefreshToken(r)
+	}
+
+	err := h.authService.Logout(claims, req.RefreshToken)
+	h.recordLogout(r, service.AuditEventLogout, claims, err)
+	if h.cookies.Enabled {
+		h.cookies.Clear(w)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Refresh token invalide", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la déconnexion", err)
+		return
+	}
+
 	response := map[string]string{
 		"message": "Déconnexion réussie",
 	}
 
-	h.respondWithJSON(w, http.StatusOK, response)
+	respondWithJSON(w, http.StatusOK, response)
 }
 
-// respondWithJSON envoie une réponse JSON
-func (h *AuthHandler) respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(payload)
-}
+// LogoutEverywhere invalide tous les tokens de l'utilisateur, sur tous ses appareils
+// POST /api/auth/logout-all (route protégée)
+func (h *AuthHandler) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	err := h.authService.LogoutEverywhere(claims.UserID)
+	h.recordLogout(r, service.AuditEventLogoutAll, claims, err)
+	if h.cookies.Enabled {
+		h.cookies.Clear(w)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la déconnexion", err)
+		return
+	}
 
-// respondWithError envoie une réponse d'erreur JSON
-func (h *AuthHandler) respondWithError(w http.ResponseWriter, status int, code, message string, err error) {
-	errorResponse := map[string]interface{}{
-		"error": map[string]string{
-			"code":    code,
-			"message": message,
-		},
+	response := map[string]string{
+		"message": "Déconnexion de tous les appareils réussie",
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(errorResponse)
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// recordLogout enregistre une déconnexion dans le journal d'audit
+func (h *AuthHandler) recordLogout(r *http.Request, event string, claims *service.JWTClaims, err error) {
+	h.auditLogger.Record(service.AuditEntry{
+		Event:  event,
+		UserID: claims.UserID,
+		Email:  claims.Email,
+		Client: clientInfo(r),
+		Err:    err,
+	})
 }
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+)
+
+// avatarFormField est le champ du formulaire multipart portant l'image
+const avatarFormField = "avatar"
+
+// multipartOverhead couvre les en-têtes et délimiteurs du formulaire en plus de l'image
+const multipartOverhead = 64 << 10
+
+// AvatarHandler gère les endpoints d'envoi et de suppression de l'avatar
+type AvatarHandler struct {
+	avatarService service.AvatarService
+	maxSize       int64
+}
+
+// NewAvatarHandler crée une nouvelle instance de AvatarHandler. maxSize borne
+// la taille en octets de l'image envoyée.
+func NewAvatarHandler(avatarService service.AvatarService, maxSize int64) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+		maxSize:       maxSize,
+	}
+}
+
+// Upload remplace l'avatar de l'utilisateur connecté par l'image envoyée dans
+// le champ "avatar" d'un formulaire multipart
+// PUT /api/users/me/avatar (route protégée)
+func (h *AvatarHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Formulaire multipart attendu", err)
+		return
+	}
+
+	// Lecture en flux : seul le champ de l'image est lu, sans copie sur disque
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Champ \"avatar\" manquant", nil)
+			return
+		}
+		if err != nil {
+			h.respondWithAvatarError(w, err)
+			return
+		}
+		if part.FormName() != avatarFormField {
+			continue
+		}
+
+		user, err := h.avatarService.Upload(r.Context(), claims.UserID, part)
+		if err != nil {
+			h.respondWithAvatarError(w, err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
+		return
+	}
+}
+
+// Delete retire l'avatar de l'utilisateur connecté
+// DELETE /api/users/me/avatar (route protégée)
+func (h *AvatarHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	user, err := h.avatarService.Remove(r.Context(), claims.UserID)
+	if err != nil {
+		h.respondWithAvatarError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
+}
+
+// respondWithAvatarError traduit les erreurs de l'avatar en réponses HTTP
+func (h *AvatarHandler) respondWithAvatarError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, service.ErrAvatarTooLarge), errors.As(err, &tooLarge):
+		respondWithError(w, appErrors.ErrAvatarTooLarge.StatusCode, appErrors.ErrAvatarTooLarge.Code, appErrors.ErrAvatarTooLarge.Message, err)
+	case errors.Is(err, service.ErrInvalidAvatar):
+		respondWithError(w, appErrors.ErrInvalidAvatar.StatusCode, appErrors.ErrInvalidAvatar.Code, appErrors.ErrInvalidAvatar.Message, err)
+	case errors.Is(err, service.ErrUserNotFound):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la mise à jour de l'avatar", err)
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/arnaud-dars/collec-app/internal/blobstore"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+)
+
+// BlobHandler sert les fichiers publics du stockage local. Avec un stockage
+// S3, les fichiers sont servis directement par le bucket.
+type BlobHandler struct {
+	store blobstore.BlobStore
+}
+
+// NewBlobHandler crée une nouvelle instance de BlobHandler
+func NewBlobHandler(store blobstore.BlobStore) *BlobHandler {
+	return &BlobHandler{store: store}
+}
+
+// Serve envoie un fichier. Les clés sont versionnées (une nouvelle image a une
+// nouvelle clé) : le contenu peut être mis en cache indéfiniment.
+// GET /blobs/{key...}
+func (h *BlobHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	body, err := h.store.Get(r.Context(), r.PathValue("key"))
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) || errors.Is(err, blobstore.ErrInvalidKey) {
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Fichier introuvable", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la lecture du fichier", err)
+		return
+	}
+	defer body.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(r.PathValue("key")))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	io.Copy(w, body)
+}
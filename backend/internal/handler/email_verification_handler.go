@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// EmailVerificationHandler gère les endpoints de vérification de l'adresse email
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+	validate            *validator.Validate
+}
+
+// NewEmailVerificationHandler crée une nouvelle instance de EmailVerificationHandler
+func NewEmailVerificationHandler(verificationService service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verificationService: verificationService,
+		validate:            validator.New(),
+	}
+}
+
+// Verify confirme l'adresse email à partir du lien reçu
+// POST /api/auth/email/verify
+func (h *EmailVerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req dto.VerifyEmailRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	user, err := h.verificationService.Verify(req.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidVerificationToken.Code, appErrors.ErrInvalidVerificationToken.Message, err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la vérification de l'email", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
+}
+
+// Resend renvoie le lien de vérification
+// POST /api/auth/email/resend
+func (h *EmailVerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResendVerificationRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	if err := h.verificationService.Resend(req.Email); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de l'envoi du lien de vérification", err)
+		return
+	}
+
+	// Même réponse que l'email soit inscrit, déjà vérifié ou trop sollicité
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Si un compte non vérifié correspond à cet email, un nouveau lien a été envoyé",
+	})
+}
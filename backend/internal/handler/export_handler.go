@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+)
+
+// ExportHandler gère les endpoints d'export des données personnelles
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+// NewExportHandler crée une nouvelle instance de ExportHandler
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// Request demande la construction d'une archive de toutes les données de
+// l'utilisateur connecté ; un email est envoyé lorsqu'elle est prête
+// POST /api/users/me/export (route protégée)
+func (h *ExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	export, err := h.exportService.RequestExport(claims.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportInProgress) {
+			respondWithError(w, http.StatusConflict, appErrors.ErrExportInProgress.Code, appErrors.ErrExportInProgress.Message, err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la demande d'export", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, dto.ToDataExportDTO(export, ""))
+}
+
+// Status retourne l'état de la dernière demande d'export de l'utilisateur
+// connecté, avec le lien de téléchargement si l'archive est prête
+// GET /api/users/me/export (route protégée)
+func (h *ExportHandler) Status(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	export, downloadURL, err := h.exportService.LatestExport(claims.UserID)
+	if err != nil {
+		if errors.Is(err, service.ErrExportNotFound) {
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Aucun export de données demandé", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération de l'export", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToDataExportDTO(export, downloadURL))
+}
+
+// Download envoie l'archive désignée par un lien signé. Le lien suffit à
+// l'authentification, pour pouvoir être ouvert depuis l'email.
+// GET /api/users/me/export/download?token=
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	export, file, err := h.exportService.OpenExport(r.URL.Query().Get("token"))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExportLink) {
+			respondWithError(w, http.StatusGone, appErrors.ErrInvalidExportLink.Code, appErrors.ErrInvalidExportLink.Message, err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors du téléchargement de l'export", err)
+		return
+	}
+	defer file.Close()
+
+	fileName := fmt.Sprintf("collec-app-export-%s.zip", export.CreatedAt.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, fileName, *export.CompletedAt, file)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	"github.com/arnaud-dars/collec-app/internal/service"
+)
+
+// JWKSHandler publie les clés publiques de vérification des JWT
+type JWKSHandler struct {
+	keyRing service.KeyRing
+	maxAge  string
+}
+
+// NewJWKSHandler crée une nouvelle instance de JWKSHandler. La durée de mise en
+// cache doit rester inférieure au délai de propagation des nouvelles clés.
+func NewJWKSHandler(keyRing service.KeyRing, maxAgeSeconds int) *JWKSHandler {
+	return &JWKSHandler{
+		keyRing: keyRing,
+		maxAge:  strconv.Itoa(maxAgeSeconds),
+	}
+}
+
+// JWKS retourne les clés publiques actives et en période de recouvrement
+// GET /.well-known/jwks.json
+func (h *JWKSHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keyRing.VerificationKeys()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération des clés", err)
+		return
+	}
+
+	set := dto.JWKSet{Keys: make([]dto.JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := dto.ToJWK(key.ID, key.Algorithm, key.PublicKey)
+		if err != nil {
+			log.Printf("clé %s non publiée: %v", key.ID, err)
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age="+h.maxAge)
+	respondWithJSON(w, http.StatusOK, set)
+}
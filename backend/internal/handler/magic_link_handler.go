@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// MagicLinkHandler gère les endpoints de connexion sans mot de passe
+type MagicLinkHandler struct {
+	magicLinkService service.MagicLinkService
+	cookies          authcookie.Config
+	validate         *validator.Validate
+}
+
+// NewMagicLinkHandler crée une nouvelle instance de MagicLinkHandler
+func NewMagicLinkHandler(magicLinkService service.MagicLinkService, cookies authcookie.Config) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		cookies:          cookies,
+		validate:         validator.New(),
+	}
+}
+
+// Request envoie un lien de connexion par email
+// POST /api/auth/magic-link
+func (h *MagicLinkHandler) Request(w http.ResponseWriter, r *http.Request) {
+	var req dto.MagicLinkRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	// Même réponse, et dans le même délai, que l'email soit inscrit ou non
+	sendInBackground("envoi du lien de connexion", func() error {
+		return h.magicLinkService.RequestLink(req.Email)
+	})
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Si un compte correspond à cet email, un lien de connexion a été envoyé",
+	})
+}
+
+// Login connecte l'utilisateur à partir d'un lien de connexion
+// POST /api/auth/magic-link/login
+func (h *MagicLinkHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req dto.MagicLinkLoginRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	client := clientInfo(r)
+	client.RememberMe = req.RememberMe
+	result, err := h.magicLinkService.Login(req.Token, client)
+	if err != nil {
+		if respondWithThrottleError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidMagicLink) {
+			respondWithError(w, appErrors.ErrInvalidMagicLink.StatusCode, appErrors.ErrInvalidMagicLink.Code, appErrors.ErrInvalidMagicLink.Message, err)
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			respondWithError(w, http.StatusForbidden, appErrors.ErrEmailNotVerified.Code, "Veuillez confirmer votre adresse email avant de vous connecter", err)
+			return
+		}
+		if errors.Is(err, service.ErrPasswordResetRequired) {
+			respondWithError(w, appErrors.ErrPasswordResetRequired.StatusCode, appErrors.ErrPasswordResetRequired.Code, appErrors.ErrPasswordResetRequired.Message, err)
+			return
+		}
+		if respondWithAccountDisabledError(w, err) {
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+		return
+	}
+
+	respondWithLoginResult(w, h.cookies, result)
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// MFAHandler gère les endpoints de configuration de la double authentification
+type MFAHandler struct {
+	mfaService service.MFAService
+	validate   *validator.Validate
+}
+
+// NewMFAHandler crée une nouvelle instance de MFAHandler
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+		validate:   validator.New(),
+	}
+}
+
+// Status retourne l'état de la double authentification
+// GET /api/auth/mfa (route protégée)
+func (h *MFAHandler) Status(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	status, err := h.mfaService.Status(claims.UserID)
+	if err != nil {
+		h.respondWithMFAError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.MFAStatusResponse{
+		Enabled:                status.Enabled,
+		RemainingRecoveryCodes: status.RemainingRecoveryCodes,
+	})
+}
+
+// Enroll génère un secret TOTP à configurer dans l'application d'authentification
+// POST /api/auth/mfa/totp (route protégée)
+func (h *MFAHandler) Enroll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	enrollment, err := h.mfaService.Enroll(claims.UserID)
+	if err != nil {
+		h.respondWithMFAError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.TOTPEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OTPAuthURI: enrollment.URI,
+	})
+}
+
+// Confirm active la double authentification avec un premier code TOTP
+// POST /api/auth/mfa/totp/confirm (route protégée)
+func (h *MFAHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.mfaService.Confirm(claims.UserID, req.Code)
+	if err != nil {
+		h.respondWithMFAError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable désactive la double authentification
+// POST /api/auth/mfa/totp/disable (route protégée)
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.DisableMFARequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	if err := h.mfaService.Disable(claims.UserID, req.Password, req.Code); err != nil {
+		h.respondWithMFAError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Double authentification désactivée",
+	})
+}
+
+// RegenerateRecoveryCodes remplace les codes de secours
+// POST /api/auth/mfa/recovery-codes (route protégée)
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.MFACodeRequest
+	if !h.decode(w, r, &req) {
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(claims.UserID, req.Code)
+	if err != nil {
+		h.respondWithMFAError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// decode décode et valide le body JSON ; retourne false si une erreur a été envoyée
+func (h *MFAHandler) decode(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return false
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return false
+	}
+
+	return true
+}
+
+// respondWithMFAError traduit les erreurs du service en réponses HTTP
+func (h *MFAHandler) respondWithMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrInvalidMFACode.Code, appErrors.ErrInvalidMFACode.Message, err)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrInvalidCredentials.Code, "Mot de passe incorrect", err)
+	case errors.Is(err, service.ErrMFAAlreadyEnabled),
+		errors.Is(err, service.ErrMFANotEnabled),
+		errors.Is(err, service.ErrMFANotEnrolled):
+		respondWithError(w, http.StatusConflict, appErrors.ErrMFAConflict.Code, err.Error(), err)
+	case errors.Is(err, service.ErrInvalidToken):
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la gestion de la double authentification", err)
+	}
+}
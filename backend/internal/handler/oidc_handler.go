@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// OIDCHandler gère les endpoints de connexion via un fournisseur d'identité
+type OIDCHandler struct {
+	oidcService service.OIDCService
+	cookies     authcookie.Config
+	validate    *validator.Validate
+}
+
+// NewOIDCHandler crée une nouvelle instance de OIDCHandler
+func NewOIDCHandler(oidcService service.OIDCService, cookies authcookie.Config) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		cookies:     cookies,
+		validate:    validator.New(),
+	}
+}
+
+// Providers liste les fournisseurs d'identité configurés
+// GET /api/auth/oidc/providers
+func (h *OIDCHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, dto.OIDCProvidersResponse{Providers: h.oidcService.Providers()})
+}
+
+// Authorize démarre une connexion et retourne l'URL du fournisseur. Le state
+// est aussi posé en cookie, pour lier la connexion à ce navigateur.
+// POST /api/auth/oidc/{provider}/authorize
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.oidcService.Authorize(r.Context(), r.PathValue("provider"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownProvider) {
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Fournisseur d'identité inconnu", err)
+			return
+		}
+		if errors.Is(err, service.ErrOIDCLoginFailed) {
+			respondWithError(w, http.StatusBadGateway, appErrors.ErrExternalLogin.Code, "Fournisseur d'identité indisponible", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+		return
+	}
+
+	h.cookies.SetOIDCState(w, authorization.State, authorization.ExpiresAt)
+	respondWithJSON(w, http.StatusOK, dto.OIDCAuthorizationResponse{
+		AuthorizationURL: authorization.URL,
+		State:            authorization.State,
+	})
+}
+
+// Callback termine la connexion au retour du fournisseur
+// POST /api/auth/oidc/{provider}/callback
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	var req dto.OIDCCallbackRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	// Le state doit avoir été émis pour ce navigateur : un code obtenu par un
+	// tiers ne peut pas y ouvrir sa propre session (login CSRF)
+	valid := authcookie.VerifyOIDCState(r, req.State)
+	h.cookies.ClearOIDCState(w)
+	if !valid {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrExternalLogin.Code, appErrors.ErrExternalLogin.Message, service.ErrInvalidOIDCState)
+		return
+	}
+
+	result, err := h.oidcService.Callback(r.Context(), r.PathValue("provider"), req.Code, req.State, clientInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnknownProvider):
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Fournisseur d'identité inconnu", err)
+		case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCLoginFailed):
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrExternalLogin.Code, appErrors.ErrExternalLogin.Message, err)
+		case errors.Is(err, service.ErrOIDCEmailNotVerified):
+			respondWithError(w, http.StatusForbidden, appErrors.ErrEmailNotVerified.Code, "Le fournisseur n'a pas confirmé votre adresse email", err)
+		case errors.Is(err, service.ErrEmailNotVerified):
+			respondWithError(w, http.StatusForbidden, appErrors.ErrEmailNotVerified.Code, "Veuillez confirmer votre adresse email avant de vous connecter", err)
+		case errors.Is(err, service.ErrPasswordResetRequired):
+			respondWithError(w, appErrors.ErrPasswordResetRequired.StatusCode, appErrors.ErrPasswordResetRequired.Code, appErrors.ErrPasswordResetRequired.Message, err)
+		case errors.Is(err, service.ErrAccountDisabled):
+			respondWithError(w, appErrors.ErrAccountDisabled.StatusCode, appErrors.ErrAccountDisabled.Code, appErrors.ErrAccountDisabled.Message, err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la connexion", err)
+		}
+		return
+	}
+
+	respondWithLoginResult(w, h.cookies, result)
+}
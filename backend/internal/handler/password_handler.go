@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// PasswordHandler gère les endpoints de réinitialisation du mot de passe
+type PasswordHandler struct {
+	passwordResetService service.PasswordResetService
+	validate             *validator.Validate
+}
+
+// NewPasswordHandler crée une nouvelle instance de PasswordHandler
+func NewPasswordHandler(passwordResetService service.PasswordResetService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordResetService: passwordResetService,
+		validate:             validator.New(),
+	}
+}
+
+// Forgot envoie un lien de réinitialisation par email
+// POST /api/auth/password/forgot
+func (h *PasswordHandler) Forgot(w http.ResponseWriter, r *http.Request) {
+	var req dto.ForgotPasswordRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	// Même réponse, et dans le même délai, que l'email soit inscrit ou non
+	sendInBackground("envoi du lien de réinitialisation", func() error {
+		return h.passwordResetService.RequestReset(req.Email)
+	})
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Si un compte correspond à cet email, un lien de réinitialisation a été envoyé",
+	})
+}
+
+// Reset remplace le mot de passe à partir d'un token de réinitialisation
+// POST /api/auth/password/reset
+func (h *PasswordHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	var req dto.ResetPasswordRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidResetToken.Code, appErrors.ErrInvalidResetToken.Message, err)
+			return
+		}
+		if respondWithPasswordPolicyError(w, err) {
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la réinitialisation du mot de passe", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Mot de passe réinitialisé, veuillez vous reconnecter",
+	})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenHandler gère les endpoints de gestion des personal access tokens
+type PersonalAccessTokenHandler struct {
+	tokenService service.PersonalAccessTokenService
+	validate     *validator.Validate
+}
+
+// NewPersonalAccessTokenHandler crée une nouvelle instance de PersonalAccessTokenHandler
+func NewPersonalAccessTokenHandler(tokenService service.PersonalAccessTokenService) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenService: tokenService,
+		validate:     validator.New(),
+	}
+}
+
+// Create crée un personal access token pour l'utilisateur connecté. La valeur
+// du token n'est retournée qu'une fois.
+// POST /api/auth/tokens (route protégée)
+func (h *PersonalAccessTokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.CreatePersonalAccessTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	token, value, err := h.tokenService.Create(claims.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrInvalidTokenExpiry):
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, err.Error(), err)
+		case errors.Is(err, service.ErrInvalidToken):
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la création du token", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, dto.CreatePersonalAccessTokenResponse{
+		Token:                  value,
+		PersonalAccessTokenDTO: dto.ToPersonalAccessTokenDTO(token),
+	})
+}
+
+// List retourne les personal access tokens utilisables de l'utilisateur connecté
+// GET /api/auth/tokens (route protégée)
+func (h *PersonalAccessTokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	tokens, err := h.tokenService.List(claims.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération des tokens", err)
+		return
+	}
+
+	response := make([]dto.PersonalAccessTokenDTO, 0, len(tokens))
+	for i := range tokens {
+		response = append(response, dto.ToPersonalAccessTokenDTO(&tokens[i]))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Revoke révoque un personal access token de l'utilisateur connecté
+// DELETE /api/auth/tokens/{id} (route protégée)
+func (h *PersonalAccessTokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Identifiant de token invalide", err)
+		return
+	}
+
+	if err := h.tokenService.Revoke(claims.UserID, tokenID); err != nil {
+		if errors.Is(err, service.ErrPersonalAccessTokenNotFound) {
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Token introuvable", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la révocation du token", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Token révoqué",
+	})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProfileHandler gère l'endpoint de modification du profil
+type ProfileHandler struct {
+	profileService service.ProfileService
+	validate       *validator.Validate
+}
+
+// NewProfileHandler crée une nouvelle instance de ProfileHandler
+func NewProfileHandler(profileService service.ProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+		validate:       validator.New(),
+	}
+}
+
+// Update modifie les champs fournis du profil de l'utilisateur connecté
+// PATCH /api/users/me (route protégée)
+func (h *ProfileHandler) Update(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	var req dto.UpdateProfileRequest
+
+	// Décoder le body JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Données invalides", err)
+		return
+	}
+
+	// Valider les données
+	if err := h.validate.Struct(req); err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Erreur de validation", err)
+		return
+	}
+
+	user, err := h.profileService.UpdateProfile(claims.UserID, service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Username:    req.Username,
+		Bio:         req.Bio,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		Currency:    req.Currency,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidUsername):
+			respondWithError(w, http.StatusBadRequest, appErrors.ErrValidation.Code, "Identifiant invalide : 3 à 30 lettres, chiffres ou underscores", err)
+		case errors.Is(err, service.ErrUsernameTaken):
+			respondWithError(w, appErrors.ErrUsernameTaken.StatusCode, appErrors.ErrUsernameTaken.Code, appErrors.ErrUsernameTaken.Message, err)
+		case errors.Is(err, service.ErrUserNotFound):
+			respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", err)
+		default:
+			respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la mise à jour du profil", err)
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, dto.ToUserDTO(user))
+}
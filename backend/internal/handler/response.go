@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/password"
+	"github.com/arnaud-dars/collec-app/internal/service"
+)
+
+// respondWithJSON envoie une réponse JSON
+func respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(payload)
+}
+
+// respondWithError envoie une réponse d'erreur JSON
+func respondWithError(w http.ResponseWriter, status int, code, message string, err error) {
+	errorResponse := map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse)
+}
+
+// sendInBackground exécute un envoi d'email hors de la requête. La réponse ne
+// dépend ainsi ni de la durée ni de l'issue de l'envoi, qui ne révèlent donc
+// pas si l'email est inscrit ; les échecs sont seulement journalisés.
+func sendInBackground(action string, send func() error) {
+	go func() {
+		if err := send(); err != nil {
+			log.Printf("%s impossible: %v", action, err)
+		}
+	}()
+}
+
+// respondWithPasswordPolicyError répond aux mots de passe refusés par la
+// politique de sécurité, en détaillant chaque règle non respectée
+func respondWithPasswordPolicyError(w http.ResponseWriter, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	errorResponse := map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":       appErrors.ErrWeakPassword.Code,
+			"message":    appErrors.ErrWeakPassword.Message,
+			"violations": policyErr.Violations,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErrors.ErrWeakPassword.StatusCode)
+	json.NewEncoder(w).Encode(errorResponse)
+	return true
+}
+
+// claimsFromContext récupère les claims injectés par le middleware d'authentification
+func claimsFromContext(r *http.Request) (*service.JWTClaims, bool) {
+	claims, ok := r.Context().Value("claims").(*service.JWTClaims)
+	return claims, ok
+}
+
+// clientInfo extrait le User-Agent et l'adresse IP du client
+func clientInfo(r *http.Request) service.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return service.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        ip,
+	}
+}
+
+// intQueryParam lit un paramètre entier facultatif de la query string (0 si absent)
+func intQueryParam(w http.ResponseWriter, value, message string) (int, bool) {
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, message, err)
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/arnaud-dars/collec-app/internal/dto"
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/google/uuid"
+)
+
+// SessionHandler gère les endpoints de gestion des sessions actives
+type SessionHandler struct {
+	sessionService service.SessionService
+}
+
+// NewSessionHandler crée une nouvelle instance de SessionHandler
+func NewSessionHandler(sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// List retourne les sessions actives de l'utilisateur connecté
+// GET /api/auth/sessions (route protégée)
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	sessions, err := h.sessionService.List(claims.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la récupération des sessions", err)
+		return
+	}
+
+	response := make([]dto.SessionDTO, 0, len(sessions))
+	for i := range sessions {
+		response = append(response, dto.ToSessionDTO(&sessions[i], claims.SessionID))
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// Revoke révoque une session de l'utilisateur connecté
+// DELETE /api/auth/sessions/{id} (route protégée)
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, appErrors.ErrInvalidInput.Code, "Identifiant de session invalide", err)
+		return
+	}
+
+	if err := h.sessionService.Revoke(claims.UserID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			respondWithError(w, http.StatusNotFound, appErrors.ErrNotFound.Code, "Session introuvable", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la révocation de la session", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Session révoquée",
+	})
+}
+
+// RevokeOthers révoque toutes les sessions de l'utilisateur sauf la session courante
+// DELETE /api/auth/sessions (route protégée)
+func (h *SessionHandler) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	claims, ok := claimsFromContext(r)
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié", nil)
+		return
+	}
+
+	if err := h.sessionService.RevokeOthers(claims.UserID, claims.SessionID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "ERR_INTERNAL_001", "Erreur lors de la révocation des sessions", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Autres sessions révoquées",
+	})
+}
@@ -0,0 +1,36 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer écrit chaque email dans un fichier .eml d'un dossier "outbox".
+// Destiné au développement local et aux tests : aucun email n'est réellement envoyé.
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer crée un Mailer qui dépose les emails dans le dossier donné
+func NewFileMailer(dir, from string) Mailer {
+	return &fileMailer{
+		dir:  dir,
+		from: from,
+	}
+}
+
+// Send écrit le message dans un nouveau fichier de l'outbox
+func (m *fileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), uuid.New().String())
+	return os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, msg, now), 0o600)
+}
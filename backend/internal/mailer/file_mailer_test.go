@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_WritesMessageToOutbox(t *testing.T) {
+	// Arrange
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m := NewFileMailer(dir, "Collec-App <no-reply@collec-app.local>")
+
+	// Act
+	err := m.Send(Message{
+		To:      "user@example.com",
+		Subject: "Réinitialisation du mot de passe",
+		Body:    "Bonjour,\n",
+	})
+
+	// Assert
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ".eml", filepath.Ext(entries[0].Name()))
+
+	content, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "From: Collec-App <no-reply@collec-app.local>\r\n")
+	assert.Contains(t, string(content), "To: user@example.com\r\n")
+	assert.Contains(t, string(content), "Subject: =?utf-8?q?R=C3=A9initialisation_du_mot_de_passe?=\r\n")
+	assert.Contains(t, string(content), "\r\n\r\nBonjour,\n")
+}
+
+func TestFileMailer_OneFilePerMessage(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	m := NewFileMailer(dir, "no-reply@collec-app.local")
+
+	// Act
+	require.NoError(t, m.Send(Message{To: "a@example.com", Subject: "A", Body: "a"}))
+	require.NoError(t, m.Send(Message{To: "b@example.com", Subject: "B", Body: "b"}))
+
+	// Assert
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import (
+	"fmt"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// smtpMailer envoie les emails via un serveur SMTP
+type smtpMailer struct {
+	addr     string
+	auth     smtp.Auth
+	from     string // en-tête From, éventuellement avec un nom affiché
+	envelope string // adresse seule, pour la commande MAIL FROM
+}
+
+// NewSMTPMailer crée un Mailer SMTP.
+// L'authentification PLAIN n'est utilisée que si un nom d'utilisateur est fourni.
+// L'expéditeur peut comporter un nom affiché ("Collec-App <no-reply@...>").
+func NewSMTPMailer(host string, port int, username, password, from string) (Mailer, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("adresse d'expéditeur invalide %q: %w", from, err)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		addr:     fmt.Sprintf("%s:%d", host, port),
+		auth:     auth,
+		from:     from,
+		envelope: sender.Address,
+	}, nil
+}
+
+// Send envoie le message au destinataire
+func (m *smtpMailer) Send(msg Message) error {
+	return smtp.SendMail(m.addr, m.auth, m.envelope, []string{msg.To}, formatMessage(m.from, msg, time.Now()))
+}
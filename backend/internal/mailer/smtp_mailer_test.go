@@ -0,0 +1,98 @@
+package mailer
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession contient ce qu'un serveur SMTP de test a reçu
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// startFakeSMTP démarre un serveur SMTP minimal qui accepte une connexion
+// et retourne son port ainsi qu'un canal recevant la session enregistrée
+func startFakeSMTP(t *testing.T) (int, <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var session smtpSession
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			session.commands = append(session.commands, line)
+			switch verb := strings.ToUpper(strings.Fields(line)[0]); verb {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				body, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				session.data = string(body)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				sessions <- session
+				return
+			default:
+				tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port, sessions
+}
+
+func TestSMTPMailer_UsesBareAddressAsEnvelopeSender(t *testing.T) {
+	// Arrange
+	port, sessions := startFakeSMTP(t)
+	m, err := NewSMTPMailer("127.0.0.1", port, "", "", "Collec-App <no-reply@collec-app.local>")
+	require.NoError(t, err)
+
+	// Act
+	err = m.Send(Message{
+		To:      "user@example.com",
+		Subject: "Réinitialisation du mot de passe",
+		Body:    "Bonjour,\n",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	session := <-sessions
+	assert.Contains(t, session.commands, "MAIL FROM:<no-reply@collec-app.local>")
+	assert.Contains(t, session.commands, "RCPT TO:<user@example.com>")
+	// Le nom affiché est conservé dans l'en-tête From
+	assert.Contains(t, session.data, "From: Collec-App <no-reply@collec-app.local>\n")
+	assert.Contains(t, session.data, "Bonjour,")
+}
+
+func TestNewSMTPMailer_InvalidSender(t *testing.T) {
+	// Act
+	m, err := NewSMTPMailer("127.0.0.1", 25, "", "", "pas une adresse")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, m)
+}
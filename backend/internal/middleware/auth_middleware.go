@@ -3,60 +3,199 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
 	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
 	"github.com/arnaud-dars/collec-app/internal/service"
 )
 
-// AuthMiddleware gère l'authentification JWT
+// AuthMiddleware gère l'authentification par JWT ou personal access token
 type AuthMiddleware struct {
-	authService service.AuthService
+	authService  service.AuthService
+	tokenService service.PersonalAccessTokenService
+	auditLogger  service.AuditLogger
+	rejections   RateLimitStore
+	cookies      authcookie.Config
 }
 
-// NewAuthMiddleware crée une nouvelle instance de AuthMiddleware
-func NewAuthMiddleware(authService service.AuthService) *AuthMiddleware {
+// rejectionAuditWindow est la fenêtre pendant laquelle les refus répétés
+// d'une même IP, pour le même motif, ne donnent lieu qu'à un événement d'audit
+const rejectionAuditWindow = time.Minute
+
+// NewAuthMiddleware crée une nouvelle instance de AuthMiddleware. Les requêtes
+// refusées faute d'authentification valide sont enregistrées dans le journal
+// d'audit ; si rejections est fourni, il sert à n'en garder qu'une par IP et
+// par motif sur rejectionAuditWindow, le journal étant en ajout seul.
+func NewAuthMiddleware(authService service.AuthService, tokenService service.PersonalAccessTokenService, auditLogger service.AuditLogger, rejections RateLimitStore, cookies authcookie.Config) *AuthMiddleware {
 	return &AuthMiddleware{
-		authService: authService,
+		authService:  authService,
+		tokenService: tokenService,
+		auditLogger:  auditLogger,
+		rejections:   rejections,
+		cookies:      cookies,
 	}
 }
 
-// RequireAuth vérifie que l'utilisateur est authentifié
+// RequireAuth vérifie que l'utilisateur est authentifié. Un personal access
+// token est accepté à la place d'un access token si ses portées autorisent la
+// méthode de la requête. En mode cookies, l'access token est lu dans son
+// cookie à défaut du header Authorization, et les requêtes modifiantes
+// doivent porter le token CSRF.
 func (m *AuthMiddleware) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extraire le token du header Authorization
 		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			m.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token manquant")
-			return
+		var token string
+		switch {
+		case authHeader != "":
+			// Le format attendu est : "Bearer <token>"
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				m.rejectAuth(w, r, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Format de token invalide")
+				return
+			}
+			token = parts[1]
+		case m.cookies.Enabled:
+			token = authcookie.AccessToken(r)
+			if token != "" && !m.cookies.VerifyCSRF(r) {
+				m.rejectAuth(w, r, appErrors.ErrInvalidCSRFToken.StatusCode, appErrors.ErrInvalidCSRFToken.Code, appErrors.ErrInvalidCSRFToken.Message)
+				return
+			}
 		}
-
-		// Le format attendu est : "Bearer <token>"
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			m.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Format de token invalide")
+		if token == "" {
+			m.rejectAuth(w, r, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token manquant")
 			return
 		}
 
-		token := parts[1]
-
 		// Valider le token
-		claims, err := m.authService.ValidateToken(token)
+		claims, err := m.validateToken(token)
 		if err != nil {
-			m.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token invalide ou expiré")
+			if errors.Is(err, service.ErrAccountDisabled) {
+				m.rejectAuth(w, r, appErrors.ErrAccountDisabled.StatusCode, appErrors.ErrAccountDisabled.Code, appErrors.ErrAccountDisabled.Message)
+				return
+			}
+			if errors.Is(err, service.ErrTokenRevoked) {
+				m.rejectAuth(w, r, http.StatusUnauthorized, appErrors.ErrTokenRevoked.Code, "Token révoqué")
+				return
+			}
+			m.rejectAuth(w, r, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Token invalide ou expiré")
+			return
+		}
+
+		if !claims.AllowsMethod(r.Method) {
+			m.respondWithError(w, appErrors.ErrForbidden.StatusCode, appErrors.ErrForbidden.Code, "Portées du token insuffisantes")
 			return
 		}
 
 		// Ajouter l'user ID au contexte pour utilisation dans les handlers
 		ctx := context.WithValue(r.Context(), "userID", claims.UserID.String())
 		ctx = context.WithValue(ctx, "userEmail", claims.Email)
+		ctx = context.WithValue(ctx, "claims", claims)
 
 		// Passer à la suite avec le contexte enrichi
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
 
+// validateToken valide un personal access token, reconnaissable à son
+// préfixe, ou un access token JWT
+func (m *AuthMiddleware) validateToken(token string) (*service.JWTClaims, error) {
+	if strings.HasPrefix(token, service.PersonalAccessTokenPrefix) {
+		return m.tokenService.Authenticate(token)
+	}
+	return m.authService.ValidateAccessToken(token)
+}
+
+// RequireSession refuse les personal access tokens : la route exige une
+// connexion interactive (gestion des identifiants, des sessions et des
+// tokens). Doit être placé après RequireAuth.
+func (m *AuthMiddleware) RequireSession(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value("claims").(*service.JWTClaims)
+		if !ok {
+			m.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié")
+			return
+		}
+
+		if claims.TokenType == service.TokenTypePersonalAccess {
+			m.respondWithError(w, appErrors.ErrForbidden.StatusCode, appErrors.ErrForbidden.Code, "Opération impossible avec un token d'accès personnel")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// RequireRole exige que l'utilisateur authentifié possède le rôle. Doit être
+// placé après RequireAuth, qui pose les claims dans le contexte.
+func (m *AuthMiddleware) RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return m.authorize(func(claims *service.JWTClaims) bool {
+		return claims.HasRole(role)
+	}, next)
+}
+
+// RequirePermission exige qu'un des rôles de l'utilisateur authentifié accorde
+// la permission. Doit être placé après RequireAuth.
+func (m *AuthMiddleware) RequirePermission(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return m.authorize(func(claims *service.JWTClaims) bool {
+		return claims.HasPermission(permission)
+	}, next)
+}
+
+// authorize refuse la requête avec 403 si les claims ne satisfont pas la règle
+func (m *AuthMiddleware) authorize(allowed func(*service.JWTClaims) bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := r.Context().Value("claims").(*service.JWTClaims)
+		if !ok {
+			m.respondWithError(w, http.StatusUnauthorized, appErrors.ErrUnauthorized.Code, "Non authentifié")
+			return
+		}
+
+		if !allowed(claims) {
+			m.respondWithError(w, appErrors.ErrForbidden.StatusCode, appErrors.ErrForbidden.Code, appErrors.ErrForbidden.Message)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// rejectAuth enregistre dans le journal d'audit une requête dont
+// l'authentification a échoué, puis envoie la réponse d'erreur
+func (m *AuthMiddleware) rejectAuth(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	if m.shouldAuditRejection(ip, code) {
+		m.auditLogger.Record(service.AuditEntry{
+			Event:     service.AuditEventUnauthorized,
+			Client:    service.ClientInfo{UserAgent: r.UserAgent(), IP: ip},
+			Outcome:   service.AuditOutcomeFailure,
+			ErrorCode: code,
+		})
+	}
+
+	m.respondWithError(w, status, code, message)
+}
+
+// shouldAuditRejection indique si le refus doit être journalisé : seul le
+// premier refus d'une IP pour un motif donné l'est sur la fenêtre. En cas
+// d'erreur du stockage, le refus est journalisé.
+func (m *AuthMiddleware) shouldAuditRejection(ip, code string) bool {
+	if m.rejections == nil {
+		return true
+	}
+	count, _, err := m.rejections.Hit("unauthorized:"+ip+":"+code, rejectionAuditWindow, time.Now())
+	return err != nil || count == 1
+}
+
 // respondWithError envoie une réponse d'erreur JSON
 func (m *AuthMiddleware) respondWithError(w http.ResponseWriter, status int, code, message string) {
 	errorResponse := map[string]interface{}{
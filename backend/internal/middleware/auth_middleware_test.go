@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/authcookie"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function pour envoyer une requête portant les claims donnés, comme
+// après RequireAuth
+func doAuthorizedRequest(handler http.HandlerFunc, claims *service.JWTClaims) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), "claims", claims))
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestRequireRole(t *testing.T) {
+	m := NewAuthMiddleware(nil, nil, nil, nil, authcookie.Config{})
+	handler := m.RequireRole(service.RoleAdmin, okHandler)
+
+	cases := []struct {
+		name     string
+		claims   *service.JWTClaims
+		expected int
+	}{
+		{"rôle présent", &service.JWTClaims{Roles: []string{service.RoleAdmin}}, http.StatusOK},
+		{"rôle absent", &service.JWTClaims{Roles: []string{"moderator"}}, http.StatusForbidden},
+		{"non authentifié", nil, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := doAuthorizedRequest(handler, tc.claims)
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
+
+func TestRequirePermission_ForbiddenResponse(t *testing.T) {
+	// Arrange
+	m := NewAuthMiddleware(nil, nil, nil, nil, authcookie.Config{})
+	handler := m.RequirePermission(service.PermissionUsersWrite, okHandler)
+	claims := &service.JWTClaims{Permissions: []string{service.PermissionUsersRead}}
+
+	// Act
+	rec := doAuthorizedRequest(handler, claims)
+
+	// Assert
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "ERR_PERM_001", body.Error.Code)
+
+	// La permission accordée laisse passer la requête
+	claims.Permissions = append(claims.Permissions, service.PermissionUsersWrite)
+	assert.Equal(t, http.StatusOK, doAuthorizedRequest(handler, claims).Code)
+}
+
+// stubTokenService authentifie un unique personal access token avec les portées données
+type stubTokenService struct {
+	token  string
+	scopes []string
+}
+
+func (s *stubTokenService) Create(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.PersonalAccessToken, string, error) {
+	return nil, "", nil
+}
+
+func (s *stubTokenService) List(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	return nil, nil
+}
+
+func (s *stubTokenService) Revoke(userID, tokenID uuid.UUID) error {
+	return nil
+}
+
+func (s *stubTokenService) Authenticate(token string) (*service.JWTClaims, error) {
+	if token != s.token {
+		return nil, service.ErrInvalidToken
+	}
+	return &service.JWTClaims{UserID: uuid.New(), TokenType: service.TokenTypePersonalAccess, Scopes: s.scopes}, nil
+}
+
+// stubAuditLogger conserve les événements enregistrés
+type stubAuditLogger struct {
+	entries []service.AuditEntry
+}
+
+func (l *stubAuditLogger) Record(entry service.AuditEntry) {
+	l.entries = append(l.entries, entry)
+}
+
+func (l *stubAuditLogger) ListForUser(userID uuid.UUID, page, pageSize int) (*service.AuditEventPage, error) {
+	return nil, nil
+}
+
+func (l *stubAuditLogger) Query(query service.AuditQuery, page, pageSize int) (*service.AuditEventPage, error) {
+	return nil, nil
+}
+
+// Helper function pour envoyer une requête authentifiée par un personal access token
+func doTokenRequest(handler http.HandlerFunc, method, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestRequireAuth_PersonalAccessTokenScopes(t *testing.T) {
+	token := service.PersonalAccessTokenPrefix + "secret"
+
+	cases := []struct {
+		name     string
+		scopes   []string
+		method   string
+		token    string
+		expected int
+	}{
+		{"lecture avec read", []string{service.ScopeRead}, http.MethodGet, token, http.StatusOK},
+		{"écriture avec read", []string{service.ScopeRead}, http.MethodPost, token, http.StatusForbidden},
+		{"écriture avec write", []string{service.ScopeWrite}, http.MethodPost, token, http.StatusOK},
+		{"lecture avec write", []string{service.ScopeWrite}, http.MethodGet, token, http.StatusOK},
+		{"token inconnu", []string{service.ScopeWrite}, http.MethodGet, service.PersonalAccessTokenPrefix + "other", http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewAuthMiddleware(nil, &stubTokenService{token: token, scopes: tc.scopes}, &stubAuditLogger{}, nil, authcookie.Config{})
+			rec := doTokenRequest(m.RequireAuth(okHandler), tc.method, tc.token)
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
+
+func TestRequireAuth_RejectionIsAudited(t *testing.T) {
+	// Arrange
+	audit := &stubAuditLogger{}
+	m := NewAuthMiddleware(nil, &stubTokenService{token: service.PersonalAccessTokenPrefix + "secret"}, audit, nil, authcookie.Config{})
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+service.PersonalAccessTokenPrefix+"other")
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.RemoteAddr = "192.0.2.10:54321"
+	rec := httptest.NewRecorder()
+
+	// Act
+	m.RequireAuth(okHandler)(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Len(t, audit.entries, 1)
+	entry := audit.entries[0]
+	assert.Equal(t, service.AuditEventUnauthorized, entry.Event)
+	assert.Equal(t, service.AuditOutcomeFailure, entry.Outcome)
+	assert.Equal(t, "ERR_AUTH_001", entry.ErrorCode)
+	assert.Equal(t, service.ClientInfo{UserAgent: "curl/8.0", IP: "192.0.2.10"}, entry.Client)
+}
+
+func TestRequireAuth_RepeatedRejectionsAreCoalesced(t *testing.T) {
+	// Arrange
+	audit := &stubAuditLogger{}
+	m := NewAuthMiddleware(nil, nil, audit, NewMemoryRateLimitStore(), authcookie.Config{})
+	reject := func(remoteAddr string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		m.RequireAuth(okHandler)(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	// Act
+	for i := 0; i < 5; i++ {
+		reject("192.0.2.10:54321")
+	}
+	reject("192.0.2.11:54321")
+
+	// Assert : un seul événement par IP et par motif sur la fenêtre
+	require.Len(t, audit.entries, 2)
+	assert.Equal(t, "192.0.2.10", audit.entries[0].Client.IP)
+	assert.Equal(t, "192.0.2.11", audit.entries[1].Client.IP)
+}
+
+func TestRequireAuth_CookieModeCSRF(t *testing.T) {
+	token := service.PersonalAccessTokenPrefix + "secret"
+	cookies := authcookie.Config{Enabled: true, CSRF: true}
+
+	cases := []struct {
+		name     string
+		method   string
+		csrf     string
+		expected int
+	}{
+		{"lecture sans token CSRF", http.MethodGet, "", http.StatusOK},
+		{"écriture sans token CSRF", http.MethodPost, "", http.StatusForbidden},
+		{"écriture avec un token CSRF différent", http.MethodPost, "other", http.StatusForbidden},
+		{"écriture avec le token CSRF", http.MethodPost, "csrf-value", http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			m := NewAuthMiddleware(nil, &stubTokenService{token: token, scopes: []string{service.ScopeWrite}}, &stubAuditLogger{}, nil, cookies)
+			req := httptest.NewRequest(tc.method, "/api/users/me/export", nil)
+			req.AddCookie(&http.Cookie{Name: authcookie.AccessTokenCookie, Value: token})
+			req.AddCookie(&http.Cookie{Name: authcookie.CSRFCookie, Value: "csrf-value"})
+			if tc.csrf != "" {
+				req.Header.Set(authcookie.CSRFHeader, tc.csrf)
+			}
+			rec := httptest.NewRecorder()
+
+			// Act
+			m.RequireAuth(okHandler)(rec, req)
+
+			// Assert
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
+
+func TestRequireAuth_CookieIgnoredWhenModeDisabled(t *testing.T) {
+	token := service.PersonalAccessTokenPrefix + "secret"
+	m := NewAuthMiddleware(nil, &stubTokenService{token: token, scopes: []string{service.ScopeRead}}, &stubAuditLogger{}, nil, authcookie.Config{})
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me/export", nil)
+	req.AddCookie(&http.Cookie{Name: authcookie.AccessTokenCookie, Value: token})
+	rec := httptest.NewRecorder()
+
+	m.RequireAuth(okHandler)(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequireSession(t *testing.T) {
+	m := NewAuthMiddleware(nil, nil, nil, nil, authcookie.Config{})
+	handler := m.RequireSession(okHandler)
+
+	cases := []struct {
+		name     string
+		claims   *service.JWTClaims
+		expected int
+	}{
+		{"access token", &service.JWTClaims{TokenType: service.TokenTypeAccess}, http.StatusOK},
+		{"personal access token", &service.JWTClaims{TokenType: service.TokenTypePersonalAccess, Scopes: []string{service.ScopeWrite}}, http.StatusForbidden},
+		{"non authentifié", nil, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := doAuthorizedRequest(handler, tc.claims)
+			assert.Equal(t, tc.expected, rec.Code)
+		})
+	}
+}
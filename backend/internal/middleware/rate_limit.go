@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+)
+
+// RateLimitStore compte les requêtes par clé sur des fenêtres fixes.
+// Hit enregistre une requête et retourne le nombre de requêtes de la fenêtre
+// courante, requête incluse, ainsi que la fin de cette fenêtre.
+type RateLimitStore interface {
+	Hit(key string, window time.Duration, now time.Time) (count int, resetAt time.Time, err error)
+}
+
+// KeyFunc extrait de la requête l'identifiant du client à limiter
+type KeyFunc func(r *http.Request) string
+
+// RateLimitPolicy décrit la limite appliquée à une route ou un groupe de routes
+type RateLimitPolicy struct {
+	Name   string        // nom de la politique, préfixe des clés de comptage
+	Limit  int           // nombre de requêtes autorisées par fenêtre
+	Window time.Duration // durée de la fenêtre
+	Key    KeyFunc       // identifiant du client ; KeyByIP par défaut
+}
+
+// RateLimiter applique des politiques de limitation de débit aux routes
+type RateLimiter struct {
+	store   RateLimitStore
+	enabled bool
+}
+
+// NewRateLimiter crée une nouvelle instance de RateLimiter. Désactivé, il
+// laisse passer toutes les requêtes sans ajouter d'en-têtes.
+func NewRateLimiter(store RateLimitStore, enabled bool) *RateLimiter {
+	return &RateLimiter{
+		store:   store,
+		enabled: enabled,
+	}
+}
+
+// Limit applique la politique à la route. Les en-têtes RateLimit-* indiquent
+// l'état du quota ; au-delà, la requête est refusée avec 429 et Retry-After.
+// Pour limiter par utilisateur, Limit doit être placé après RequireAuth.
+func (l *RateLimiter) Limit(policy RateLimitPolicy, next http.HandlerFunc) http.HandlerFunc {
+	if !l.enabled || policy.Limit <= 0 {
+		return next
+	}
+	keyFunc := policy.Key
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		count, resetAt, err := l.store.Hit(policy.Name+":"+keyFunc(r), policy.Window, now)
+		if err != nil {
+			// Un incident du stockage ne doit pas rendre l'API indisponible
+			log.Printf("limitation de débit %s indisponible: %v", policy.Name, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reset := int(math.Ceil(resetAt.Sub(now).Seconds()))
+		remaining := policy.Limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		w.Header().Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(int(policy.Window.Seconds())))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+
+		if count > policy.Limit {
+			w.Header().Set("Retry-After", strconv.Itoa(reset))
+			respondWithRateLimitError(w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// KeyByIP identifie le client par son adresse IP
+func KeyByIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return "ip:" + ip
+}
+
+// KeyByUser identifie le client par l'utilisateur authentifié (valeur "userID"
+// du contexte posée par RequireAuth), ou à défaut par son adresse IP
+func KeyByUser(r *http.Request) string {
+	if userID, ok := r.Context().Value("userID").(string); ok && userID != "" {
+		return "user:" + userID
+	}
+	return KeyByIP(r)
+}
+
+// respondWithRateLimitError envoie l'erreur de dépassement de quota
+func respondWithRateLimitError(w http.ResponseWriter) {
+	errorResponse := map[string]interface{}{
+		"error": map[string]string{
+			"code":    appErrors.ErrRateLimited.Code,
+			"message": appErrors.ErrRateLimited.Message,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErrors.ErrRateLimited.StatusCode)
+	json.NewEncoder(w).Encode(errorResponse)
+}
+
+// memoryRateLimitStore implémente RateLimitStore en mémoire. Les compteurs ne
+// sont pas partagés entre les instances : à réserver au développement ou à un
+// déploiement mono-instance.
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+// memoryCounter est le compteur d'une clé sur la fenêtre courante
+type memoryCounter struct {
+	count   int
+	resetAt time.Time
+}
+
+// memorySweepInterval espace les purges des compteurs expirés
+const memorySweepInterval = time.Minute
+
+// NewMemoryRateLimitStore crée un stockage des compteurs en mémoire
+func NewMemoryRateLimitStore() RateLimitStore {
+	return &memoryRateLimitStore{counters: make(map[string]*memoryCounter)}
+}
+
+// Hit enregistre une requête pour la clé
+func (s *memoryRateLimitStore) Hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, counter := range s.counters {
+			if !now.Before(counter.resetAt) {
+				delete(s.counters, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.resetAt) {
+		counter = &memoryCounter{resetAt: now.Add(window)}
+		s.counters[key] = counter
+	}
+	counter.count++
+
+	return counter.count, counter.resetAt, nil
+}
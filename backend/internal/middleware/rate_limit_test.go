@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingStore simule un stockage indisponible
+type failingStore struct{}
+
+func (failingStore) Hit(string, time.Duration, time.Time) (int, time.Time, error) {
+	return 0, time.Time{}, errors.New("database down")
+}
+
+// okHandler répond 200 aux requêtes qui passent la limite
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// Helper function pour envoyer une requête depuis une adresse donnée
+func doRequest(handler http.HandlerFunc, remoteAddr string, mutate func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	if mutate != nil {
+		mutate(req)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestRateLimit_HeadersAndRejection(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), true)
+	handler := limiter.Limit(RateLimitPolicy{Name: "login", Limit: 2, Window: time.Minute}, okHandler)
+
+	// Act
+	first := doRequest(handler, "192.0.2.10:1234", nil)
+	second := doRequest(handler, "192.0.2.10:1234", nil)
+	third := doRequest(handler, "192.0.2.10:5678", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", first.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "2;w=60", first.Header().Get("RateLimit-Policy"))
+
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, "0", second.Header().Get("RateLimit-Remaining"))
+
+	assert.Equal(t, http.StatusTooManyRequests, third.Code)
+	assert.Equal(t, "60", third.Header().Get("Retry-After"))
+
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(third.Body).Decode(&body))
+	assert.Equal(t, "ERR_RATE_001", body.Error.Code)
+}
+
+func TestRateLimit_KeysAreIsolated(t *testing.T) {
+	// Arrange
+	store := NewMemoryRateLimitStore()
+	limiter := NewRateLimiter(store, true)
+	login := limiter.Limit(RateLimitPolicy{Name: "login", Limit: 1, Window: time.Minute}, okHandler)
+	register := limiter.Limit(RateLimitPolicy{Name: "register", Limit: 1, Window: time.Minute}, okHandler)
+
+	// Act
+	doRequest(login, "192.0.2.10:1234", nil)
+	otherIP := doRequest(login, "192.0.2.11:1234", nil)
+	otherPolicy := doRequest(register, "192.0.2.10:1234", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, otherIP.Code)
+	assert.Equal(t, http.StatusOK, otherPolicy.Code)
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), false)
+	handler := limiter.Limit(RateLimitPolicy{Name: "login", Limit: 1, Window: time.Minute}, okHandler)
+
+	// Act
+	doRequest(handler, "192.0.2.10:1234", nil)
+	rec := doRequest(handler, "192.0.2.10:1234", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimit_StoreFailureLetsRequestsThrough(t *testing.T) {
+	// Arrange
+	limiter := NewRateLimiter(failingStore{}, true)
+	handler := limiter.Limit(RateLimitPolicy{Name: "login", Limit: 1, Window: time.Minute}, okHandler)
+
+	// Act
+	rec := doRequest(handler, "192.0.2.10:1234", nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/me", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+
+	assert.Equal(t, "ip:192.0.2.10", KeyByIP(req))
+	assert.Equal(t, "ip:192.0.2.10", KeyByUser(req))
+
+	authenticated := req.WithContext(context.WithValue(req.Context(), "userID", "8b7c1f9e-0000-0000-0000-000000000001"))
+	assert.Equal(t, "user:8b7c1f9e-0000-0000-0000-000000000001", KeyByUser(authenticated))
+}
+
+func TestMemoryStore_WindowReset(t *testing.T) {
+	// Arrange
+	store := NewMemoryRateLimitStore()
+	now := time.Now()
+
+	// Act
+	count1, reset1, _ := store.Hit("k", time.Minute, now)
+	count2, _, _ := store.Hit("k", time.Minute, now.Add(30*time.Second))
+	count3, reset3, _ := store.Hit("k", time.Minute, now.Add(61*time.Second))
+
+	// Assert
+	assert.Equal(t, 1, count1)
+	assert.Equal(t, 2, count2)
+	assert.Equal(t, 1, count3)
+	assert.True(t, reset3.After(reset1))
+}
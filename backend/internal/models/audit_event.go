@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEvent représente un événement de sécurité lié à l'authentification
+// (inscription, connexion, renouvellement de token, requête refusée...).
+// Le journal est en ajout seul : les événements ne sont ni modifiés ni
+// supprimés. À la purge d'un compte, ils sont seulement pseudonymisés
+// (utilisateur, email, adresse IP et user agent effacés).
+type AuditEvent struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index" json:"userId,omitempty"` // NULL si l'acteur n'a pas été identifié
+	Email     string     `gorm:"not null;default:''" json:"email"`        // email présenté, même sans compte correspondant
+	Event     string     `gorm:"not null;index" json:"event"`
+	Outcome   string     `gorm:"not null" json:"outcome"`
+	ErrorCode string     `gorm:"not null;default:''" json:"errorCode,omitempty"`
+	IP        string     `gorm:"not null;default:''" json:"ip"`
+	UserAgent string     `gorm:"not null;default:''" json:"userAgent"` // tronqué à 512 caractères
+	CreatedAt time.Time  `gorm:"index" json:"createdAt"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Statuts d'un export des données personnelles
+const (
+	DataExportPending    = "pending"    // en attente d'un worker
+	DataExportProcessing = "processing" // archive en cours de construction
+	DataExportReady      = "ready"      // archive téléchargeable jusqu'à ExpiresAt
+	DataExportFailed     = "failed"     // construction en échec, une nouvelle demande est possible
+	DataExportExpired    = "expired"    // archive supprimée à l'expiration du lien
+)
+
+// DataExport représente une demande d'export des données personnelles d'un
+// utilisateur (RGPD). L'archive ZIP est construite en tâche de fond puis
+// conservée jusqu'à l'expiration du lien de téléchargement.
+type DataExport struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Status      string     `gorm:"not null;default:'pending';index" json:"status"`
+	FileName    string     `gorm:"not null;default:''" json:"-"` // nom de l'archive dans le dossier des exports
+	Size        int64      `gorm:"not null;default:0" json:"size"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"-"` // prise en charge par un worker
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (DataExport) TableName() string {
+	return "data_exports"
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// LoginThrottle compte les échecs de connexion récents pour une clé
+// ("account:<email>" ou "ip:<adresse>"). Partagé en base entre les instances.
+type LoginThrottle struct {
+	Key           string     `gorm:"primary_key" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OIDCAuthRequest conserve côté serveur une demande d'autorisation en cours
+// auprès d'un fournisseur OpenID Connect. Elle est identifiée par le hash du
+// paramètre state et supprimée dès son utilisation.
+type OIDCAuthRequest struct {
+	ID           uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	StateHash    string    `gorm:"uniqueIndex;not null" json:"-"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"` // Code verifier PKCE, jamais transmis au navigateur
+	ExpiresAt    time.Time `gorm:"not null" json:"expiresAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (r *OIDCAuthRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (OIDCAuthRequest) TableName() string {
+	return "oidc_auth_requests"
+}
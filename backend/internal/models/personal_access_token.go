@@ -0,0 +1,43 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessToken représente un token d'API créé par un utilisateur pour
+// ses scripts et intégrations. Seul le hash SHA-256 du token est conservé ;
+// les portées sont stockées séparées par des espaces.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	Name       string     `gorm:"not null" json:"name"`
+	TokenHash  string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"not null;default:''" json:"scopes"`
+	Generation int        `gorm:"not null;default:0" json:"-"` // génération de tokens de l'utilisateur à la création
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`         // nil : sans expiration
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// ScopeList retourne les portées du token
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (t *PersonalAccessToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitCounter compte les requêtes d'un client pour une politique de
+// limitation de débit sur la fenêtre qui se termine à ResetAt
+type RateLimitCounter struct {
+	Key     string    `gorm:"primary_key" json:"key"`
+	Count   int       `gorm:"not null" json:"count"`
+	ResetAt time.Time `gorm:"not null;index" json:"resetAt"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (RateLimitCounter) TableName() string {
+	return "rate_limit_counters"
+}
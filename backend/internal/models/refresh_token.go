@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken représente un refresh token émis et suivi côté serveur.
+// Les tokens d'une même connexion partagent un FamilyID : chaque rotation
+// crée un nouveau token dans la famille et marque le précédent comme utilisé.
+type RefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	FamilyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"familyId"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expiresAt"`
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken représente un access token révoqué avant son expiration.
+// L'entrée peut être supprimée une fois ExpiresAt dépassé, le token étant
+// alors rejeté de toute façon.
+type RevokedToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"` // claim jti du token
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"userId"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Role regroupe des permissions attribuables aux utilisateurs
+type Role struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"not null;default:''" json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (Role) TableName() string {
+	return "roles"
+}
+
+// Permission autorise une action, sous la forme "<ressource>:<action>" (ex. users:write)
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"not null;default:''" json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (p *Permission) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RolePermission associe une permission à un rôle
+type RolePermission struct {
+	RoleID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	PermissionID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// UserRole attribue un rôle à un utilisateur
+type UserRole struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RoleID    uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	GrantedAt time.Time `gorm:"not null"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (UserRole) TableName() string {
+	return "user_roles"
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session représente une connexion active d'un utilisateur sur un appareil.
+// Elle est liée à une famille de refresh tokens : révoquer la session
+// révoque la famille.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"userId"`
+	FamilyID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"familyId"`
+	UserAgent  string     `gorm:"not null;default:''" json:"userAgent"`
+	IP         string     `gorm:"not null;default:''" json:"ip"`
+	RememberMe bool       `gorm:"not null;default:false" json:"rememberMe"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `gorm:"not null" json:"lastUsedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // expiration du dernier refresh token émis, NULL pour les sessions antérieures
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+// BeforeCreate hook GORM pour générer un UUID avant la création
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// TableName spécifie le nom de la table en base de données
+func (Session) TableName() string {
+	return "sessions"
+}
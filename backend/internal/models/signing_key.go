@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// SigningKey représente une clé de signature des JWT, identifiée par son kid.
+// Une clé est publiée dès sa création, signe à partir de ActivatesAt et reste
+// publiée pour la vérification jusqu'à ExpiresAt (nul tant qu'aucune rotation
+// ne l'a remplacée).
+type SigningKey struct {
+	ID          string     `gorm:"primary_key" json:"kid"`
+	Algorithm   string     `gorm:"not null" json:"alg"`
+	PrivateKey  string     `gorm:"not null" json:"-"` // Clé privée PKCS#8 (PEM) chiffrée
+	PublicKey   string     `gorm:"not null" json:"-"` // Clé publique PKIX (PEM)
+	ActivatesAt time.Time  `gorm:"not null;index" json:"activatesAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// TableName spécifie le nom de la table en base de données
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
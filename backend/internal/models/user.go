@@ -9,9 +9,30 @@ import (
 
 // User représente un utilisateur de l'application
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"-"` // Le tag json:"-" empêche l'export du password en JSON
+	ID                    uuid.UUID  `gorm:"type:uuid;primary_key" json:"id"`
+	Email                 string     `gorm:"uniqueIndex;not null" json:"email"`
+	PendingEmail          *string    `json:"-"`                           // Nouvelle adresse en attente de confirmation
+	Password              string     `gorm:"not null" json:"-"`           // Le tag json:"-" empêche l'export du password en JSON
+	TokenGeneration       int        `gorm:"not null;default:0" json:"-"` // Incrémenté par "déconnecter partout" pour invalider les tokens émis
+	EmailVerifiedAt       *time.Time `json:"emailVerifiedAt,omitempty"`
+	VerificationSentAt    *time.Time `json:"-"`                            // Dernier envoi du lien de vérification (throttling)
+	TOTPSecret            string     `gorm:"not null;default:''" json:"-"` // Secret TOTP chiffré, en attente de confirmation tant que TOTPEnabledAt est nul
+	TOTPEnabledAt         *time.Time `json:"totpEnabledAt,omitempty"`
+	TOTPLastStep          int64      `gorm:"not null;default:0" json:"-"`     // Dernière période TOTP acceptée, pour refuser le rejeu d'un code
+	DeletionRequestedAt   *time.Time `gorm:"index" json:"-"`                  // Suppression demandée : le compte est purgé à la fin du délai de grâce
+	DisabledAt            *time.Time `json:"-"`                               // Compte désactivé par un administrateur : connexion et tokens refusés
+	PasswordResetRequired bool       `gorm:"not null;default:false" json:"-"` // Réinitialisation imposée par un administrateur avant toute nouvelle connexion
+
+	// Profil, modifiable par l'utilisateur
+	DisplayName string  `gorm:"not null;default:''" json:"displayName"`
+	Username    *string `gorm:"uniqueIndex:idx_users_username_lower,expression:LOWER(username)" json:"username,omitempty"` // Identifiant public, unique sans tenir compte de la casse
+	Bio         string  `gorm:"not null;default:''" json:"bio"`
+	Locale      string  `gorm:"not null;default:''" json:"locale"`          // Tag de langue BCP 47 (ex. fr-FR)
+	Timezone    string  `gorm:"not null;default:''" json:"timezone"`        // Fuseau horaire IANA (ex. Europe/Paris)
+	Currency    string  `gorm:"size:3;not null;default:''" json:"currency"` // Devise préférée, code ISO 4217
+	AvatarKey   string  `gorm:"not null;default:''" json:"-"`               // Préfixe des fichiers de l'avatar dans le stockage, vide sans avatar
+	AvatarURL   string  `gorm:"not null;default:''" json:"-"`               // URL publique correspondant à AvatarKey
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,252 @@
+// Package oidctest fournit un fournisseur OpenID Connect local pour les tests :
+// découverte, JWKS, endpoint token avec vérification PKCE et endpoint userinfo.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims décrit l'utilisateur authentifié par le fournisseur de test
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// authorization représente un code d'autorisation émis et non encore échangé
+type authorization struct {
+	claims        Claims
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+// Server est un fournisseur OIDC de test
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	// OmitIDToken simule un fournisseur OAuth2 sans ID token (identité via userinfo)
+	OmitIDToken bool
+
+	key *rsa.PrivateKey
+	kid string
+
+	mu           sync.Mutex
+	codes        map[string]authorization
+	accessTokens map[string]Claims
+}
+
+// NewServer démarre un fournisseur de test pour le client donné
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		kid:          "test-key-1",
+		codes:        make(map[string]authorization),
+		accessTokens: make(map[string]Claims),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /jwks", s.jwks)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /userinfo", s.userInfo)
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Issuer retourne l'émetteur du fournisseur
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// Authorize simule l'authentification de l'utilisateur sur la page du
+// fournisseur : à partir de l'URL d'autorisation, retourne le code et le state
+// qui seraient transmis à l'URL de redirection
+func (s *Server) Authorize(authURL string, claims Claims) (code, state string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := parsed.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" {
+		return "", "", errors.New("demande d'autorisation invalide")
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		return "", "", errors.New("PKCE requis")
+	}
+
+	code = randomString()
+	s.mu.Lock()
+	s.codes[code] = authorization{
+		claims:        claims,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+	s.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken signe des claims arbitraires avec la clé du fournisseur, pour
+// construire des ID tokens invalides dans les tests
+func (s *Server) SignIDToken(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// discovery publie le document de découverte
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"userinfo_endpoint":                     s.URL + "/userinfo",
+		"jwks_uri":                              s.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// jwks publie la clé publique de signature
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": s.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token échange un code d'autorisation après vérification du client et du PKCE
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	if r.PostForm.Get("client_id") != s.ClientID || r.PostForm.Get("client_secret") != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	auth, ok := s.codes[r.PostForm.Get("code")]
+	delete(s.codes, r.PostForm.Get("code")) // usage unique
+	s.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE"})
+		return
+	}
+
+	accessToken := randomString()
+	s.mu.Lock()
+	s.accessTokens[accessToken] = auth.claims
+	s.mu.Unlock()
+
+	response := map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	if !s.OmitIDToken {
+		now := time.Now()
+		response["id_token"] = s.SignIDToken(jwt.MapClaims{
+			"iss":            s.URL,
+			"sub":            auth.claims.Subject,
+			"aud":            s.ClientID,
+			"exp":            now.Add(time.Hour).Unix(),
+			"iat":            now.Unix(),
+			"nonce":          auth.nonce,
+			"email":          auth.claims.Email,
+			"email_verified": auth.claims.EmailVerified,
+			"name":           auth.claims.Name,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// userInfo retourne le profil associé à un access token
+func (s *Server) userInfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) || header[:len(prefix)] != prefix {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	s.mu.Lock()
+	claims, ok := s.accessTokens[header[len(prefix):]]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"sub":            claims.Subject,
+		"email":          claims.Email,
+		"email_verified": claims.EmailVerified,
+		"name":           claims.Name,
+	})
+}
+
+// writeJSON écrit une réponse JSON
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// randomString génère une valeur opaque aléatoire
+func randomString() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
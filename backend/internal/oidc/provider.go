@@ -0,0 +1,346 @@
+// Package oidc implémente la partie "relying party" d'OpenID Connect :
+// flux authorization code avec PKCE, découverte du fournisseur et validation
+// des ID tokens. Les fournisseurs OAuth2 sans ID token (GitHub) sont pris en
+// charge via leur endpoint userinfo.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrDiscovery      = errors.New("découverte du fournisseur OIDC impossible")
+	ErrExchange       = errors.New("échange du code d'autorisation refusé")
+	ErrInvalidIDToken = errors.New("ID token invalide")
+	ErrUserInfo       = errors.New("récupération du profil utilisateur impossible")
+)
+
+// clockSkew est la tolérance sur les dates des ID tokens
+const clockSkew = time.Minute
+
+// Config décrit un fournisseur d'identité. Issuer suffit pour un fournisseur
+// OIDC : les endpoints sont découverts. Les endpoints explicites permettent de
+// configurer un fournisseur OAuth2 sans découverte.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	TrustEmail   bool // le fournisseur ne communique que des adresses vérifiées, sans claim email_verified
+}
+
+// Tokens représente la réponse de l'endpoint token du fournisseur
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+}
+
+// Identity représente l'utilisateur authentifié par le fournisseur
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// metadata représente le document de découverte du fournisseur
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider est un client pour un fournisseur d'identité. La découverte est
+// effectuée au premier usage pour ne pas bloquer le démarrage du serveur.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *metadata
+	keys     *keySet
+}
+
+// NewProvider crée un client pour le fournisseur configuré
+func NewProvider(cfg Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// Name retourne le nom du fournisseur
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL construit l'URL d'autorisation vers laquelle rediriger l'utilisateur
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return meta.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange échange le code d'autorisation contre des tokens en présentant le code verifier PKCE
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens Tokens
+	if err := doJSON(p.client, req, &tokens); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if tokens.IDToken == "" && tokens.AccessToken == "" {
+		return nil, ErrExchange
+	}
+
+	return &tokens, nil
+}
+
+// Identity retourne l'utilisateur authentifié : à partir de l'ID token, qui est
+// vérifié (signature, émetteur, audience, expiration, nonce), ou à défaut via
+// l'endpoint userinfo pour les fournisseurs OAuth2 sans ID token
+func (p *Provider) Identity(ctx context.Context, tokens *Tokens, nonce string) (*Identity, error) {
+	if tokens.IDToken != "" {
+		return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+	}
+
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if meta.UserInfoEndpoint == "" {
+		return nil, ErrInvalidIDToken
+	}
+	return p.userInfo(ctx, meta.UserInfoEndpoint, tokens.AccessToken)
+}
+
+// idTokenClaims représente les claims utilisés d'un ID token
+type idTokenClaims struct {
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+	AuthorizedParty string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// verifyIDToken vérifie un ID token et en extrait l'identité
+func (p *Provider) verifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseWithClaims(rawIDToken, &idTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	claims, ok := token.Claims.(*idTokenClaims)
+	if !ok || !token.Valid || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	// Le nonce lie l'ID token à la demande d'autorisation
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce inattendu", ErrInvalidIDToken)
+	}
+
+	// Avec plusieurs audiences, le token doit avoir été émis pour ce client
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp inattendu", ErrInvalidIDToken)
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified) || (p.cfg.TrustEmail && claims.Email != ""),
+		Name:          claims.Name,
+	}, nil
+}
+
+// userInfo récupère l'identité depuis l'endpoint userinfo du fournisseur
+func (p *Provider) userInfo(ctx context.Context, endpoint, accessToken string) (*Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var info struct {
+		Subject       string       `json:"sub"`
+		ID            json.Number  `json:"id"` // identifiant des fournisseurs OAuth2 non OIDC
+		Email         string       `json:"email"`
+		EmailVerified flexibleBool `json:"email_verified"`
+		Name          string       `json:"name"`
+	}
+	if err := doJSON(p.client, req, &info); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUserInfo, err)
+	}
+
+	subject := info.Subject
+	if subject == "" {
+		subject = info.ID.String()
+	}
+	if subject == "" {
+		return nil, ErrUserInfo
+	}
+
+	return &Identity{
+		Subject:       subject,
+		Email:         info.Email,
+		EmailVerified: bool(info.EmailVerified) || (p.cfg.TrustEmail && info.Email != ""),
+		Name:          info.Name,
+	}, nil
+}
+
+// discover charge les métadonnées du fournisseur. Les endpoints configurés
+// explicitement sont prioritaires sur ceux du document de découverte.
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	meta := &metadata{Issuer: p.cfg.Issuer}
+	if p.cfg.Issuer != "" && (p.cfg.AuthURL == "" || p.cfg.TokenURL == "") {
+		wellKnown := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := doJSON(p.client, req, meta); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+		}
+		// L'émetteur annoncé doit être celui configuré (OpenID Connect Discovery, section 4.3)
+		if meta.Issuer != p.cfg.Issuer {
+			return nil, fmt.Errorf("%w: émetteur %q inattendu", ErrDiscovery, meta.Issuer)
+		}
+	}
+
+	if p.cfg.AuthURL != "" {
+		meta.AuthorizationEndpoint = p.cfg.AuthURL
+	}
+	if p.cfg.TokenURL != "" {
+		meta.TokenEndpoint = p.cfg.TokenURL
+	}
+	if p.cfg.UserInfoURL != "" {
+		meta.UserInfoEndpoint = p.cfg.UserInfoURL
+	}
+	if p.cfg.JWKSURL != "" {
+		meta.JWKSURI = p.cfg.JWKSURL
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" {
+		return nil, fmt.Errorf("%w: endpoints manquants", ErrDiscovery)
+	}
+
+	p.metadata = meta
+	p.keys = newKeySet(p.client, meta.JWKSURI)
+	return meta, nil
+}
+
+// doJSON exécute une requête et décode sa réponse JSON
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("statut HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// flexibleBool accepte un booléen JSON ou sa représentation en chaîne,
+// certains fournisseurs retournant email_verified sous la forme "true"
+type flexibleBool bool
+
+// UnmarshalJSON implémente json.Unmarshaler
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseBool(strings.Trim(string(data), `"`))
+	if err != nil {
+		*b = false
+		return nil
+	}
+	*b = flexibleBool(value)
+	return nil
+}
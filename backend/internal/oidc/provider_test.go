@@ -0,0 +1,208 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function pour créer un fournisseur de test et son client
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+	server := oidctest.NewServer("client-id", "client-secret")
+	t.Cleanup(server.Close)
+
+	provider := NewProvider(Config{
+		Name:         "mock",
+		Issuer:       server.Issuer(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:3000/auth/oidc/mock/callback",
+	}, server.Client())
+
+	return server, provider
+}
+
+// Helper function pour dérouler le flux jusqu'à l'échange du code
+func authorizeAndExchange(t *testing.T, server *oidctest.Server, provider *Provider, claims oidctest.Claims) (*Tokens, string) {
+	ctx := context.Background()
+	verifier, err := GenerateCodeVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", CodeChallengeS256(verifier))
+	require.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL, claims)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	tokens, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+	return tokens, "nonce-1"
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	// Arrange
+	server, provider := newTestProvider(t)
+
+	// Act
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+
+	// Assert
+	require.NoError(t, err)
+	parsed, _ := url.Parse(authURL)
+	assert.Equal(t, server.Issuer()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+	assert.Equal(t, "http://localhost:3000/auth/oidc/mock/callback", parsed.Query().Get("redirect_uri"))
+}
+
+func TestIdentity_FromIDToken(t *testing.T) {
+	// Arrange
+	server, provider := newTestProvider(t)
+	tokens, nonce := authorizeAndExchange(t, server, provider, oidctest.Claims{
+		Subject: "user-42", Email: "collector@example.com", EmailVerified: true, Name: "Collector",
+	})
+
+	// Act
+	identity, err := provider.Identity(context.Background(), tokens, nonce)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", identity.Subject)
+	assert.Equal(t, "collector@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "Collector", identity.Name)
+}
+
+func TestIdentity_FromUserInfo(t *testing.T) {
+	// Arrange
+	server, provider := newTestProvider(t)
+	server.OmitIDToken = true
+	tokens, nonce := authorizeAndExchange(t, server, provider, oidctest.Claims{
+		Subject: "user-42", Email: "collector@example.com", EmailVerified: false,
+	})
+
+	// Act
+	identity, err := provider.Identity(context.Background(), tokens, nonce)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", identity.Subject)
+	assert.False(t, identity.EmailVerified)
+}
+
+func TestExchange_WrongCodeVerifier(t *testing.T) {
+	// Arrange
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, _ := provider.AuthCodeURL(ctx, "state", "nonce", CodeChallengeS256("expected-verifier"))
+	code, _, err := server.Authorize(authURL, oidctest.Claims{Subject: "user-42"})
+	require.NoError(t, err)
+
+	// Act
+	tokens, err := provider.Exchange(ctx, code, "another-verifier")
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrExchange))
+	assert.Nil(t, tokens)
+}
+
+func TestIdentity_RejectsInvalidIDTokens(t *testing.T) {
+	server, provider := newTestProvider(t)
+	now := time.Now()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   server.Issuer(),
+			"sub":   "user-42",
+			"aud":   "client-id",
+			"exp":   now.Add(time.Hour).Unix(),
+			"iat":   now.Unix(),
+			"nonce": "nonce-1",
+		}
+	}
+
+	cases := map[string]func(jwt.MapClaims){
+		"mauvais nonce":     func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"mauvaise audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"mauvais émetteur":  func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expiré":            func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"azp manquant":      func(c jwt.MapClaims) { c["aud"] = []string{"client-id", "other-client"} },
+		"sans sujet":        func(c jwt.MapClaims) { delete(c, "sub") },
+		"sans expiration":   func(c jwt.MapClaims) { delete(c, "exp") },
+	}
+
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			claims := valid()
+			mutate(claims)
+			tokens := &Tokens{IDToken: server.SignIDToken(claims)}
+
+			// Act
+			identity, err := provider.Identity(context.Background(), tokens, "nonce-1")
+
+			// Assert
+			assert.True(t, errors.Is(err, ErrInvalidIDToken), err)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestIdentity_RejectsUnsignedIDToken(t *testing.T) {
+	// Arrange
+	server, provider := newTestProvider(t)
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"iss":   server.Issuer(),
+		"sub":   "user-42",
+		"aud":   "client-id",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": "nonce-1",
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	// Act
+	_, err := provider.Identity(context.Background(), &Tokens{IDToken: unsigned}, "nonce-1")
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrInvalidIDToken))
+}
+
+func TestDiscovery_IssuerMismatch(t *testing.T) {
+	// Arrange
+	server := oidctest.NewServer("client-id", "client-secret")
+	defer server.Close()
+
+	provider := NewProvider(Config{
+		Name:     "mock",
+		Issuer:   server.Issuer() + "/", // émetteur différent de celui annoncé
+		ClientID: "client-id",
+	}, server.Client())
+
+	// Act
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+
+	// Assert
+	assert.True(t, errors.Is(err, ErrDiscovery))
+}
+
+func TestFlexibleBool(t *testing.T) {
+	var claims struct {
+		A flexibleBool `json:"a"`
+		B flexibleBool `json:"b"`
+		C flexibleBool `json:"c"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a": true, "b": "true", "c": "nope"}`), &claims))
+
+	assert.True(t, bool(claims.A))
+	assert.True(t, bool(claims.B))
+	assert.False(t, bool(claims.C))
+}
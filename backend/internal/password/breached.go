@@ -0,0 +1,108 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength est la longueur des préfixes SHA-1 des fichiers de plages HIBP
+const prefixLength = 5
+
+// NewBreachedList ouvre une liste locale de mots de passe compromis au format
+// Have I Been Pwned. Le chemin désigne soit un répertoire de plages, un fichier
+// par préfixe SHA-1 de 5 caractères (ABCDE.txt) contenant des lignes
+// SUFFIXE:COMPTE, soit un fichier unique de lignes HASH[:COMPTE] chargé en
+// mémoire, à réserver aux listes de taille raisonnable.
+func NewBreachedList(path string) (BreachedList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &rangeDirectory{dir: path}, nil
+	}
+
+	set, err := loadHashSet(path)
+	if err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// sha1Hex retourne l'empreinte SHA-1 d'un mot de passe en hexadécimal majuscule
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// rangeDirectory implémente BreachedList sur un répertoire de plages : seul le
+// fichier du préfixe de l'empreinte est lu à chaque vérification
+type rangeDirectory struct {
+	dir string
+}
+
+// Contains cherche le suffixe de l'empreinte dans le fichier de son préfixe
+func (d *rangeDirectory) Contains(password string) (bool, error) {
+	hash := sha1Hex(password)
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	file, err := os.Open(filepath.Join(d.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		candidate, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// hashSet implémente BreachedList sur un ensemble d'empreintes en mémoire
+type hashSet map[string]struct{}
+
+// loadHashSet charge un fichier de lignes HASH[:COMPTE]
+func loadHashSet(path string) (hashSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	set := make(hashSet)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d: empreinte SHA-1 invalide", path, line)
+		}
+		set[strings.ToUpper(hash)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Contains cherche l'empreinte du mot de passe dans l'ensemble
+func (s hashSet) Contains(password string) (bool, error) {
+	_, ok := s[sha1Hex(password)]
+	return ok, nil
+}
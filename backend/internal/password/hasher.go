@@ -0,0 +1,148 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUnsupportedHash = errors.New("format d'empreinte de mot de passe non pris en charge")
+	ErrInvalidHash     = errors.New("empreinte de mot de passe invalide")
+)
+
+// argon2idPrefix débute les empreintes argon2id au format PHC :
+// $argon2id$v=19$m=<mémoire>,t=<itérations>,p=<parallélisme>$<sel>$<empreinte>
+const argon2idPrefix = "$argon2id$"
+
+// b64 encode le sel et l'empreinte comme les autres implémentations PHC
+var b64 = base64.RawStdEncoding
+
+// Argon2Params regroupe les paramètres de coût d'argon2id
+type Argon2Params struct {
+	Memory      uint32 // mémoire en Kio
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32 // en octets
+	KeyLength   uint32 // en octets
+}
+
+// DefaultArgon2Params suit la deuxième recommandation de la RFC 9106
+// (64 Mio, 3 itérations), avec 4 fils d'exécution
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hasher calcule et vérifie les empreintes des mots de passe. L'algorithme et
+// ses paramètres sont enregistrés dans l'empreinte, ce qui permet de les faire
+// évoluer sans invalider les empreintes existantes.
+type Hasher interface {
+	Hash(password string) (string, error)
+	// Verify indique si le mot de passe correspond à l'empreinte
+	Verify(password, encoded string) (bool, error)
+	// NeedsRehash indique si l'empreinte a été calculée avec un autre
+	// algorithme ou d'autres paramètres que ceux en vigueur
+	NeedsRehash(encoded string) bool
+}
+
+// argon2Hasher implémente Hasher avec argon2id, et vérifie également les
+// empreintes bcrypt héritées
+type argon2Hasher struct {
+	params Argon2Params
+}
+
+// NewHasher crée un Hasher argon2id utilisant les paramètres donnés
+func NewHasher(params Argon2Params) Hasher {
+	return &argon2Hasher{params: params}
+}
+
+// Hash calcule l'empreinte argon2id d'un mot de passe avec un sel aléatoire
+func (h *argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify compare le mot de passe à une empreinte argon2id ou bcrypt
+func (h *argon2Hasher) Verify(password, encoded string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+// NeedsRehash indique si l'empreinte doit être recalculée : empreinte bcrypt,
+// ou empreinte argon2id dont les paramètres diffèrent des paramètres courants
+func (h *argon2Hasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params != h.params
+}
+
+// isBcrypt reconnaît les préfixes des empreintes bcrypt
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// decodeArgon2id extrait les paramètres, le sel et l'empreinte d'une chaîne PHC
+func decodeArgon2id(encoded string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return params, nil, nil, ErrUnsupportedHash
+	}
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", sel, empreinte
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,99 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params sont des paramètres peu coûteux pour les tests
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHash_RecordsParameters(t *testing.T) {
+	// Arrange
+	hasher := NewHasher(testArgon2Params)
+
+	// Act
+	encoded, err := hasher.Hash("password123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	other, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other) // sel aléatoire
+}
+
+func TestVerify_Argon2id(t *testing.T) {
+	// Arrange
+	hasher := NewHasher(testArgon2Params)
+	encoded, err := hasher.Hash("password123")
+	require.NoError(t, err)
+
+	// Act & Assert
+	ok, err := hasher.Verify("password123", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify("wrongpassword", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerify_UsesParametersFromHash(t *testing.T) {
+	// Arrange : empreinte calculée avant un changement de paramètres
+	encoded, err := NewHasher(testArgon2Params).Hash("password123")
+	require.NoError(t, err)
+	upgraded := testArgon2Params
+	upgraded.Iterations = 2
+	hasher := NewHasher(upgraded)
+
+	// Act
+	ok, err := hasher.Verify("password123", encoded)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(encoded))
+}
+
+func TestVerify_LegacyBcrypt(t *testing.T) {
+	// Arrange
+	hasher := NewHasher(testArgon2Params)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	// Act & Assert
+	ok, err := hasher.Verify("password123", string(legacy))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify("wrongpassword", string(legacy))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+}
+
+func TestVerify_InvalidHash(t *testing.T) {
+	hasher := NewHasher(testArgon2Params)
+
+	cases := map[string]error{
+		"":                                     ErrUnsupportedHash,
+		"$pbkdf2-sha256$29000$c2FsdA$aGFzaA":   ErrUnsupportedHash,
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA": ErrInvalidHash,
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$aGFzaA": ErrInvalidHash,
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$aGFzaA":    ErrInvalidHash,
+	}
+
+	for encoded, expected := range cases {
+		ok, err := hasher.Verify("password123", encoded)
+		assert.ErrorIs(t, err, expected, encoded)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,166 @@
+// Package password regroupe les règles appliquées aux mots de passe choisis
+// par les utilisateurs (longueur, classes de caractères, lien avec l'email et
+// présence dans une liste de mots de passe compromis) ainsi que le calcul et
+// la vérification de leurs empreintes.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrPolicyViolation est l'erreur sous-jacente de toute PolicyError
+var ErrPolicyViolation = errors.New("le mot de passe ne respecte pas la politique de sécurité")
+
+// Identifiants des règles, repris dans les réponses de l'API
+const (
+	RuleMinLength     = "min_length"
+	RuleMaxLength     = "max_length"
+	RuleLowercase     = "lowercase"
+	RuleUppercase     = "uppercase"
+	RuleDigit         = "digit"
+	RuleSymbol        = "symbol"
+	RuleContainsEmail = "contains_email"
+	RuleBreached      = "breached"
+)
+
+// minEmailPartLength évite de refuser un mot de passe parce qu'il contient
+// une partie locale d'email trop courte pour être significative
+const minEmailPartLength = 3
+
+// Violation décrit une règle non respectée
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PolicyError liste toutes les règles non respectées par un mot de passe
+type PolicyError struct {
+	Violations []Violation
+}
+
+// Error implémente l'interface error
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return strings.Join(messages, " ; ")
+}
+
+// Unwrap permet d'utiliser errors.Is avec ErrPolicyViolation
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// BreachedList indique si un mot de passe figure dans une liste de mots de
+// passe compromis
+type BreachedList interface {
+	Contains(password string) (bool, error)
+}
+
+// PolicyConfig regroupe les règles de la politique de mots de passe
+type PolicyConfig struct {
+	MinLength        int          // longueur minimale en caractères
+	MaxLength        int          // longueur maximale en caractères, 0 pour aucune
+	RequireLowercase bool         // au moins une minuscule
+	RequireUppercase bool         // au moins une majuscule
+	RequireDigit     bool         // au moins un chiffre
+	RequireSymbol    bool         // au moins un caractère ni lettre ni chiffre
+	ForbidEmail      bool         // refuse les mots de passe contenant l'email ou sa partie locale
+	Breached         BreachedList // liste de mots de passe compromis, nil pour ne pas vérifier
+}
+
+// Policy applique une PolicyConfig aux mots de passe
+type Policy struct {
+	config PolicyConfig
+}
+
+// NewPolicy crée une nouvelle instance de Policy
+func NewPolicy(config PolicyConfig) *Policy {
+	return &Policy{config: config}
+}
+
+// Validate vérifie un mot de passe et retourne une *PolicyError listant les
+// règles non respectées. L'email du compte, s'il est connu, sert à la règle
+// contains_email.
+func (p *Policy) Validate(password, email string) error {
+	var violations []Violation
+	add := func(rule, message string) {
+		violations = append(violations, Violation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		add(RuleMinLength, fmt.Sprintf("le mot de passe doit contenir au moins %d caractères", p.config.MinLength))
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		add(RuleMaxLength, fmt.Sprintf("le mot de passe doit contenir au plus %d caractères", p.config.MaxLength))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.config.RequireLowercase && !hasLower {
+		add(RuleLowercase, "le mot de passe doit contenir au moins une minuscule")
+	}
+	if p.config.RequireUppercase && !hasUpper {
+		add(RuleUppercase, "le mot de passe doit contenir au moins une majuscule")
+	}
+	if p.config.RequireDigit && !hasDigit {
+		add(RuleDigit, "le mot de passe doit contenir au moins un chiffre")
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		add(RuleSymbol, "le mot de passe doit contenir au moins un caractère spécial")
+	}
+
+	if p.config.ForbidEmail && containsEmail(password, email) {
+		add(RuleContainsEmail, "le mot de passe ne doit pas contenir l'adresse email")
+	}
+
+	// La liste n'est consultée que pour un mot de passe par ailleurs valide
+	if len(violations) == 0 && p.config.Breached != nil {
+		breached, err := p.config.Breached.Contains(password)
+		if err != nil {
+			// Une liste illisible ne doit pas empêcher les inscriptions
+			log.Printf("vérification des mots de passe compromis impossible: %v", err)
+		} else if breached {
+			add(RuleBreached, "ce mot de passe figure dans une fuite de données connue, choisissez-en un autre")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsEmail indique si le mot de passe contient l'email ou sa partie locale,
+// sans tenir compte de la casse
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	password = strings.ToLower(password)
+
+	if strings.Contains(password, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(local) >= minEmailPartLength && strings.Contains(password, local)
+}
@@ -0,0 +1,145 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// violatedRules extrait les identifiants des règles non respectées
+func violatedRules(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *PolicyError
+	require.True(t, errors.As(err, &policyErr))
+	rules := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		rules[i] = violation.Rule
+	}
+	return rules
+}
+
+// stubBreachedList est une liste de mots de passe compromis en mémoire
+type stubBreachedList struct {
+	passwords map[string]bool
+	err       error
+}
+
+func (s stubBreachedList) Contains(password string) (bool, error) {
+	return s.passwords[password], s.err
+}
+
+func TestValidate_Length(t *testing.T) {
+	policy := NewPolicy(PolicyConfig{MinLength: 8, MaxLength: 12})
+
+	assert.NoError(t, policy.Validate("password", ""))
+	assert.NoError(t, policy.Validate("éèàçùêâîôû", "")) // longueur en caractères, pas en octets
+	assert.Equal(t, []string{RuleMinLength}, violatedRules(t, policy.Validate("short", "")))
+	assert.Equal(t, []string{RuleMaxLength}, violatedRules(t, policy.Validate("much-too-long-password", "")))
+}
+
+func TestValidate_CharacterClassesReportsEveryViolation(t *testing.T) {
+	// Arrange
+	policy := NewPolicy(PolicyConfig{
+		MinLength:        8,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	})
+
+	// Act
+	err := policy.Validate("abc", "")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Equal(t, []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol}, violatedRules(t, err))
+	assert.NoError(t, policy.Validate("Abcdef1!", ""))
+}
+
+func TestValidate_ForbidEmail(t *testing.T) {
+	policy := NewPolicy(PolicyConfig{MinLength: 8, ForbidEmail: true})
+
+	assert.Equal(t, []string{RuleContainsEmail}, violatedRules(t, policy.Validate("Jean.Dupont2024", "jean.dupont@example.com")))
+	assert.Equal(t, []string{RuleContainsEmail}, violatedRules(t, policy.Validate("xjean.dupont@example.comx", "Jean.Dupont@Example.com")))
+	assert.NoError(t, policy.Validate("correct-horse", "jo@example.com")) // partie locale trop courte
+	assert.NoError(t, policy.Validate("jean.dupont2024", ""))
+}
+
+func TestValidate_Breached(t *testing.T) {
+	// Arrange
+	breached := stubBreachedList{passwords: map[string]bool{"password123": true}}
+	policy := NewPolicy(PolicyConfig{MinLength: 8, Breached: breached})
+
+	// Act & Assert
+	assert.Equal(t, []string{RuleBreached}, violatedRules(t, policy.Validate("password123", "")))
+	assert.NoError(t, policy.Validate("correct-horse-battery", ""))
+}
+
+func TestValidate_BreachedListFailureIsIgnored(t *testing.T) {
+	// Arrange
+	breached := stubBreachedList{err: errors.New("disque indisponible")}
+	policy := NewPolicy(PolicyConfig{MinLength: 8, Breached: breached})
+
+	// Act
+	err := policy.Validate("correct-horse-battery", "")
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestNewBreachedList_RangeDirectory(t *testing.T) {
+	// Arrange : SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	dir := t.TempDir()
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(content), 0o644))
+
+	// Act
+	list, err := NewBreachedList(dir)
+
+	// Assert
+	require.NoError(t, err)
+	found, err := list.Contains("password")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	found, err = list.Contains("correct-horse-battery") // fichier de préfixe absent
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestNewBreachedList_HashFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	content := "5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:9545824\n\n7C4A8D09CA3762AF61E59520943DC26494F8941B\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	// Act
+	list, err := NewBreachedList(path)
+
+	// Assert
+	require.NoError(t, err)
+	for _, password := range []string{"password", "123456"} {
+		found, err := list.Contains(password)
+		require.NoError(t, err)
+		assert.True(t, found, password)
+	}
+	found, _ := list.Contains("correct-horse-battery")
+	assert.False(t, found)
+}
+
+func TestNewBreachedList_InvalidHashFile(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o644))
+
+	// Act
+	list, err := NewBreachedList(path)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, list)
+}
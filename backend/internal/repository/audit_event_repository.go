@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEventRepository définit l'interface pour les opérations sur le journal
+// d'audit. Le journal est en ajout seul : il n'offre ni modification ni suppression.
+type AuditEventRepository interface {
+	Create(event *models.AuditEvent) error
+	List(filter AuditEventFilter) ([]models.AuditEvent, int64, error)
+}
+
+// AuditEventFilter décrit une recherche paginée dans le journal d'audit
+type AuditEventFilter struct {
+	UserID  *uuid.UUID // utilisateur concerné, nil pour tous
+	Email   string     // email présenté, exact et insensible à la casse, vide pour tous
+	Event   string     // type d'événement, vide pour tous
+	Outcome string     // issue, vide pour toutes
+	IP      string     // adresse IP exacte, vide pour toutes
+	From    *time.Time // événements survenus à partir de cette date
+	To      *time.Time // événements survenus avant cette date
+	Offset  int
+	Limit   int
+}
+
+// auditEventRepository implémente AuditEventRepository
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditEventRepository crée une nouvelle instance de AuditEventRepository
+func NewAuditEventRepository(db *gorm.DB) AuditEventRepository {
+	return &auditEventRepository{db: db}
+}
+
+// Create ajoute un événement au journal
+func (r *auditEventRepository) Create(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+// List retourne une page d'événements correspondant au filtre, du plus récent
+// au plus ancien, et le nombre total d'événements correspondants
+func (r *auditEventRepository) List(filter AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	query := r.db.Model(&models.AuditEvent{})
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", filter.Email)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip = ?", filter.IP)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&events).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
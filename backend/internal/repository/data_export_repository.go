@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DataExportRepository définit l'interface pour les exports de données personnelles
+type DataExportRepository interface {
+	Create(export *models.DataExport) error
+	FindByID(id uuid.UUID) (*models.DataExport, error)
+	FindLatestByUserID(userID uuid.UUID) (*models.DataExport, error)
+	FindByUserID(userID uuid.UUID) ([]models.DataExport, error)
+	FindClaimable(staleBefore time.Time, limit int) ([]models.DataExport, error)
+	Claim(id uuid.UUID, startedAt, staleBefore time.Time) (bool, error)
+	MarkReady(id uuid.UUID, fileName string, size int64, completedAt, expiresAt time.Time) error
+	MarkFailed(id uuid.UUID) error
+	FindExpired(now time.Time, limit int) ([]models.DataExport, error)
+	MarkExpired(id uuid.UUID) error
+}
+
+// dataExportRepository implémente DataExportRepository
+type dataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository crée une nouvelle instance de DataExportRepository
+func NewDataExportRepository(db *gorm.DB) DataExportRepository {
+	return &dataExportRepository{db: db}
+}
+
+// Create insère une nouvelle demande d'export
+func (r *dataExportRepository) Create(export *models.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+// FindByID recherche un export par son ID
+func (r *dataExportRepository) FindByID(id uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.Where("id = ?", id).First(&export).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// FindLatestByUserID retourne la dernière demande d'export d'un utilisateur
+func (r *dataExportRepository) FindLatestByUserID(userID uuid.UUID) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&export).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// FindByUserID retourne toutes les demandes d'export d'un utilisateur
+func (r *dataExportRepository) FindByUserID(userID uuid.UUID) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.Where("user_id = ?", userID).Find(&exports).Error
+	return exports, err
+}
+
+// FindClaimable retourne les exports en attente, ainsi que ceux dont la
+// construction a été interrompue (prise en charge avant staleBefore)
+func (r *dataExportRepository) FindClaimable(staleBefore time.Time, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.
+		Where("status = ? OR (status = ? AND started_at < ?)", models.DataExportPending, models.DataExportProcessing, staleBefore).
+		Order("created_at").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// Claim réserve un export pour le worker courant. Retourne false si une autre
+// instance l'a déjà pris en charge.
+func (r *dataExportRepository) Claim(id uuid.UUID, startedAt, staleBefore time.Time) (bool, error) {
+	result := r.db.Model(&models.DataExport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", id, models.DataExportPending, models.DataExportProcessing, staleBefore).
+		Updates(map[string]interface{}{"status": models.DataExportProcessing, "started_at": startedAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkReady enregistre l'archive construite et la date d'expiration du lien
+func (r *dataExportRepository) MarkReady(id uuid.UUID, fileName string, size int64, completedAt, expiresAt time.Time) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.DataExportReady,
+		"file_name":    fileName,
+		"size":         size,
+		"completed_at": completedAt,
+		"expires_at":   expiresAt,
+	}).Error
+}
+
+// MarkFailed enregistre l'échec de la construction de l'archive
+func (r *dataExportRepository) MarkFailed(id uuid.UUID) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).
+		Update("status", models.DataExportFailed).Error
+}
+
+// FindExpired retourne les exports prêts dont le lien a expiré
+func (r *dataExportRepository) FindExpired(now time.Time, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.
+		Where("status = ? AND expires_at < ?", models.DataExportReady, now).
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// MarkExpired enregistre la suppression de l'archive
+func (r *dataExportRepository) MarkExpired(id uuid.UUID) error {
+	return r.db.Model(&models.DataExport{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    models.DataExportExpired,
+		"file_name": "",
+	}).Error
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"gorm.io/gorm"
+)
+
+// LoginThrottleRepository définit l'interface des compteurs d'échecs de connexion
+type LoginThrottleRepository interface {
+	Find(key string) (*models.LoginThrottle, error)
+	RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginThrottle, error)
+	Lock(key string, until time.Time) error
+	Delete(key string) (bool, error)
+	DeleteStale(lastFailureBefore time.Time) error
+}
+
+// loginThrottleRepository implémente LoginThrottleRepository
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginThrottleRepository crée une nouvelle instance de LoginThrottleRepository
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+// Find trouve le compteur d'une clé
+func (r *loginThrottleRepository) Find(key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Where("key = ?", key).First(&throttle).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure incrémente atomiquement le compteur d'une clé et retourne son
+// nouvel état. Le compteur repart de 1 si le dernier échec est plus ancien
+// que la fenêtre d'observation.
+func (r *loginThrottleRepository) RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.Raw(`
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until`,
+		key, at, at.Add(-window),
+	).Scan(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// Lock verrouille une clé jusqu'à la date donnée
+func (r *loginThrottleRepository) Lock(key string, until time.Time) error {
+	return r.db.Model(&models.LoginThrottle{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+// Delete supprime le compteur d'une clé (déverrouillage ou connexion réussie)
+func (r *loginThrottleRepository) Delete(key string) (bool, error) {
+	result := r.db.Where("key = ?", key).Delete(&models.LoginThrottle{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteStale supprime les compteurs oubliés : dernier échec antérieur à la
+// date donnée et aucun verrouillage en cours
+func (r *loginThrottleRepository) DeleteStale(lastFailureBefore time.Time) error {
+	return r.db.Where("last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", lastFailureBefore, time.Now()).
+		Delete(&models.LoginThrottle{}).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkRepository définit l'interface pour les liens de connexion sans mot de passe
+type MagicLinkRepository interface {
+	Create(token *models.MagicLinkToken) error
+	FindByHash(tokenHash string) (*models.MagicLinkToken, error)
+	MarkUsed(id uuid.UUID) (bool, error)
+	InvalidateForUser(userID uuid.UUID) error
+	DeleteExpired() error
+}
+
+// magicLinkRepository implémente MagicLinkRepository
+type magicLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewMagicLinkRepository crée une nouvelle instance de MagicLinkRepository
+func NewMagicLinkRepository(db *gorm.DB) MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+// Create insère un nouveau lien de connexion
+func (r *magicLinkRepository) Create(token *models.MagicLinkToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash recherche un lien par le hash de son token
+func (r *magicLinkRepository) FindByHash(tokenHash string) (*models.MagicLinkToken, error) {
+	var token models.MagicLinkToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consomme un lien. Retourne false s'il avait déjà été utilisé.
+func (r *magicLinkRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateForUser consomme tous les liens encore valides d'un utilisateur
+func (r *magicLinkRepository) InvalidateForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// DeleteExpired supprime les liens de connexion expirés, utilisés ou non
+func (r *magicLinkRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.MagicLinkToken{}).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OIDCAuthRequestRepository définit l'interface pour les demandes d'autorisation OIDC en cours
+type OIDCAuthRequestRepository interface {
+	Create(request *models.OIDCAuthRequest) error
+	FindByStateHash(stateHash string) (*models.OIDCAuthRequest, error)
+	Delete(id uuid.UUID) (bool, error)
+	DeleteExpired() error
+}
+
+// oidcAuthRequestRepository implémente OIDCAuthRequestRepository
+type oidcAuthRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewOIDCAuthRequestRepository crée une nouvelle instance de OIDCAuthRequestRepository
+func NewOIDCAuthRequestRepository(db *gorm.DB) OIDCAuthRequestRepository {
+	return &oidcAuthRequestRepository{db: db}
+}
+
+// Create insère une nouvelle demande d'autorisation
+func (r *oidcAuthRequestRepository) Create(request *models.OIDCAuthRequest) error {
+	return r.db.Create(request).Error
+}
+
+// FindByStateHash recherche une demande par le hash de son paramètre state
+func (r *oidcAuthRequestRepository) FindByStateHash(stateHash string) (*models.OIDCAuthRequest, error) {
+	var request models.OIDCAuthRequest
+	err := r.db.Where("state_hash = ?", stateHash).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Delete consomme une demande. Retourne false si elle avait déjà été consommée.
+func (r *oidcAuthRequestRepository) Delete(id uuid.UUID) (bool, error) {
+	result := r.db.Where("id = ?", id).Delete(&models.OIDCAuthRequest{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteExpired supprime les demandes abandonnées
+func (r *oidcAuthRequestRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCAuthRequest{}).Error
+}
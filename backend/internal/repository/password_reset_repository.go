@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository définit l'interface pour les tokens de réinitialisation de mot de passe
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	FindByHash(tokenHash string) (*models.PasswordResetToken, error)
+	MarkUsed(id uuid.UUID) (bool, error)
+	InvalidateForUser(userID uuid.UUID) error
+	DeleteExpired() error
+}
+
+// passwordResetRepository implémente PasswordResetRepository
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository crée une nouvelle instance de PasswordResetRepository
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create insère un nouveau token de réinitialisation
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash recherche un token par son hash
+func (r *passwordResetRepository) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consomme un token. Retourne false s'il avait déjà été utilisé.
+func (r *passwordResetRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidateForUser consomme tous les tokens encore valides d'un utilisateur
+func (r *passwordResetRepository) InvalidateForUser(userID uuid.UUID) error {
+	return r.db.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// DeleteExpired supprime les tokens de réinitialisation expirés, utilisés ou non
+func (r *passwordResetRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.PasswordResetToken{}).Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PersonalAccessTokenRepository définit l'interface pour les opérations sur
+// les personal access tokens
+type PersonalAccessTokenRepository interface {
+	Create(token *models.PersonalAccessToken) error
+	FindByHash(tokenHash string) (*models.PersonalAccessToken, error)
+	FindActiveByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	FindByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error
+	Revoke(userID, id uuid.UUID) (bool, error)
+}
+
+// personalAccessTokenRepository implémente PersonalAccessTokenRepository
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPersonalAccessTokenRepository crée une nouvelle instance de PersonalAccessTokenRepository
+func NewPersonalAccessTokenRepository(db *gorm.DB) PersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db: db}
+}
+
+// Create insère un nouveau token en base de données
+func (r *personalAccessTokenRepository) Create(token *models.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindByHash recherche un token par le hash de sa valeur
+func (r *personalAccessTokenRepository) FindByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindActiveByUserID liste les tokens non révoqués et non expirés d'un
+// utilisateur, du plus récent au plus ancien. Les tokens émis avant la
+// génération courante de l'utilisateur (changement de mot de passe,
+// déconnexion globale...) sont refusés à l'authentification et donc exclus.
+func (r *personalAccessTokenRepository) FindActiveByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Where("generation >= (SELECT token_generation FROM users WHERE users.id = personal_access_tokens.user_id)").
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// FindByUserID liste tous les tokens d'un utilisateur, révoqués et expirés
+// compris, du plus récent au plus ancien
+func (r *personalAccessTokenRepository) FindByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// UpdateLastUsed met à jour la date de dernière utilisation d'un token
+func (r *personalAccessTokenRepository) UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error {
+	return r.db.Model(&models.PersonalAccessToken{}).Where("id = ?", id).Update("last_used_at", lastUsedAt).Error
+}
+
+// Revoke révoque un token de l'utilisateur. Retourne false si le token
+// n'existe pas, appartient à un autre utilisateur ou est déjà révoqué.
+func (r *personalAccessTokenRepository) Revoke(userID, id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"gorm.io/gorm"
+)
+
+// RateLimitRepository définit l'interface des compteurs de limitation de débit.
+// Il satisfait middleware.RateLimitStore pour partager les compteurs entre instances.
+type RateLimitRepository interface {
+	Hit(key string, window time.Duration, now time.Time) (int, time.Time, error)
+	DeleteExpired() error
+}
+
+// rateLimitRepository implémente RateLimitRepository
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitRepository crée une nouvelle instance de RateLimitRepository
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepository {
+	return &rateLimitRepository{db: db}
+}
+
+// Hit incrémente atomiquement le compteur de la clé, ou ouvre une nouvelle
+// fenêtre si la précédente est terminée
+func (r *rateLimitRepository) Hit(key string, window time.Duration, now time.Time) (int, time.Time, error) {
+	var counter models.RateLimitCounter
+	err := r.db.Raw(`
+		INSERT INTO rate_limit_counters (key, count, reset_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_counters.reset_at <= ? THEN 1 ELSE rate_limit_counters.count + 1 END,
+			reset_at = CASE WHEN rate_limit_counters.reset_at <= ? THEN EXCLUDED.reset_at ELSE rate_limit_counters.reset_at END
+		RETURNING key, count, reset_at`,
+		key, now.Add(window), now, now,
+	).Scan(&counter).Error
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return counter.Count, counter.ResetAt, nil
+}
+
+// DeleteExpired supprime les compteurs dont la fenêtre est terminée
+func (r *rateLimitRepository) DeleteExpired() error {
+	return r.db.Where("reset_at < ?", time.Now()).Delete(&models.RateLimitCounter{}).Error
+}
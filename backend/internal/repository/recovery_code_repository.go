@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository définit l'interface pour les codes de secours de la double authentification
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uuid.UUID, codeHashes []string) error
+	Consume(userID uuid.UUID, codeHash string) (bool, error)
+	CountRemaining(userID uuid.UUID) (int64, error)
+	DeleteForUser(userID uuid.UUID) error
+}
+
+// recoveryCodeRepository implémente RecoveryCodeRepository
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository crée une nouvelle instance de RecoveryCodeRepository
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser remplace tous les codes de secours d'un utilisateur
+func (r *recoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, codeHash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: codeHash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume utilise un code de secours. Retourne false s'il n'existe pas ou a déjà été utilisé.
+func (r *recoveryCodeRepository) Consume(userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountRemaining compte les codes de secours encore utilisables
+func (r *recoveryCodeRepository) CountRemaining(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteForUser supprime tous les codes de secours d'un utilisateur
+func (r *recoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	return r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository définit l'interface pour les opérations sur les refresh tokens
+type RefreshTokenRepository interface {
+	Create(token *models.RefreshToken) error
+	FindByID(id uuid.UUID) (*models.RefreshToken, error)
+	MarkRotated(id uuid.UUID) (bool, error)
+	RevokeFamily(familyID uuid.UUID) error
+	DeleteExpired() error
+}
+
+// refreshTokenRepository implémente RefreshTokenRepository
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository crée une nouvelle instance de RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create insère un nouveau refresh token en base de données et reporte son
+// expiration sur la session de sa famille, qui expire avec lui
+func (r *refreshTokenRepository) Create(token *models.RefreshToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(token).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("family_id = ?", token.FamilyID).
+			Update("expires_at", token.ExpiresAt).Error
+	})
+}
+
+// FindByID recherche un refresh token par son ID
+func (r *refreshTokenRepository) FindByID(id uuid.UUID) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.Where("id = ?", id).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRotated marque un refresh token comme utilisé.
+// Retourne false si le token avait déjà été utilisé ou révoqué entre-temps,
+// ce qui permet de détecter deux rotations concurrentes du même token.
+func (r *refreshTokenRepository) MarkRotated(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily révoque tous les refresh tokens encore actifs d'une famille
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// DeleteExpired supprime les refresh tokens expirés, devenus inutilisables
+func (r *refreshTokenRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.RefreshToken{}).Error
+}
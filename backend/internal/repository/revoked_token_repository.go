@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository définit l'interface du registre des tokens révoqués
+type RevokedTokenRepository interface {
+	Create(token *models.RevokedToken) error
+	IsRevoked(id uuid.UUID) (bool, error)
+	DeleteExpired() error
+}
+
+// revokedTokenRepository implémente RevokedTokenRepository
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository crée une nouvelle instance de RevokedTokenRepository
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+// Create enregistre un token révoqué (sans erreur s'il l'est déjà)
+func (r *revokedTokenRepository) Create(token *models.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsRevoked vérifie si un token a été révoqué
+func (r *revokedTokenRepository) IsRevoked(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RevokedToken{}).Where("id = ?", id).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired supprime les entrées dont le token a de toute façon expiré
+func (r *revokedTokenRepository) DeleteExpired() error {
+	return r.db.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error
+}
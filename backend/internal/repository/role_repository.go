@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepository définit l'interface pour les rôles, les permissions et leur attribution
+type RoleRepository interface {
+	UpsertRole(name, description string) (*models.Role, error)
+	UpsertPermission(name, description string) (*models.Permission, error)
+	AttachPermission(roleID, permissionID uuid.UUID) error
+	FindByName(name string) (*models.Role, error)
+	FindRoleNamesByUserID(userID uuid.UUID) ([]string, error)
+	FindPermissionNamesByUserID(userID uuid.UUID) ([]string, error)
+	Grant(userID, roleID uuid.UUID, grantedAt time.Time) error
+	Revoke(userID, roleID uuid.UUID) (bool, error)
+	CountUsers(roleID uuid.UUID) (int64, error)
+}
+
+// roleRepository implémente RoleRepository
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository crée une nouvelle instance de RoleRepository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// UpsertRole crée le rôle s'il n'existe pas encore et le retourne
+func (r *roleRepository) UpsertRole(name, description string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Where(models.Role{Name: name}).
+		Attrs(models.Role{Description: description}).
+		FirstOrCreate(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpsertPermission crée la permission si elle n'existe pas encore et la retourne
+func (r *roleRepository) UpsertPermission(name, description string) (*models.Permission, error) {
+	var permission models.Permission
+	err := r.db.Where(models.Permission{Name: name}).
+		Attrs(models.Permission{Description: description}).
+		FirstOrCreate(&permission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// AttachPermission accorde une permission à un rôle (sans effet si déjà accordée)
+func (r *roleRepository) AttachPermission(roleID, permissionID uuid.UUID) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RolePermission{RoleID: roleID, PermissionID: permissionID}).Error
+}
+
+// FindByName recherche un rôle par son nom
+func (r *roleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Where("name = ?", name).First(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// FindRoleNamesByUserID liste les noms des rôles d'un utilisateur
+func (r *roleRepository) FindRoleNamesByUserID(userID uuid.UUID) ([]string, error) {
+	var names []string
+	err := r.db.Model(&models.Role{}).
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Pluck("roles.name", &names).Error
+	return names, err
+}
+
+// FindPermissionNamesByUserID liste les permissions accordées à un
+// utilisateur par l'ensemble de ses rôles
+func (r *roleRepository) FindPermissionNamesByUserID(userID uuid.UUID) ([]string, error) {
+	var names []string
+	err := r.db.Model(&models.Permission{}).
+		Distinct("permissions.name").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("permissions.name").
+		Pluck("permissions.name", &names).Error
+	return names, err
+}
+
+// Grant attribue un rôle à un utilisateur (sans effet s'il le possède déjà)
+func (r *roleRepository) Grant(userID, roleID uuid.UUID, grantedAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.UserRole{UserID: userID, RoleID: roleID, GrantedAt: grantedAt}).Error
+}
+
+// Revoke retire un rôle à un utilisateur. Retourne false s'il ne le possédait pas.
+func (r *roleRepository) Revoke(userID, roleID uuid.UUID) (bool, error) {
+	result := r.db.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUsers compte les utilisateurs possédant un rôle
+func (r *roleRepository) CountUsers(roleID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserRole{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
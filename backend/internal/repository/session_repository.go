@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionRepository définit l'interface pour les opérations sur les sessions
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByID(id uuid.UUID) (*models.Session, error)
+	FindByFamilyID(familyID uuid.UUID) (*models.Session, error)
+	FindActiveByUserID(userID uuid.UUID) ([]models.Session, error)
+	FindByUserID(userID uuid.UUID) ([]models.Session, error)
+	UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error
+	Revoke(id uuid.UUID) error
+}
+
+// sessionRepository implémente SessionRepository
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository crée une nouvelle instance de SessionRepository
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// Create insère une nouvelle session en base de données
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID recherche une session par son ID
+func (r *sessionRepository) FindByID(id uuid.UUID) (*models.Session, error) {
+	return r.findOne("id = ?", id)
+}
+
+// FindByFamilyID recherche la session associée à une famille de refresh tokens
+func (r *sessionRepository) FindByFamilyID(familyID uuid.UUID) (*models.Session, error) {
+	return r.findOne("family_id = ?", familyID)
+}
+
+// FindActiveByUserID liste les sessions ni révoquées ni expirées d'un
+// utilisateur, de la plus récemment utilisée à la plus ancienne. L'inactivité
+// est appréciée par SessionService.
+func (r *sessionRepository) FindActiveByUserID(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindByUserID liste toutes les sessions d'un utilisateur, révoquées
+// comprises, de la plus récente à la plus ancienne
+func (r *sessionRepository) FindByUserID(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// UpdateLastUsed met à jour la date de dernière utilisation d'une session
+func (r *sessionRepository) UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error {
+	return r.db.Model(&models.Session{}).Where("id = ?", id).Update("last_used_at", lastUsedAt).Error
+}
+
+// Revoke marque une session comme révoquée
+func (r *sessionRepository) Revoke(id uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+// findOne retourne la première session correspondant à la condition, ou nil
+func (r *sessionRepository) findOne(query string, args ...interface{}) (*models.Session, error) {
+	var session models.Session
+	err := r.db.Where(query, args...).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository définit l'interface pour les clés de signature des JWT
+type SigningKeyRepository interface {
+	Create(key *models.SigningKey) error
+	FindUsable(now time.Time) ([]models.SigningKey, error)
+	ScheduleExpiry(exceptID string, expiresAt time.Time) error
+}
+
+// signingKeyRepository implémente SigningKeyRepository
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository crée une nouvelle instance de SigningKeyRepository
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{db: db}
+}
+
+// Create insère une nouvelle clé de signature
+func (r *signingKeyRepository) Create(key *models.SigningKey) error {
+	return r.db.Create(key).Error
+}
+
+// FindUsable retourne les clés non expirées, de la plus récemment activée à la plus ancienne
+func (r *signingKeyRepository) FindUsable(now time.Time) ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := r.db.Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("activates_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// ScheduleExpiry fixe la date d'expiration de toutes les clés qui n'en ont pas
+// encore, à l'exception de la clé donnée
+func (r *signingKeyRepository) ScheduleExpiry(exceptID string, expiresAt time.Time) error {
+	return r.db.Model(&models.SigningKey{}).
+		Where("expires_at IS NULL AND id <> ?", exceptID).
+		Update("expires_at", expiresAt).Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository définit l'interface pour les identités externes des utilisateurs
+type UserIdentityRepository interface {
+	Create(identity *models.UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*models.UserIdentity, error)
+	FindByUserID(userID uuid.UUID) ([]models.UserIdentity, error)
+	UpdateLastLogin(id uuid.UUID, lastLoginAt time.Time) error
+}
+
+// userIdentityRepository implémente UserIdentityRepository
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository crée une nouvelle instance de UserIdentityRepository
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+// Create insère une nouvelle identité externe
+func (r *userIdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// FindByProviderSubject recherche l'identité correspondant au compte du fournisseur
+func (r *userIdentityRepository) FindByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Pas d'erreur si non trouvé, juste nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByUserID liste les comptes externes liés à un utilisateur
+func (r *userIdentityRepository) FindByUserID(userID uuid.UUID) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// UpdateLastLogin met à jour la date de dernière connexion via le fournisseur
+func (r *userIdentityRepository) UpdateLastLogin(id uuid.UUID, lastLoginAt time.Time) error {
+	return r.db.Model(&models.UserIdentity{}).Where("id = ?", id).Update("last_login_at", lastLoginAt).Error
+}
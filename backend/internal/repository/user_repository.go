@@ -2,10 +2,24 @@ package repository
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/arnaud-dars/collec-app/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUsernameConflict signale qu'un autre compte possède déjà l'identifiant,
+// détecté par l'index unique lors de l'écriture
+var ErrUsernameConflict = errors.New("identifiant déjà utilisé par un autre compte")
+
+// Violation de contrainte d'unicité Postgres et index unique des identifiants
+const (
+	uniqueViolationCode = "23505"
+	usernameIndexName   = "idx_users_username_lower"
 )
 
 // UserRepository définit l'interface pour les opérations sur les utilisateurs
@@ -14,6 +28,60 @@ type UserRepository interface {
 	FindByEmail(email string) (*models.User, error)
 	FindByID(id uuid.UUID) (*models.User, error)
 	ExistsByEmail(email string) (bool, error)
+	ExistsByUsername(username string, excludeID uuid.UUID) (bool, error)
+	Update(user *models.User) error
+	UpdateProfile(user *models.User) error
+	UpdateAvatar(id uuid.UUID, key, url string) error
+	UpdatePassword(id uuid.UUID, hashedPassword string) error
+	RehashPassword(id uuid.UUID, currentHash, newHash string) (bool, error)
+	IncrementTokenGeneration(id uuid.UUID) error
+	MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error
+	ClaimUnverified(id uuid.UUID, verifiedAt time.Time) (bool, error)
+	MarkVerificationSent(id uuid.UUID, notSentSince time.Time) (bool, error)
+	ConfirmEmailChange(id uuid.UUID, email string, verifiedAt time.Time) (bool, error)
+	SetPendingTOTPSecret(id uuid.UUID, secret string) (bool, error)
+	EnableTOTP(id uuid.UUID, enabledAt time.Time, step int64) (bool, error)
+	DisableTOTP(id uuid.UUID) error
+	MarkTOTPStepUsed(id uuid.UUID, step int64) (bool, error)
+	MarkDeletionRequested(id uuid.UUID, requestedAt time.Time) error
+	CancelDeletion(id uuid.UUID) error
+	FindDeletionDue(requestedBefore time.Time, limit int) ([]models.User, error)
+	Purge(id uuid.UUID, requestedBefore time.Time) (bool, error)
+	List(filter UserFilter) ([]models.User, int64, error)
+	SetDisabled(id uuid.UUID, disabledAt *time.Time) error
+	RequirePasswordReset(id uuid.UUID) error
+}
+
+// Statuts de compte utilisables pour filtrer la liste des utilisateurs
+const (
+	UserStatusActive          = "active"           // ni désactivé ni en attente de suppression
+	UserStatusDisabled        = "disabled"         // désactivé par un administrateur
+	UserStatusUnverified      = "unverified"       // email non vérifié
+	UserStatusPendingDeletion = "pending_deletion" // suppression demandée, délai de grâce en cours
+)
+
+// UserFilter décrit une recherche paginée dans la liste des utilisateurs
+type UserFilter struct {
+	Search string // fragment de l'adresse email, insensible à la casse
+	Status string // un des UserStatus*, vide pour tous
+	Role   string // nom d'un rôle possédé, vide pour tous
+	Offset int
+	Limit  int
+}
+
+// userOwnedModels liste les tables dont les lignes appartiennent à un
+// utilisateur (colonne user_id) et sont supprimées avec lui
+var userOwnedModels = []interface{}{
+	&models.RefreshToken{},
+	&models.RevokedToken{},
+	&models.Session{},
+	&models.PasswordResetToken{},
+	&models.RecoveryCode{},
+	&models.UserIdentity{},
+	&models.DataExport{},
+	&models.UserRole{},
+	&models.PersonalAccessToken{},
+	&models.MagicLinkToken{},
 }
 
 // userRepository implémente UserRepository
@@ -66,3 +134,309 @@ func (r *userRepository) ExistsByEmail(email string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// ExistsByUsername vérifie si un autre utilisateur que excludeID possède déjà
+// l'identifiant, sans tenir compte de la casse
+func (r *userRepository) ExistsByUsername(username string, excludeID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Where("LOWER(username) = LOWER(?) AND id <> ?", username, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// updatableUserColumns liste les colonnes enregistrées par Update. Les
+// colonnes de sécurité (mot de passe, génération de tokens, TOTP...) ont
+// leurs propres méthodes, atomiques.
+var updatableUserColumns = []string{"pending_email"}
+
+// Update enregistre les champs modifiables d'un utilisateur
+func (r *userRepository) Update(user *models.User) error {
+	return r.db.Model(user).Select(updatableUserColumns).Updates(user).Error
+}
+
+// profileColumns liste les colonnes du profil modifiables par l'utilisateur
+var profileColumns = []string{"display_name", "username", "bio", "locale", "timezone", "currency"}
+
+// UpdateProfile enregistre le profil d'un utilisateur. L'index unique sur
+// LOWER(username) refuse un identifiant pris entre-temps par un autre compte :
+// ErrUsernameConflict est alors retourné.
+func (r *userRepository) UpdateProfile(user *models.User) error {
+	err := r.db.Model(user).Select(profileColumns).Updates(user).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == usernameIndexName {
+		return ErrUsernameConflict
+	}
+	return err
+}
+
+// UpdateAvatar enregistre l'avatar courant d'un utilisateur (vides pour le retirer)
+func (r *userRepository) UpdateAvatar(id uuid.UUID, key, url string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"avatar_key": key,
+		"avatar_url": url,
+	}).Error
+}
+
+// UpdatePassword remplace le hash du mot de passe d'un utilisateur et lève
+// l'éventuelle réinitialisation imposée
+func (r *userRepository) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":                hashedPassword,
+		"password_reset_required": false,
+	}).Error
+}
+
+// ConfirmEmailChange remplace l'adresse email par l'adresse en attente, à
+// condition qu'elle corresponde toujours à celle confirmée. Retourne false si
+// le changement a été annulé, remplacé par une autre demande ou déjà appliqué.
+func (r *userRepository) ConfirmEmailChange(id uuid.UUID, email string, verifiedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND pending_email = ?", id, email).
+		Updates(map[string]interface{}{
+			"email":             email,
+			"pending_email":     nil,
+			"email_verified_at": verifiedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RehashPassword remplace l'empreinte du mot de passe par une empreinte du même
+// mot de passe calculée avec d'autres paramètres. Retourne false si le mot de
+// passe a changé entre-temps, auquel cas la nouvelle empreinte est ignorée.
+func (r *userRepository) RehashPassword(id uuid.UUID, currentHash, newHash string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND password = ?", id, currentHash).
+		Update("password", newHash)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// IncrementTokenGeneration invalide tous les tokens déjà émis pour un utilisateur
+func (r *userRepository) IncrementTokenGeneration(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Update("token_generation", gorm.Expr("token_generation + 1")).Error
+}
+
+// MarkEmailVerified enregistre la vérification de l'email d'un utilisateur
+func (r *userRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("email_verified_at", verifiedAt).Error
+}
+
+// ClaimUnverified vérifie l'email d'un compte qui n'avait jamais prouvé la
+// possession de son adresse, et supprime dans une transaction tout ce qu'a pu
+// y laisser celui qui l'a créé : mot de passe, tokens émis (génération),
+// double authentification et codes de secours, changement d'email en attente,
+// sessions, personal access tokens, comptes externes liés et liens de
+// réinitialisation ou de connexion. Retourne false si l'email a été vérifié
+// entre-temps, sans rien modifier.
+func (r *userRepository) ClaimUnverified(id uuid.UUID, verifiedAt time.Time) (bool, error) {
+	claimed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", id).
+			Updates(map[string]interface{}{
+				"email_verified_at": verifiedAt,
+				"password":          "",
+				"token_generation":  gorm.Expr("token_generation + 1"),
+				"totp_secret":       "",
+				"totp_enabled_at":   nil,
+				"totp_last_step":    0,
+				"pending_email":     nil,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		for _, owned := range []interface{}{&models.RecoveryCode{}, &models.UserIdentity{}} {
+			if err := tx.Where("user_id = ?", id).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+		for _, revocable := range []interface{}{&models.Session{}, &models.RefreshToken{}, &models.PersonalAccessToken{}} {
+			if err := tx.Model(revocable).Where("user_id = ? AND revoked_at IS NULL", id).Update("revoked_at", verifiedAt).Error; err != nil {
+				return err
+			}
+		}
+		for _, link := range []interface{}{&models.PasswordResetToken{}, &models.MagicLinkToken{}} {
+			if err := tx.Model(link).Where("user_id = ? AND used_at IS NULL", id).Update("used_at", verifiedAt).Error; err != nil {
+				return err
+			}
+		}
+		claimed = true
+		return nil
+	})
+	return claimed && err == nil, err
+}
+
+// MarkVerificationSent enregistre l'envoi d'un lien de vérification, à condition
+// qu'aucun lien n'ait été envoyé depuis notSentSince. Retourne false sinon.
+func (r *userRepository) MarkVerificationSent(id uuid.UUID, notSentSince time.Time) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", id, notSentSince).
+		Update("verification_sent_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetPendingTOTPSecret enregistre un secret TOTP en attente de confirmation.
+// Retourne false si la double authentification est déjà activée.
+func (r *userRepository) SetPendingTOTPSecret(id uuid.UUID, secret string) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", id).
+		Updates(map[string]interface{}{"totp_secret": secret, "totp_last_step": 0})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// EnableTOTP active la double authentification avec le secret en attente et
+// mémorise la période du code de confirmation. Retourne false si elle était déjà active.
+func (r *userRepository) EnableTOTP(id uuid.UUID, enabledAt time.Time, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL AND totp_secret <> ''", id).
+		Updates(map[string]interface{}{"totp_enabled_at": enabledAt, "totp_last_step": step})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DisableTOTP désactive la double authentification et efface le secret
+func (r *userRepository) DisableTOTP(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil, "totp_last_step": 0}).Error
+}
+
+// MarkTOTPStepUsed consomme une période TOTP. Retourne false si un code de
+// cette période (ou d'une période ultérieure) a déjà été accepté.
+func (r *userRepository) MarkTOTPStepUsed(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// MarkDeletionRequested place le compte en attente de suppression
+func (r *userRepository) MarkDeletionRequested(id uuid.UUID, requestedAt time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("deletion_requested_at", requestedAt).Error
+}
+
+// CancelDeletion annule la suppression en attente d'un compte
+func (r *userRepository) CancelDeletion(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("deletion_requested_at", nil).Error
+}
+
+// FindDeletionDue retourne les comptes dont la suppression a été demandée
+// avant la date donnée, les plus anciens en premier
+func (r *userRepository) FindDeletionDue(requestedBefore time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.Where("deletion_requested_at IS NOT NULL AND deletion_requested_at < ?", requestedBefore).
+		Order("deletion_requested_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Purge supprime définitivement un utilisateur dont la suppression a été
+// demandée avant la date donnée, ainsi que toutes les données qui lui
+// appartiennent, dans une transaction. Retourne false si la suppression a été
+// annulée entre-temps. Les données sont supprimées explicitement car les
+// tables créées par AutoMigrate n'ont pas de clés étrangères ON DELETE CASCADE.
+// Les événements du journal d'audit sont conservés mais pseudonymisés.
+func (r *userRepository) Purge(id uuid.UUID, requestedBefore time.Time) (bool, error) {
+	purged := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		result := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "email"}}}).
+			Where("id = ? AND deletion_requested_at IS NOT NULL AND deletion_requested_at < ?", id, requestedBefore).
+			Delete(&user)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		for _, owned := range userOwnedModels {
+			if err := tx.Where("user_id = ?", id).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+
+		// Le journal d'audit est conservé, mais sans données personnelles : les
+		// événements du compte et ceux présentant son email sont pseudonymisés
+		err := tx.Model(&models.AuditEvent{}).
+			Where("user_id = ? OR LOWER(email) = LOWER(?)", id, user.Email).
+			Updates(map[string]interface{}{"user_id": nil, "email": "", "ip": "", "user_agent": ""}).Error
+		if err != nil {
+			return err
+		}
+		purged = true
+		return nil
+	})
+	return purged && err == nil, err
+}
+
+// List retourne une page d'utilisateurs correspondant au filtre, du plus
+// récent au plus ancien, ainsi que le nombre total de résultats
+func (r *userRepository) List(filter UserFilter) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+
+	if filter.Search != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(filter.Search))
+		query = query.Where("LOWER(email) LIKE ?", "%"+escaped+"%")
+	}
+
+	switch filter.Status {
+	case UserStatusActive:
+		query = query.Where("disabled_at IS NULL AND deletion_requested_at IS NULL")
+	case UserStatusDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	case UserStatusUnverified:
+		query = query.Where("email_verified_at IS NULL")
+	case UserStatusPendingDeletion:
+		query = query.Where("deletion_requested_at IS NOT NULL")
+	}
+
+	if filter.Role != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id "+
+			"WHERE user_roles.user_id = users.id AND roles.name = ?)", filter.Role)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SetDisabled désactive (date renseignée) ou réactive (nil) un compte
+func (r *userRepository) SetDisabled(id uuid.UUID, disabledAt *time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("disabled_at", disabledAt).Error
+}
+
+// RequirePasswordReset refuse la connexion par mot de passe jusqu'à la
+// prochaine réinitialisation
+func (r *userRepository) RequirePasswordReset(id uuid.UUID) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("password_reset_required", true).Error
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/password"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmailUnchanged          = errors.New("la nouvelle adresse email est identique à l'adresse actuelle")
+	ErrInvalidEmailChangeToken = errors.New("lien de confirmation invalide ou expiré")
+)
+
+// purposeEmailChange identifie les liens signés de confirmation d'une nouvelle adresse
+const purposeEmailChange = "email_change"
+
+// purgeBatchSize limite le nombre de comptes supprimés par passage de la purge
+const purgeBatchSize = 100
+
+// AccountConfig regroupe les paramètres de gestion des comptes
+type AccountConfig struct {
+	LinkSecret          string        // secret HMAC des liens envoyés par email
+	FrontendURL         string        // base des liens envoyés par email
+	EmailChangeTTL      time.Duration // validité du lien de confirmation d'une nouvelle adresse
+	DeletionGracePeriod time.Duration // délai pendant lequel une connexion annule la suppression
+}
+
+// AccountService définit l'interface de gestion d'un compte par son
+// propriétaire : identifiants et suppression
+type AccountService interface {
+	ChangePassword(userID, currentSessionID uuid.UUID, currentPassword, newPassword string) error
+	RequestEmailChange(userID uuid.UUID, currentPassword, newEmail string) error
+	ConfirmEmailChange(token string) (*models.User, error)
+	DeleteAccount(userID uuid.UUID, currentPassword string) (time.Time, error)
+	PurgeDeletedAccounts() (int, error)
+}
+
+// accountService implémente AccountService
+type accountService struct {
+	userRepo            repository.UserRepository
+	sessionService      SessionService
+	lockoutService      LockoutService
+	avatarService       AvatarService
+	exportService       ExportService
+	mailer              mailer.Mailer
+	passwordPolicy      *password.Policy
+	passwordHasher      password.Hasher
+	signer              linkSigner
+	frontendURL         string
+	linkDuration        time.Duration
+	deletionGracePeriod time.Duration
+}
+
+// NewAccountService crée une nouvelle instance de AccountService
+func NewAccountService(
+	userRepo repository.UserRepository,
+	sessionService SessionService,
+	lockoutService LockoutService,
+	avatarService AvatarService,
+	exportService ExportService,
+	mailer mailer.Mailer,
+	passwordPolicy *password.Policy,
+	passwordHasher password.Hasher,
+	config AccountConfig,
+) AccountService {
+	return &accountService{
+		userRepo:            userRepo,
+		sessionService:      sessionService,
+		lockoutService:      lockoutService,
+		avatarService:       avatarService,
+		exportService:       exportService,
+		mailer:              mailer,
+		passwordPolicy:      passwordPolicy,
+		passwordHasher:      passwordHasher,
+		signer:              linkSigner{secret: []byte(config.LinkSecret)},
+		frontendURL:         config.FrontendURL,
+		linkDuration:        config.EmailChangeTTL,
+		deletionGracePeriod: config.DeletionGracePeriod,
+	}
+}
+
+// ChangePassword remplace le mot de passe après vérification du mot de passe
+// actuel, puis révoque toutes les sessions sauf la session courante
+func (s *accountService) ChangePassword(userID, currentSessionID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := s.authenticate(userID, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.passwordPolicy.Validate(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, hashedPassword); err != nil {
+		return err
+	}
+
+	if err := s.sessionService.RevokeOthers(user.ID, currentSessionID); err != nil {
+		return err
+	}
+
+	s.notify(user.Email, "Votre mot de passe Collec-App a été modifié",
+		"Bonjour,\n\n"+
+			"Le mot de passe de votre compte Collec-App vient d'être modifié et vos autres appareils ont été déconnectés.\n\n"+
+			"Si vous n'êtes pas à l'origine de ce changement, réinitialisez immédiatement votre mot de passe "+
+			"depuis la page de connexion.\n")
+	return nil
+}
+
+// RequestEmailChange enregistre la nouvelle adresse en attente et lui envoie un
+// lien de confirmation. L'adresse actuelle reste utilisée jusqu'à la
+// confirmation ; elle est prévenue de la demande.
+func (s *accountService) RequestEmailChange(userID uuid.UUID, currentPassword, newEmail string) error {
+	user, err := s.authenticate(userID, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(newEmail)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrEmailAlreadyExists
+	}
+
+	// Une nouvelle demande remplace la précédente, dont le lien devient invalide
+	user.PendingEmail = &newEmail
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	token, err := s.signer.sign(purposeEmailChange, user.ID, newEmail, s.linkDuration)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/confirm-email-change?token=%s", s.frontendURL, url.QueryEscape(token))
+	err = s.mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirmez votre nouvelle adresse email Collec-App",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"Pour utiliser cette adresse avec votre compte Collec-App, ouvrez le lien suivant (valable %d heures) :\n\n"+
+			"%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet email.\n",
+			int(s.linkDuration.Hours()), link),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.notify(user.Email, "Demande de changement d'adresse email Collec-App",
+		fmt.Sprintf("Bonjour,\n\n"+
+			"Une demande de remplacement de l'adresse email de votre compte Collec-App par %s vient d'être faite.\n"+
+			"Le changement ne prendra effet qu'après confirmation depuis la nouvelle adresse.\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, changez immédiatement votre mot de passe.\n",
+			newEmail))
+	return nil
+}
+
+// ConfirmEmailChange valide le lien reçu sur la nouvelle adresse et l'applique
+// au compte. L'ancienne adresse est prévenue du changement.
+func (s *accountService) ConfirmEmailChange(token string) (*models.User, error) {
+	claims, err := s.signer.verify(purposeEmailChange, token)
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Seul le lien de la dernière demande en cours est accepté
+	if user == nil || user.PendingEmail == nil || *user.PendingEmail != claims.Email {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	// L'adresse a pu être prise par une inscription depuis la demande
+	exists, err := s.userRepo.ExistsByEmail(claims.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	now := time.Now()
+	changed, err := s.userRepo.ConfirmEmailChange(user.ID, claims.Email, now)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrInvalidEmailChangeToken
+	}
+
+	oldEmail := user.Email
+	user.Email = claims.Email
+	user.PendingEmail = nil
+	user.EmailVerifiedAt = &now
+
+	s.notify(oldEmail, "L'adresse email de votre compte Collec-App a été modifiée",
+		fmt.Sprintf("Bonjour,\n\n"+
+			"Votre compte Collec-App utilise désormais l'adresse %s. Cette adresse ne recevra plus nos emails.\n\n"+
+			"Si vous n'êtes pas à l'origine de ce changement, contactez-nous au plus vite.\n",
+			user.Email))
+	return user, nil
+}
+
+// DeleteAccount place le compte en attente de suppression après vérification
+// du mot de passe et déconnecte immédiatement tous ses appareils. Une connexion
+// pendant le délai de grâce annule la suppression. Retourne la date à partir de
+// laquelle le compte sera définitivement supprimé.
+func (s *accountService) DeleteAccount(userID uuid.UUID, currentPassword string) (time.Time, error) {
+	user, err := s.authenticate(userID, currentPassword)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	if err := s.userRepo.MarkDeletionRequested(user.ID, now); err != nil {
+		return time.Time{}, err
+	}
+
+	// Invalider les tokens déjà émis, puis les sessions et leurs refresh tokens
+	if err := s.userRepo.IncrementTokenGeneration(user.ID); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.sessionService.RevokeAll(user.ID); err != nil {
+		return time.Time{}, err
+	}
+
+	purgeAt := now.Add(s.deletionGracePeriod)
+	s.notify(user.Email, "Suppression de votre compte Collec-App",
+		fmt.Sprintf("Bonjour,\n\n"+
+			"La suppression de votre compte Collec-App a bien été enregistrée et vos appareils ont été déconnectés.\n"+
+			"Le compte et toutes ses données seront définitivement supprimés le %s.\n\n"+
+			"Pour annuler la suppression, il vous suffit de vous reconnecter avant cette date.\n",
+			purgeAt.Format("02/01/2006 à 15:04")))
+	return purgeAt, nil
+}
+
+// PurgeDeletedAccounts supprime définitivement les comptes dont le délai de
+// grâce est écoulé, ainsi que toutes leurs données. Retourne le nombre de
+// comptes supprimés ; à appeler périodiquement.
+func (s *accountService) PurgeDeletedAccounts() (int, error) {
+	requestedBefore := time.Now().Add(-s.deletionGracePeriod)
+	users, err := s.userRepo.FindDeletionDue(requestedBefore, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, user := range users {
+		// Les archives d'export sont hors de la base et ne sont retrouvées qu'à
+		// partir des demandes d'export, effacées par la purge : les supprimer
+		// d'abord, ou reporter la purge du compte au passage suivant
+		if err := s.exportService.DeleteArchives(user.ID); err != nil {
+			log.Printf("suppression des archives d'export de %s impossible: %v", user.ID, err)
+			continue
+		}
+
+		purged, err := s.userRepo.Purge(user.ID, requestedBefore)
+		if err != nil {
+			return count, err
+		}
+		if !purged {
+			continue
+		}
+		count++
+
+		// Le compteur d'échecs de connexion est indexé par l'adresse email
+		if err := s.lockoutService.Unlock(user.Email); err != nil && !errors.Is(err, ErrNotLocked) {
+			log.Printf("suppression du compteur de connexion de %s impossible: %v", user.ID, err)
+		}
+
+		// Les fichiers de l'avatar sont hors de la base
+		if user.AvatarKey != "" {
+			s.avatarService.DeleteFiles(context.Background(), user.AvatarKey)
+		}
+	}
+	return count, nil
+}
+
+// authenticate charge l'utilisateur et vérifie son mot de passe actuel
+func (s *accountService) authenticate(userID uuid.UUID, currentPassword string) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Un compte créé via un fournisseur externe n'a pas de mot de passe : il
+	// doit d'abord en définir un avec le flux "mot de passe oublié"
+	valid, err := s.passwordHasher.Verify(currentPassword, user.Password)
+	if err != nil || !valid {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// notify envoie une notification de sécurité. Le changement ayant déjà été
+// appliqué, un échec d'envoi est journalisé sans être retourné.
+func (s *accountService) notify(to, subject, body string) {
+	if err := s.mailer.Send(mailer.Message{To: to, Subject: subject, Body: body}); err != nil {
+		log.Printf("envoi de la notification de sécurité à %s impossible: %v", to, err)
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testLinkSecret est le secret des liens signés utilisé par les tests
+const testLinkSecret = "test-link-secret"
+
+// testAccountConfig est la configuration de gestion des comptes utilisée par les tests
+var testAccountConfig = AccountConfig{
+	LinkSecret:          testLinkSecret,
+	FrontendURL:         "http://localhost:3000",
+	EmailChangeTTL:      24 * time.Hour,
+	DeletionGracePeriod: 30 * 24 * time.Hour,
+}
+
+// Helper function pour créer le service de compte et ses mocks
+func newAccountTestService() (AccountService, *MockUserRepository, *MockSessionService, *MockMailer) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionService)
+	mockMailer := new(MockMailer)
+	accountService := NewAccountService(mockRepo, mockSessions, newTestLockout(), new(MockAvatarService), new(MockExportService), mockMailer, testPasswordPolicy, testPasswordHasher, testAccountConfig)
+	return accountService, mockRepo, mockSessions, mockMailer
+}
+
+// Helper function pour créer un utilisateur avec un mot de passe connu
+func newAccountTestUser(t *testing.T) *models.User {
+	t.Helper()
+	hashedPassword, err := testPasswordHasher.Hash("password123")
+	require.NoError(t, err)
+	return &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword}
+}
+
+// Tests du changement de mot de passe
+
+func TestChangePassword_Success(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, mockSessions, mockMailer := newAccountTestService()
+	user := newAccountTestUser(t)
+	sessionID := uuid.New()
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdatePassword", user.ID, mock.MatchedBy(func(hash string) bool {
+		valid, err := testPasswordHasher.Verify("new-password456", hash)
+		return err == nil && valid
+	})).Return(nil)
+	mockSessions.On("RevokeOthers", user.ID, sessionID).Return(nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	err := accountService.ChangePassword(user.ID, sessionID, "password123", "new-password456")
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, mockSessions, _ := newAccountTestService()
+	user := newAccountTestUser(t)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	err := accountService.ChangePassword(user.ID, uuid.New(), "wrongpassword", "new-password456")
+
+	// Assert
+	assert.Equal(t, ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+	mockSessions.AssertNotCalled(t, "RevokeOthers", mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_WeakPassword(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, _, _ := newAccountTestService()
+	user := newAccountTestUser(t)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	err := accountService.ChangePassword(user.ID, uuid.New(), "password123", "short")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestChangePassword_NotificationFailureIsIgnored(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, mockSessions, mockMailer := newAccountTestService()
+	user := newAccountTestUser(t)
+	sessionID := uuid.New()
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdatePassword", user.ID, mock.AnythingOfType("string")).Return(nil)
+	mockSessions.On("RevokeOthers", user.ID, sessionID).Return(nil)
+	mockMailer.On("Send", mock.Anything).Return(errors.New("smtp error"))
+
+	// Act
+	err := accountService.ChangePassword(user.ID, sessionID, "password123", "new-password456")
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+// Tests du changement d'adresse email
+
+func TestRequestEmailChange_SendsConfirmationAndNotifiesOldAddress(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, _, mockMailer := newAccountTestService()
+	user := newAccountTestUser(t)
+	var confirmation mailer.Message
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("ExistsByEmail", "new@example.com").Return(false, nil)
+	mockRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+		return u.ID == user.ID && u.PendingEmail != nil && *u.PendingEmail == "new@example.com"
+	})).Return(nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		confirmation = msg
+		return msg.To == "new@example.com"
+	})).Return(nil).Once()
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == user.Email && strings.Contains(msg.Body, "new@example.com")
+	})).Return(nil).Once()
+
+	// Act
+	err := accountService.RequestEmailChange(user.ID, "password123", " new@example.com ")
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+	assert.NotEmpty(t, tokenFromEmail(confirmation.Body, "http://localhost:3000/auth/confirm-email-change"))
+}
+
+func TestRequestEmailChange_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		password string
+		newEmail string
+		taken    bool
+		expected error
+	}{
+		{"mot de passe incorrect", "wrongpassword", "new@example.com", false, ErrInvalidCredentials},
+		{"adresse identique", "password123", "Test@Example.com", false, ErrEmailUnchanged},
+		{"adresse déjà utilisée", "password123", "taken@example.com", true, ErrEmailAlreadyExists},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			accountService, mockRepo, _, mockMailer := newAccountTestService()
+			user := newAccountTestUser(t)
+
+			mockRepo.On("FindByID", user.ID).Return(user, nil)
+			mockRepo.On("ExistsByEmail", tc.newEmail).Return(tc.taken, nil)
+
+			// Act
+			err := accountService.RequestEmailChange(user.ID, tc.password, tc.newEmail)
+
+			// Assert
+			assert.Equal(t, tc.expected, err)
+			mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+			mockMailer.AssertNotCalled(t, "Send", mock.Anything)
+		})
+	}
+}
+
+func TestConfirmEmailChange_Success(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, _, mockMailer := newAccountTestService()
+	pending := "new@example.com"
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", PendingEmail: &pending}
+	token, err := linkSigner{secret: []byte(testLinkSecret)}.sign(purposeEmailChange, user.ID, pending, time.Hour)
+	require.NoError(t, err)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("ExistsByEmail", pending).Return(false, nil)
+	mockRepo.On("ConfirmEmailChange", user.ID, pending, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "test@example.com"
+	})).Return(nil)
+
+	// Act
+	updated, err := accountService.ConfirmEmailChange(token)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, pending, updated.Email)
+	assert.Nil(t, updated.PendingEmail)
+	assert.NotNil(t, updated.EmailVerifiedAt)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestConfirmEmailChange_SupersededRequest(t *testing.T) {
+	// Arrange : une nouvelle demande a remplacé celle du lien
+	accountService, mockRepo, _, _ := newAccountTestService()
+	pending := "other@example.com"
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", PendingEmail: &pending}
+	token, err := linkSigner{secret: []byte(testLinkSecret)}.sign(purposeEmailChange, user.ID, "new@example.com", time.Hour)
+	require.NoError(t, err)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	updated, err := accountService.ConfirmEmailChange(token)
+
+	// Assert
+	assert.Equal(t, ErrInvalidEmailChangeToken, err)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "ConfirmEmailChange", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestConfirmEmailChange_RejectsOtherLinkPurpose(t *testing.T) {
+	// Arrange : un lien de vérification d'email ne confirme pas un changement
+	accountService, mockRepo, _, _ := newAccountTestService()
+	token, err := linkSigner{secret: []byte(testLinkSecret)}.sign(purposeEmailVerification, uuid.New(), "new@example.com", time.Hour)
+	require.NoError(t, err)
+
+	// Act
+	updated, err := accountService.ConfirmEmailChange(token)
+
+	// Assert
+	assert.Equal(t, ErrInvalidEmailChangeToken, err)
+	assert.Nil(t, updated)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+// Tests de la suppression de compte
+
+func TestDeleteAccount_InvalidatesTokensAndSchedulesPurge(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, mockSessions, mockMailer := newAccountTestService()
+	user := newAccountTestUser(t)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("MarkDeletionRequested", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	mockRepo.On("IncrementTokenGeneration", user.ID).Return(nil)
+	mockSessions.On("RevokeAll", user.ID).Return(nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	purgeAt, err := accountService.DeleteAccount(user.ID, "password123")
+
+	// Assert
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(testAccountConfig.DeletionGracePeriod), purgeAt, time.Second)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestDeleteAccount_WrongPassword(t *testing.T) {
+	// Arrange
+	accountService, mockRepo, mockSessions, _ := newAccountTestService()
+	user := newAccountTestUser(t)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	_, err := accountService.DeleteAccount(user.ID, "wrongpassword")
+
+	// Assert
+	assert.Equal(t, ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "MarkDeletionRequested", mock.Anything, mock.Anything)
+	mockSessions.AssertNotCalled(t, "RevokeAll", mock.Anything)
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLockout := new(MockLockoutService)
+	mockAvatars := new(MockAvatarService)
+	mockExports := new(MockExportService)
+	accountService := NewAccountService(mockRepo, new(MockSessionService), mockLockout, mockAvatars, mockExports, new(MockMailer), testPasswordPolicy, testPasswordHasher, testAccountConfig)
+
+	requestedAt := time.Now().Add(-31 * 24 * time.Hour)
+	due := []models.User{
+		{ID: uuid.New(), Email: "first@example.com", DeletionRequestedAt: &requestedAt, AvatarKey: "avatars/first/v1"},
+		{ID: uuid.New(), Email: "reactivated@example.com", DeletionRequestedAt: &requestedAt, AvatarKey: "avatars/reactivated/v1"},
+	}
+	isCutoff := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= testAccountConfig.DeletionGracePeriod
+	})
+
+	mockRepo.On("FindDeletionDue", isCutoff, purgeBatchSize).Return(due, nil)
+	mockExports.On("DeleteArchives", due[0].ID).Return(nil)
+	mockExports.On("DeleteArchives", due[1].ID).Return(nil)
+	mockRepo.On("Purge", due[0].ID, isCutoff).Return(true, nil)
+	mockRepo.On("Purge", due[1].ID, isCutoff).Return(false, nil) // reconnecté entre-temps
+	mockLockout.On("Unlock", "first@example.com").Return(ErrNotLocked)
+	mockAvatars.On("DeleteFiles", mock.Anything, "avatars/first/v1").Return()
+
+	// Act
+	count, err := accountService.PurgeDeletedAccounts()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockRepo.AssertExpectations(t)
+	mockLockout.AssertNotCalled(t, "Unlock", "reactivated@example.com")
+	mockAvatars.AssertExpectations(t)
+	mockAvatars.AssertNotCalled(t, "DeleteFiles", mock.Anything, "avatars/reactivated/v1")
+	mockExports.AssertExpectations(t)
+}
+
+func TestPurgeDeletedAccounts_PostponedWhenArchivesCannotBeDeleted(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockExports := new(MockExportService)
+	accountService := NewAccountService(mockRepo, new(MockSessionService), newTestLockout(), new(MockAvatarService), mockExports, new(MockMailer), testPasswordPolicy, testPasswordHasher, testAccountConfig)
+
+	requestedAt := time.Now().Add(-31 * 24 * time.Hour)
+	user := models.User{ID: uuid.New(), Email: "test@example.com", DeletionRequestedAt: &requestedAt}
+
+	mockRepo.On("FindDeletionDue", mock.AnythingOfType("time.Time"), purgeBatchSize).Return([]models.User{user}, nil)
+	mockExports.On("DeleteArchives", user.ID).Return(errors.New("disque indisponible"))
+
+	// Act
+	count, err := accountService.PurgeDeletedAccounts()
+
+	// Assert : le compte reste en base pour que ses archives soient retrouvées au passage suivant
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	mockRepo.AssertNotCalled(t, "Purge", mock.Anything, mock.Anything)
+}
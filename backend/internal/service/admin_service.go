@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSelfAdministration = errors.New("opération impossible sur son propre compte")
+	ErrUnknownUserStatus  = errors.New("statut de compte inconnu")
+)
+
+// Pagination de la liste des utilisateurs
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+// UserPage représente une page de la liste des utilisateurs
+type UserPage struct {
+	Users    []models.User
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// UserDetails regroupe un utilisateur et les rôles qui lui sont attribués
+type UserDetails struct {
+	User  *models.User
+	Roles []string
+}
+
+// AdminService définit l'interface d'administration des comptes utilisateurs
+type AdminService interface {
+	ListUsers(search, status, role string, page, pageSize int) (*UserPage, error)
+	GetUser(userID uuid.UUID) (*UserDetails, error)
+	ListSessions(userID uuid.UUID) ([]models.Session, error)
+	DisableUser(adminID, userID uuid.UUID) error
+	EnableUser(userID uuid.UUID) error
+	ForcePasswordReset(userID uuid.UUID) error
+	RevokeTokens(userID uuid.UUID) error
+}
+
+// adminService implémente AdminService
+type adminService struct {
+	userRepo             repository.UserRepository
+	sessionService       SessionService
+	roleService          RoleService
+	passwordResetService PasswordResetService
+}
+
+// NewAdminService crée une nouvelle instance de AdminService
+func NewAdminService(
+	userRepo repository.UserRepository,
+	sessionService SessionService,
+	roleService RoleService,
+	passwordResetService PasswordResetService,
+) AdminService {
+	return &adminService{
+		userRepo:             userRepo,
+		sessionService:       sessionService,
+		roleService:          roleService,
+		passwordResetService: passwordResetService,
+	}
+}
+
+// ListUsers retourne une page d'utilisateurs filtrée par fragment d'email,
+// statut de compte et rôle. Les pages sont numérotées à partir de 1 ; une
+// taille hors limites est ramenée à la valeur par défaut ou au maximum.
+func (s *adminService) ListUsers(search, status, role string, page, pageSize int) (*UserPage, error) {
+	switch status {
+	case "", repository.UserStatusActive, repository.UserStatusDisabled,
+		repository.UserStatusUnverified, repository.UserStatusPendingDeletion:
+	default:
+		return nil, ErrUnknownUserStatus
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultUserPageSize
+	}
+	if pageSize > MaxUserPageSize {
+		pageSize = MaxUserPageSize
+	}
+
+	users, total, err := s.userRepo.List(repository.UserFilter{
+		Search: search,
+		Status: status,
+		Role:   role,
+		Offset: (page - 1) * pageSize,
+		Limit:  pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserPage{Users: users, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// GetUser retourne un utilisateur et ses rôles
+func (s *adminService) GetUser(userID uuid.UUID) (*UserDetails, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizations, err := s.roleService.Authorizations(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDetails{User: user, Roles: authorizations.Roles}, nil
+}
+
+// ListSessions retourne les sessions actives d'un utilisateur
+func (s *adminService) ListSessions(userID uuid.UUID) ([]models.Session, error) {
+	if _, err := s.findUser(userID); err != nil {
+		return nil, err
+	}
+	return s.sessionService.List(userID)
+}
+
+// DisableUser désactive un compte et le déconnecte de tous ses appareils. Les
+// tokens déjà émis sont refusés dès la requête suivante. Un administrateur ne
+// peut pas désactiver son propre compte.
+func (s *adminService) DisableUser(adminID, userID uuid.UUID) error {
+	if adminID == userID {
+		return ErrSelfAdministration
+	}
+
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := s.userRepo.SetDisabled(user.ID, &now); err != nil {
+		return err
+	}
+	return s.revokeTokens(user.ID)
+}
+
+// EnableUser réactive un compte désactivé. L'utilisateur doit se reconnecter :
+// ses sessions ont été révoquées lors de la désactivation.
+func (s *adminService) EnableUser(userID uuid.UUID) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt == nil {
+		return nil
+	}
+	return s.userRepo.SetDisabled(user.ID, nil)
+}
+
+// ForcePasswordReset refuse toute connexion jusqu'à ce que l'utilisateur
+// choisisse un nouveau mot de passe, le déconnecte de tous ses appareils
+// et lui envoie un lien de réinitialisation
+func (s *adminService) ForcePasswordReset(userID uuid.UUID) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.RequirePasswordReset(user.ID); err != nil {
+		return err
+	}
+	if err := s.revokeTokens(user.ID); err != nil {
+		return err
+	}
+
+	// La réinitialisation reste imposée si l'envoi échoue : l'utilisateur peut
+	// redemander le lien depuis la page de connexion
+	if err := s.passwordResetService.RequestReset(user.Email); err != nil {
+		log.Printf("envoi du lien de réinitialisation imposée à %s impossible: %v", user.ID, err)
+	}
+	return nil
+}
+
+// RevokeTokens invalide tous les tokens émis pour l'utilisateur et révoque
+// ses sessions, comme une déconnexion partout
+func (s *adminService) RevokeTokens(userID uuid.UUID) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	return s.revokeTokens(user.ID)
+}
+
+// revokeTokens incrémente la génération de tokens et révoque les sessions
+func (s *adminService) revokeTokens(userID uuid.UUID) error {
+	if err := s.userRepo.IncrementTokenGeneration(userID); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeAll(userID)
+}
+
+// findUser charge un utilisateur, ErrUserNotFound s'il n'existe pas
+func (s *adminService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
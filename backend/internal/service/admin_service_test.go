@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du PasswordResetService
+type MockPasswordResetService struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetService) RequestReset(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetService) ResetPassword(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+// Helper function pour créer le service d'administration et ses mocks
+func newAdminTestService() (AdminService, *MockUserRepository, *MockSessionService, *MockPasswordResetService) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionService)
+	mockReset := new(MockPasswordResetService)
+	adminService := NewAdminService(mockRepo, mockSessions, newTestRoles(), mockReset)
+	return adminService, mockRepo, mockSessions, mockReset
+}
+
+// Tests de la liste des utilisateurs
+
+func TestListUsers_PaginatesAndFilters(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, _, _ := newAdminTestService()
+	users := []models.User{{ID: uuid.New(), Email: "alice@example.com"}}
+
+	mockRepo.On("List", repository.UserFilter{
+		Search: "alice",
+		Status: repository.UserStatusDisabled,
+		Role:   RoleAdmin,
+		Offset: 40,
+		Limit:  20,
+	}).Return(users, int64(41), nil)
+
+	// Act
+	page, err := adminService.ListUsers("alice", repository.UserStatusDisabled, RoleAdmin, 3, 20)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, users, page.Users)
+	assert.Equal(t, int64(41), page.Total)
+	assert.Equal(t, 3, page.Page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListUsers_NormalizesPagination(t *testing.T) {
+	cases := []struct {
+		name             string
+		page, pageSize   int
+		expectedOffset   int
+		expectedPageSize int
+	}{
+		{"valeurs absentes", 0, 0, 0, DefaultUserPageSize},
+		{"taille maximale dépassée", 2, 1000, MaxUserPageSize, MaxUserPageSize},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			adminService, mockRepo, _, _ := newAdminTestService()
+			mockRepo.On("List", repository.UserFilter{Offset: tc.expectedOffset, Limit: tc.expectedPageSize}).Return([]models.User{}, int64(0), nil)
+
+			page, err := adminService.ListUsers("", "", "", tc.page, tc.pageSize)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedPageSize, page.PageSize)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListUsers_UnknownStatus(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, _, _ := newAdminTestService()
+
+	// Act
+	page, err := adminService.ListUsers("", "banned", "", 1, 20)
+
+	// Assert
+	assert.Nil(t, page)
+	assert.Equal(t, ErrUnknownUserStatus, err)
+	mockRepo.AssertNotCalled(t, "List", mock.Anything)
+}
+
+// Tests du détail d'un utilisateur
+
+func TestGetUser_NotFound(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, mockSessions, _ := newAdminTestService()
+	userID := uuid.New()
+	mockRepo.On("FindByID", userID).Return(nil, nil)
+
+	// Act
+	_, err := adminService.GetUser(userID)
+	_, sessionsErr := adminService.ListSessions(userID)
+
+	// Assert
+	assert.Equal(t, ErrUserNotFound, err)
+	assert.Equal(t, ErrUserNotFound, sessionsErr)
+	mockSessions.AssertNotCalled(t, "List", mock.Anything)
+}
+
+// Tests de la désactivation
+
+func TestDisableUser_RevokesTokensAndSessions(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, mockSessions, _ := newAdminTestService()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("SetDisabled", user.ID, mock.MatchedBy(func(disabledAt *time.Time) bool {
+		return disabledAt != nil && time.Since(*disabledAt) < time.Minute
+	})).Return(nil)
+	mockRepo.On("IncrementTokenGeneration", user.ID).Return(nil)
+	mockSessions.On("RevokeAll", user.ID).Return(nil)
+
+	// Act
+	err := adminService.DisableUser(uuid.New(), user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestDisableUser_OwnAccountRefused(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, _, _ := newAdminTestService()
+	adminID := uuid.New()
+
+	// Act
+	err := adminService.DisableUser(adminID, adminID)
+
+	// Assert
+	assert.Equal(t, ErrSelfAdministration, err)
+	mockRepo.AssertNotCalled(t, "SetDisabled", mock.Anything, mock.Anything)
+}
+
+func TestEnableUser(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, _, _ := newAdminTestService()
+	disabledAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), DisabledAt: &disabledAt}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("SetDisabled", user.ID, (*time.Time)(nil)).Return(nil)
+
+	// Act
+	err := adminService.EnableUser(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// Tests de la réinitialisation imposée
+
+func TestForcePasswordReset_RevokesTokensAndSendsLink(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, mockSessions, mockReset := newAdminTestService()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("RequirePasswordReset", user.ID).Return(nil)
+	mockRepo.On("IncrementTokenGeneration", user.ID).Return(nil)
+	mockSessions.On("RevokeAll", user.ID).Return(nil)
+	mockReset.On("RequestReset", user.Email).Return(nil)
+
+	// Act
+	err := adminService.ForcePasswordReset(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+	mockReset.AssertExpectations(t)
+}
+
+func TestForcePasswordReset_EmailFailureIsIgnored(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, mockSessions, mockReset := newAdminTestService()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("RequirePasswordReset", user.ID).Return(nil)
+	mockRepo.On("IncrementTokenGeneration", user.ID).Return(nil)
+	mockSessions.On("RevokeAll", user.ID).Return(nil)
+	mockReset.On("RequestReset", user.Email).Return(errors.New("smtp down"))
+
+	// Act
+	err := adminService.ForcePasswordReset(user.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertCalled(t, "RequirePasswordReset", user.ID)
+}
+
+// Tests de la révocation des tokens
+
+func TestRevokeTokens_NotFound(t *testing.T) {
+	// Arrange
+	adminService, mockRepo, _, _ := newAdminTestService()
+	userID := uuid.New()
+	mockRepo.On("FindByID", userID).Return(nil, nil)
+
+	// Act
+	err := adminService.RevokeTokens(userID)
+
+	// Assert
+	assert.Equal(t, ErrUserNotFound, err)
+	mockRepo.AssertNotCalled(t, "IncrementTokenGeneration", mock.Anything)
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	appErrors "github.com/arnaud-dars/collec-app/internal/errors"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidAuditFilter = errors.New("filtre du journal d'audit invalide")
+
+// Types d'événements du journal d'audit
+const (
+	AuditEventRegister     = "register"
+	AuditEventLogin        = "login"         // mot de passe, fournisseur externe ou lien de connexion
+	AuditEventMFALogin     = "login_mfa"     // second facteur d'une connexion
+	AuditEventTokenRefresh = "token_refresh" // renouvellement par refresh token
+	AuditEventLogout       = "logout"
+	AuditEventLogoutAll    = "logout_all"
+	AuditEventUnauthorized = "unauthorized" // requête refusée par le middleware d'authentification
+)
+
+// Issues d'un événement du journal d'audit
+const (
+	AuditOutcomeSuccess     = "success"
+	AuditOutcomeFailure     = "failure"
+	AuditOutcomeMFARequired = "mfa_required" // mot de passe accepté, second facteur attendu
+)
+
+// auditEvents et auditOutcomes listent les valeurs acceptées par les filtres
+var (
+	auditEvents = []string{
+		AuditEventRegister, AuditEventLogin, AuditEventMFALogin, AuditEventTokenRefresh,
+		AuditEventLogout, AuditEventLogoutAll, AuditEventUnauthorized,
+	}
+	auditOutcomes = []string{AuditOutcomeSuccess, AuditOutcomeFailure, AuditOutcomeMFARequired}
+)
+
+// MaxAuditUserAgentLength borne la taille du user agent enregistré, fourni
+// librement par le client
+const MaxAuditUserAgentLength = 512
+
+// Pagination du journal d'audit
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 200
+)
+
+// AuditEntry décrit un événement à enregistrer. L'issue et le code d'erreur
+// sont déduits de Err s'ils ne sont pas renseignés.
+type AuditEntry struct {
+	Event     string
+	UserID    uuid.UUID // uuid.Nil si l'acteur n'a pas été identifié
+	Email     string
+	Client    ClientInfo
+	Outcome   string
+	Err       error
+	ErrorCode string
+}
+
+// AuditQuery décrit les critères de recherche des administrateurs
+type AuditQuery struct {
+	UserID  *uuid.UUID
+	Email   string
+	Event   string
+	Outcome string
+	IP      string
+	From    *time.Time
+	To      *time.Time
+}
+
+// AuditEventPage représente une page du journal d'audit
+type AuditEventPage struct {
+	Events   []models.AuditEvent
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// AuditLogger définit l'interface du journal d'audit des événements d'authentification
+type AuditLogger interface {
+	Record(entry AuditEntry)
+	ListForUser(userID uuid.UUID, page, pageSize int) (*AuditEventPage, error)
+	Query(query AuditQuery, page, pageSize int) (*AuditEventPage, error)
+}
+
+// auditLogger implémente AuditLogger
+type auditLogger struct {
+	auditRepo repository.AuditEventRepository
+}
+
+// NewAuditLogger crée une nouvelle instance de AuditLogger
+func NewAuditLogger(auditRepo repository.AuditEventRepository) AuditLogger {
+	return &auditLogger{
+		auditRepo: auditRepo,
+	}
+}
+
+// Record ajoute un événement au journal. Un échec d'écriture est journalisé
+// mais n'interrompt pas l'opération auditée.
+func (l *auditLogger) Record(entry AuditEntry) {
+	event := &models.AuditEvent{
+		Email:     entry.Email,
+		Event:     entry.Event,
+		Outcome:   entry.Outcome,
+		ErrorCode: entry.ErrorCode,
+		IP:        entry.Client.IP,
+		UserAgent: truncateRunes(entry.Client.UserAgent, MaxAuditUserAgentLength),
+	}
+	if entry.UserID != uuid.Nil {
+		userID := entry.UserID
+		event.UserID = &userID
+	}
+	if event.Outcome == "" {
+		event.Outcome = AuditOutcomeSuccess
+		if entry.Err != nil {
+			event.Outcome = AuditOutcomeFailure
+		}
+	}
+	if event.ErrorCode == "" && entry.Err != nil {
+		event.ErrorCode = auditErrorCode(entry.Err)
+	}
+
+	if err := l.auditRepo.Create(event); err != nil {
+		log.Printf("enregistrement de l'événement d'audit %s (%s) impossible: %v", event.Event, event.Outcome, err)
+	}
+}
+
+// ListForUser retourne l'historique de sécurité d'un utilisateur, du plus
+// récent au plus ancien
+func (l *auditLogger) ListForUser(userID uuid.UUID, page, pageSize int) (*AuditEventPage, error) {
+	return l.list(repository.AuditEventFilter{UserID: &userID}, page, pageSize)
+}
+
+// Query recherche dans l'ensemble du journal. Les types d'événements et les
+// issues inconnus sont refusés, comme une période dont la fin précède le début.
+func (l *auditLogger) Query(query AuditQuery, page, pageSize int) (*AuditEventPage, error) {
+	if query.Event != "" && !slices.Contains(auditEvents, query.Event) {
+		return nil, ErrInvalidAuditFilter
+	}
+	if query.Outcome != "" && !slices.Contains(auditOutcomes, query.Outcome) {
+		return nil, ErrInvalidAuditFilter
+	}
+	if query.From != nil && query.To != nil && query.To.Before(*query.From) {
+		return nil, ErrInvalidAuditFilter
+	}
+
+	return l.list(repository.AuditEventFilter{
+		UserID:  query.UserID,
+		Email:   query.Email,
+		Event:   query.Event,
+		Outcome: query.Outcome,
+		IP:      query.IP,
+		From:    query.From,
+		To:      query.To,
+	}, page, pageSize)
+}
+
+// list applique la pagination au filtre. Les pages sont numérotées à partir
+// de 1 ; une taille hors limites est ramenée à la valeur par défaut ou au maximum.
+func (l *auditLogger) list(filter repository.AuditEventFilter, page, pageSize int) (*AuditEventPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultAuditPageSize
+	}
+	if pageSize > MaxAuditPageSize {
+		pageSize = MaxAuditPageSize
+	}
+
+	filter.Offset = (page - 1) * pageSize
+	filter.Limit = pageSize
+	events, total, err := l.auditRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEventPage{Events: events, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// auditExportSource exporte l'historique de sécurité d'un utilisateur
+type auditExportSource struct {
+	auditRepo repository.AuditEventRepository
+}
+
+// NewAuditExportSource crée la source d'export du journal d'audit
+func NewAuditExportSource(auditRepo repository.AuditEventRepository) ExportSource {
+	return &auditExportSource{
+		auditRepo: auditRepo,
+	}
+}
+
+// Export écrit le fichier audit/events.json, lu par pages de MaxAuditPageSize
+func (s *auditExportSource) Export(user *models.User, archive *ExportArchive) error {
+	events := []models.AuditEvent{}
+	for offset := 0; ; offset += MaxAuditPageSize {
+		page, _, err := s.auditRepo.List(repository.AuditEventFilter{UserID: &user.ID, Offset: offset, Limit: MaxAuditPageSize})
+		if err != nil {
+			return err
+		}
+		events = append(events, page...)
+		if len(page) < MaxAuditPageSize {
+			break
+		}
+	}
+	return archive.WriteJSON("audit/events.json", events)
+}
+
+// auditErrorCode associe une erreur du service au code d'erreur applicatif
+// correspondant
+func auditErrorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrAccountLocked):
+		return appErrors.ErrAccountLocked.Code
+	case errors.Is(err, ErrTooManyAttempts):
+		return appErrors.ErrTooManyLoginAttempts.Code
+	case errors.Is(err, ErrInvalidCredentials):
+		return appErrors.ErrInvalidCredentials.Code
+	case errors.Is(err, ErrEmailNotVerified):
+		return appErrors.ErrEmailNotVerified.Code
+	case errors.Is(err, ErrInvalidMFACode):
+		return appErrors.ErrInvalidMFACode.Code
+	case errors.Is(err, ErrAccountDisabled):
+		return appErrors.ErrAccountDisabled.Code
+	case errors.Is(err, ErrPasswordResetRequired):
+		return appErrors.ErrPasswordResetRequired.Code
+	case errors.Is(err, ErrTokenRevoked), errors.Is(err, ErrTokenReused):
+		return appErrors.ErrTokenRevoked.Code
+	case errors.Is(err, ErrInvalidToken):
+		return appErrors.ErrUnauthorized.Code
+	case errors.Is(err, ErrWeakPassword):
+		return appErrors.ErrWeakPassword.Code
+	case errors.Is(err, ErrEmailAlreadyExists):
+		return appErrors.ErrDuplicate.Code
+	default:
+		return appErrors.ErrInternal.Code
+	}
+}
+
+// truncateRunes tronque une chaîne à max caractères sans couper de caractère
+// multi-octets
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
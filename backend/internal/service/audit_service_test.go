@@ -0,0 +1,262 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du AuditEventRepository
+type MockAuditEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditEventRepository) Create(event *models.AuditEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func (m *MockAuditEventRepository) List(filter repository.AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.AuditEvent), args.Get(1).(int64), args.Error(2)
+}
+
+// discardAuditLogger ignore les événements, pour les tests qui ne portent pas sur l'audit
+type discardAuditLogger struct{}
+
+func (discardAuditLogger) Record(entry AuditEntry) {}
+
+func (discardAuditLogger) ListForUser(userID uuid.UUID, page, pageSize int) (*AuditEventPage, error) {
+	return &AuditEventPage{}, nil
+}
+
+func (discardAuditLogger) Query(query AuditQuery, page, pageSize int) (*AuditEventPage, error) {
+	return &AuditEventPage{}, nil
+}
+
+// recordingAuditLogger conserve les événements enregistrés
+type recordingAuditLogger struct {
+	discardAuditLogger
+	entries []AuditEntry
+}
+
+func (l *recordingAuditLogger) Record(entry AuditEntry) {
+	l.entries = append(l.entries, entry)
+}
+
+// Tests de l'enregistrement
+
+func TestAuditRecord_DerivesOutcomeAndErrorCode(t *testing.T) {
+	userID := uuid.New()
+
+	cases := []struct {
+		name            string
+		entry           AuditEntry
+		expectedOutcome string
+		expectedCode    string
+	}{
+		{"succès", AuditEntry{Event: AuditEventLogin, UserID: userID}, AuditOutcomeSuccess, ""},
+		{"identifiants invalides", AuditEntry{Event: AuditEventLogin, Err: ErrInvalidCredentials}, AuditOutcomeFailure, "ERR_AUTH_002"},
+		{"compte verrouillé", AuditEntry{Event: AuditEventLogin, Err: &ThrottleError{Err: ErrAccountLocked}}, AuditOutcomeFailure, "ERR_AUTH_011"},
+		{"token rejoué", AuditEntry{Event: AuditEventTokenRefresh, Err: ErrTokenReused}, AuditOutcomeFailure, "ERR_AUTH_004"},
+		{"second facteur attendu", AuditEntry{Event: AuditEventLogin, Outcome: AuditOutcomeMFARequired}, AuditOutcomeMFARequired, ""},
+		{"code explicite", AuditEntry{Event: AuditEventUnauthorized, Outcome: AuditOutcomeFailure, ErrorCode: "ERR_AUTH_001"}, AuditOutcomeFailure, "ERR_AUTH_001"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockAuditEventRepository)
+			var stored *models.AuditEvent
+			mockRepo.On("Create", mock.MatchedBy(func(event *models.AuditEvent) bool {
+				stored = event
+				return true
+			})).Return(nil)
+
+			NewAuditLogger(mockRepo).Record(tc.entry)
+
+			require.NotNil(t, stored)
+			assert.Equal(t, tc.entry.Event, stored.Event)
+			assert.Equal(t, tc.expectedOutcome, stored.Outcome)
+			assert.Equal(t, tc.expectedCode, stored.ErrorCode)
+			if tc.entry.UserID == uuid.Nil {
+				assert.Nil(t, stored.UserID)
+			} else {
+				assert.Equal(t, tc.entry.UserID, *stored.UserID)
+			}
+		})
+	}
+}
+
+func TestAuditRecord_CapturesClient(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockAuditEventRepository)
+	mockRepo.On("Create", mock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.IP == "192.0.2.10" && event.UserAgent == "Mozilla/5.0" && event.Email == "test@example.com"
+	})).Return(nil)
+
+	// Act
+	NewAuditLogger(mockRepo).Record(AuditEntry{
+		Event:  AuditEventRegister,
+		Email:  "test@example.com",
+		Client: ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"},
+	})
+
+	// Assert
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditRecord_TruncatesUserAgent(t *testing.T) {
+	// Arrange : caractères multi-octets pour vérifier la coupure
+	mockRepo := new(MockAuditEventRepository)
+	mockRepo.On("Create", mock.MatchedBy(func(event *models.AuditEvent) bool {
+		return event.UserAgent == strings.Repeat("é", MaxAuditUserAgentLength) && utf8.ValidString(event.UserAgent)
+	})).Return(nil)
+
+	// Act
+	NewAuditLogger(mockRepo).Record(AuditEntry{
+		Event:  AuditEventLogin,
+		Client: ClientInfo{UserAgent: strings.Repeat("é", MaxAuditUserAgentLength+100)},
+	})
+
+	// Assert
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditRecord_WriteFailureIsIgnored(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockAuditEventRepository)
+	mockRepo.On("Create", mock.Anything).Return(errors.New("database down"))
+
+	// Act & Assert
+	assert.NotPanics(t, func() {
+		NewAuditLogger(mockRepo).Record(AuditEntry{Event: AuditEventLogin})
+	})
+	mockRepo.AssertExpectations(t)
+}
+
+// Tests de la consultation
+
+func TestAuditListForUser_NormalizesPagination(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockAuditEventRepository)
+	userID := uuid.New()
+	events := []models.AuditEvent{{ID: uuid.New(), Event: AuditEventLogin}}
+	mockRepo.On("List", repository.AuditEventFilter{UserID: &userID, Offset: MaxAuditPageSize, Limit: MaxAuditPageSize}).Return(events, int64(201), nil)
+
+	// Act
+	page, err := NewAuditLogger(mockRepo).ListForUser(userID, 2, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, events, page.Events)
+	assert.Equal(t, int64(201), page.Total)
+	assert.Equal(t, MaxAuditPageSize, page.PageSize)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditQuery_Filters(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockAuditEventRepository)
+	from := time.Now().Add(-24 * time.Hour)
+	mockRepo.On("List", repository.AuditEventFilter{
+		Email:   "test@example.com",
+		Event:   AuditEventLogin,
+		Outcome: AuditOutcomeFailure,
+		IP:      "192.0.2.10",
+		From:    &from,
+		Limit:   DefaultAuditPageSize,
+	}).Return([]models.AuditEvent{}, int64(0), nil)
+
+	// Act
+	page, err := NewAuditLogger(mockRepo).Query(AuditQuery{
+		Email:   "test@example.com",
+		Event:   AuditEventLogin,
+		Outcome: AuditOutcomeFailure,
+		IP:      "192.0.2.10",
+		From:    &from,
+	}, 0, 0)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.Page)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditQuery_InvalidFilter(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	cases := []struct {
+		name  string
+		query AuditQuery
+	}{
+		{"événement inconnu", AuditQuery{Event: "password_change"}},
+		{"issue inconnue", AuditQuery{Outcome: "ok"}},
+		{"période inversée", AuditQuery{From: &now, To: &earlier}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockAuditEventRepository)
+
+			page, err := NewAuditLogger(mockRepo).Query(tc.query, 1, 20)
+
+			assert.Nil(t, page)
+			assert.Equal(t, ErrInvalidAuditFilter, err)
+			mockRepo.AssertNotCalled(t, "List", mock.Anything)
+		})
+	}
+}
+
+// Tests de l'export
+
+func TestAuditExportSource_WritesAllEventsOfUser(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockAuditEventRepository)
+	user := &models.User{ID: uuid.New()}
+	firstPage := make([]models.AuditEvent, MaxAuditPageSize)
+	for i := range firstPage {
+		firstPage[i] = models.AuditEvent{ID: uuid.New(), UserID: &user.ID, Event: AuditEventLogin}
+	}
+	lastPage := []models.AuditEvent{{ID: uuid.New(), UserID: &user.ID, Event: AuditEventRegister}}
+	mockRepo.On("List", repository.AuditEventFilter{UserID: &user.ID, Offset: 0, Limit: MaxAuditPageSize}).Return(firstPage, int64(MaxAuditPageSize+1), nil)
+	mockRepo.On("List", repository.AuditEventFilter{UserID: &user.ID, Offset: MaxAuditPageSize, Limit: MaxAuditPageSize}).Return(lastPage, int64(MaxAuditPageSize+1), nil)
+
+	var buf bytes.Buffer
+	archive := &ExportArchive{zip: zip.NewWriter(&buf)}
+
+	// Act
+	err := NewAuditExportSource(mockRepo).Export(user, archive)
+
+	// Assert
+	require.NoError(t, err)
+	require.NoError(t, archive.zip.Close())
+	mockRepo.AssertExpectations(t)
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, reader.File, 1)
+	assert.Equal(t, "audit/events.json", reader.File[0].Name)
+	file, err := reader.File[0].Open()
+	require.NoError(t, err)
+	defer file.Close()
+	var exported []models.AuditEvent
+	require.NoError(t, json.NewDecoder(file).Decode(&exported))
+	assert.Len(t, exported, MaxAuditPageSize+1)
+	assert.Equal(t, AuditEventRegister, exported[MaxAuditPageSize].Event)
+}
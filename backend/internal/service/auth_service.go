@@ -2,65 +2,201 @@ package service
 
 import (
 	"errors"
+	"log"
+	"net/http"
+	"slices"
 	"time"
 
 	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/password"
 	"github.com/arnaud-dars/collec-app/internal/repository"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrEmailAlreadyExists = errors.New("cet email est déjà utilisé")
-	ErrInvalidCredentials = errors.New("email ou mot de passe incorrect")
-	ErrInvalidToken       = errors.New("token invalide")
-	ErrWeakPassword       = errors.New("le mot de passe doit contenir au moins 8 caractères")
+	ErrEmailAlreadyExists    = errors.New("cet email est déjà utilisé")
+	ErrInvalidCredentials    = errors.New("email ou mot de passe incorrect")
+	ErrInvalidToken          = errors.New("token invalide")
+	ErrWeakPassword          = password.ErrPolicyViolation // détaillée par une *password.PolicyError
+	ErrTokenReused           = errors.New("refresh token déjà utilisé")
+	ErrTokenRevoked          = errors.New("token révoqué")
+	ErrEmailNotVerified      = errors.New("adresse email non vérifiée")
+	ErrAccountDisabled       = errors.New("compte désactivé")
+	ErrPasswordResetRequired = errors.New("réinitialisation du mot de passe requise")
 )
 
-// JWTClaims représente les données contenues dans le JWT
+// Types de tokens émis par le service
+const (
+	TokenTypeAccess         = "access"
+	TokenTypeRefresh        = "refresh"
+	TokenTypeMFAPending     = "mfa_pending"     // émis par Login lorsque le second facteur est requis
+	TokenTypePersonalAccess = "personal_access" // claims construits pour un personal access token, jamais signés
+)
+
+// JWTClaims représente les données contenues dans le JWT.
+// Le jti (RegisteredClaims.ID) identifie chaque token pour permettre sa révocation.
 type JWTClaims struct {
-	UserID uuid.UUID `json:"userId"`
-	Email  string    `json:"email"`
+	UserID      uuid.UUID `json:"userId"`
+	Email       string    `json:"email"`
+	SessionID   uuid.UUID `json:"sid"`
+	TokenType   string    `json:"tokenType"`
+	Generation  int       `json:"gen"`
+	Roles       []string  `json:"roles,omitempty"` // access tokens uniquement
+	Permissions []string  `json:"perms,omitempty"` // access tokens uniquement
+	Scopes      []string  `json:"-"`               // personal access tokens uniquement
+	RememberMe  bool      `json:"rmb,omitempty"`   // tokens "mfa pending" uniquement
 	jwt.RegisteredClaims
 }
 
+// HasRole indique si les claims portent le rôle
+func (c *JWTClaims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasPermission indique si les claims portent la permission
+func (c *JWTClaims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// AllowsMethod indique si les claims autorisent une requête de la méthode
+// HTTP donnée. Seuls les personal access tokens sont limités par leurs
+// portées : read pour la lecture, write pour tout le reste.
+func (c *JWTClaims) AllowsMethod(method string) bool {
+	if c.TokenType != TokenTypePersonalAccess || slices.Contains(c.Scopes, ScopeWrite) {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return slices.Contains(c.Scopes, ScopeRead)
+	}
+	return false
+}
+
+// AuthConfig regroupe les paramètres d'émission et de vérification des JWT
+// ainsi que les règles de connexion
+type AuthConfig struct {
+	KeyRing              KeyRing
+	LegacySecret         string // si renseigné, les anciens tokens HS256 sans kid restent acceptés
+	PasswordPolicy       *password.Policy
+	PasswordHasher       password.Hasher
+	Roles                RoleService // rôles et permissions portés par les access tokens
+	Audit                AuditLogger // journal des inscriptions, connexions et renouvellements
+	Issuer               string
+	Audience             string
+	AccessTokenTTL       time.Duration
+	RefreshTokenTTL      time.Duration // sessions ouvertes sans "se souvenir de moi"
+	RememberMeTTL        time.Duration // sessions ouvertes avec "se souvenir de moi"
+	MFAPendingTTL        time.Duration // délai pour saisir le second facteur après le mot de passe
+	RequireVerifiedEmail bool          // refuser la connexion tant que l'email n'est pas vérifié
+	DeletionGracePeriod  time.Duration // délai pendant lequel une connexion annule la suppression du compte
+}
+
+// LoginResult représente l'issue d'une authentification réussie : soit une
+// paire de tokens, soit un token "mfa pending" à échanger avec le second facteur
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+	User         *models.User
+}
+
+// MFARequired indique que la connexion doit être complétée par CompleteMFALogin
+func (r *LoginResult) MFARequired() bool {
+	return r.MFAToken != ""
+}
+
 // AuthService définit l'interface pour les opérations d'authentification
 type AuthService interface {
-	Register(email, password string) (*models.User, error)
-	Login(email, password string) (accessToken, refreshToken string, user *models.User, err error)
-	RefreshToken(refreshToken string) (string, error)
-	ValidateToken(token string) (*JWTClaims, error)
+	Register(email, password string, client ClientInfo) (*models.User, error)
+	Login(email, password string, client ClientInfo) (*LoginResult, error)
+	CompleteMFALogin(mfaToken, code string, client ClientInfo) (*LoginResult, error)
+	LoginWithUser(user *models.User, client ClientInfo) (*LoginResult, error)
+	RefreshToken(refreshToken string, client ClientInfo) (accessToken, newRefreshToken string, err error)
+	ValidateAccessToken(token string) (*JWTClaims, error)
+	Logout(claims *JWTClaims, refreshToken string) error
+	LogoutEverywhere(userID uuid.UUID) error
 }
 
 // authService implémente AuthService
 type authService struct {
 	userRepo             repository.UserRepository
-	jwtSecret            []byte
+	refreshTokenRepo     repository.RefreshTokenRepository
+	revokedTokenRepo     repository.RevokedTokenRepository
+	sessionService       SessionService
+	verificationService  EmailVerificationService
+	mfaService           MFAService
+	lockoutService       LockoutService
+	keyRing              KeyRing
+	legacySecret         []byte
+	passwordPolicy       *password.Policy
+	passwordHasher       password.Hasher
+	roleService          RoleService
+	auditLogger          AuditLogger
+	issuer               string
+	audience             string
 	accessTokenDuration  time.Duration
 	refreshTokenDuration time.Duration
+	rememberMeDuration   time.Duration
+	mfaPendingDuration   time.Duration
+	requireVerifiedEmail bool
+	deletionGracePeriod  time.Duration
 }
 
 // NewAuthService crée une nouvelle instance de AuthService
 func NewAuthService(
 	userRepo repository.UserRepository,
-	jwtSecret string,
-	accessTokenDuration time.Duration,
-	refreshTokenDuration time.Duration,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	revokedTokenRepo repository.RevokedTokenRepository,
+	sessionService SessionService,
+	verificationService EmailVerificationService,
+	mfaService MFAService,
+	lockoutService LockoutService,
+	authConfig AuthConfig,
 ) AuthService {
 	return &authService{
 		userRepo:             userRepo,
-		jwtSecret:            []byte(jwtSecret),
-		accessTokenDuration:  accessTokenDuration,
-		refreshTokenDuration: refreshTokenDuration,
+		refreshTokenRepo:     refreshTokenRepo,
+		revokedTokenRepo:     revokedTokenRepo,
+		sessionService:       sessionService,
+		verificationService:  verificationService,
+		mfaService:           mfaService,
+		lockoutService:       lockoutService,
+		keyRing:              authConfig.KeyRing,
+		legacySecret:         []byte(authConfig.LegacySecret),
+		passwordPolicy:       authConfig.PasswordPolicy,
+		passwordHasher:       authConfig.PasswordHasher,
+		roleService:          authConfig.Roles,
+		auditLogger:          authConfig.Audit,
+		issuer:               authConfig.Issuer,
+		audience:             authConfig.Audience,
+		accessTokenDuration:  authConfig.AccessTokenTTL,
+		refreshTokenDuration: authConfig.RefreshTokenTTL,
+		rememberMeDuration:   authConfig.RememberMeTTL,
+		mfaPendingDuration:   authConfig.MFAPendingTTL,
+		requireVerifiedEmail: authConfig.RequireVerifiedEmail,
+		deletionGracePeriod:  authConfig.DeletionGracePeriod,
 	}
 }
 
-// Register crée un nouveau compte utilisateur
-func (s *authService) Register(email, password string) (*models.User, error) {
+// Register crée un nouveau compte utilisateur et envoie le lien de vérification de l'email
+func (s *authService) Register(email, password string, client ClientInfo) (*models.User, error) {
+	user, err := s.register(email, password)
+
+	entry := AuditEntry{Event: AuditEventRegister, Email: email, Client: client, Err: err}
+	if user != nil {
+		entry.UserID = user.ID
+	}
+	s.auditLogger.Record(entry)
+
+	return user, err
+}
+
+// register crée le compte et envoie le lien de vérification
+func (s *authService) register(email, password string) (*models.User, error) {
 	// Valider le mot de passe
-	if len(password) < 8 {
-		return nil, ErrWeakPassword
+	if err := s.passwordPolicy.Validate(password, email); err != nil {
+		return nil, err
 	}
 
 	// Vérifier que l'email n'existe pas déjà
@@ -73,7 +209,7 @@ func (s *authService) Register(email, password string) (*models.User, error) {
 	}
 
 	// Hasher le mot de passe
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordHasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +217,7 @@ func (s *authService) Register(email, password string) (*models.User, error) {
 	// Créer l'utilisateur
 	user := &models.User{
 		Email:    email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 	}
 
 	err = s.userRepo.Create(user)
@@ -89,100 +225,546 @@ func (s *authService) Register(email, password string) (*models.User, error) {
 		return nil, err
 	}
 
+	// Le compte est créé même si l'envoi échoue : l'utilisateur peut redemander le lien
+	if err := s.verificationService.SendVerification(user); err != nil {
+		log.Printf("envoi de l'email de vérification à %s impossible: %v", user.ID, err)
+	}
+
 	return user, nil
 }
 
-// Login authentifie un utilisateur et retourne les tokens JWT
-func (s *authService) Login(email, password string) (string, string, *models.User, error) {
+// Login authentifie un utilisateur, ouvre une session et retourne les tokens JWT.
+// Si la double authentification est activée, seul un token "mfa pending" est
+// retourné : la session n'est ouverte qu'après CompleteMFALogin.
+func (s *authService) Login(email, password string, client ClientInfo) (*LoginResult, error) {
+	result, user, err := s.login(email, password, client)
+	s.recordLogin(AuditEventLogin, email, user, result, client, err)
+	return result, err
+}
+
+// login vérifie les identifiants puis poursuit avec loginWithUser. L'utilisateur
+// est retourné dès que l'email correspond à un compte, y compris en cas d'échec,
+// pour que la tentative apparaisse dans son historique.
+func (s *authService) login(email, password string, client ClientInfo) (*LoginResult, *models.User, error) {
+	// Refuser la tentative si le compte ou l'adresse IP est verrouillé
+	if err := s.lockoutService.Check(email, client.IP); err != nil {
+		return nil, nil, err
+	}
+
 	// Trouver l'utilisateur par email
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
-		return "", "", nil, err
+		return nil, nil, err
 	}
 	if user == nil {
-		return "", "", nil, ErrInvalidCredentials
+		return nil, nil, s.loginFailed(email, client, ErrInvalidCredentials)
+	}
+
+	// Vérifier le mot de passe. Un compte créé via un fournisseur externe n'a
+	// pas d'empreinte et ne peut pas se connecter par mot de passe.
+	valid, err := s.passwordHasher.Verify(password, user.Password)
+	if err != nil || !valid {
+		return nil, user, s.loginFailed(email, client, ErrInvalidCredentials)
+	}
+	s.rehashPassword(user, password)
+
+	// Les règles de connexion sont vérifiées après le mot de passe pour ne rien
+	// révéler sans identifiants valides
+	result, err := s.loginWithUser(user, client)
+	return result, user, err
+}
+
+// rehashPassword recalcule l'empreinte d'un mot de passe qui vient d'être
+// vérifié lorsqu'elle utilise un algorithme ou des paramètres dépassés, pour
+// que les comptes migrent au fil des connexions. Un échec n'empêche pas la
+// connexion : la mise à jour sera retentée à la suivante.
+func (s *authService) rehashPassword(user *models.User, password string) {
+	if !s.passwordHasher.NeedsRehash(user.Password) {
+		return
+	}
+
+	newHash, err := s.passwordHasher.Hash(password)
+	if err != nil {
+		log.Printf("mise à jour de l'empreinte du mot de passe de %s impossible: %v", user.ID, err)
+		return
+	}
+	updated, err := s.userRepo.RehashPassword(user.ID, user.Password, newHash)
+	if err != nil {
+		log.Printf("mise à jour de l'empreinte du mot de passe de %s impossible: %v", user.ID, err)
+		return
+	}
+	if updated {
+		user.Password = newHash
+	}
+}
+
+// LoginWithUser poursuit la connexion d'un utilisateur dont l'identité a été
+// établie (mot de passe, fournisseur externe...) en appliquant les mêmes règles
+// que Login : compte actif, réinitialisation du mot de passe non imposée, email
+// vérifié si exigé, puis double authentification si activée.
+func (s *authService) LoginWithUser(user *models.User, client ClientInfo) (*LoginResult, error) {
+	result, err := s.loginWithUser(user, client)
+	s.recordLogin(AuditEventLogin, user.Email, user, result, client, err)
+	return result, err
+}
+
+// loginWithUser applique les règles de connexion à un utilisateur identifié
+func (s *authService) loginWithUser(user *models.User, client ClientInfo) (*LoginResult, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	// Un compte dont le délai de grâce est écoulé attend seulement la purge
+	if user.DeletionRequestedAt != nil && time.Since(*user.DeletionRequestedAt) >= s.deletionGracePeriod {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Un administrateur a imposé la réinitialisation : le compte est considéré
+	// comme compromis, aucune méthode de connexion n'est acceptée d'ici là
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
+	if s.requireVerifiedEmail && user.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
 	}
 
-	// Vérifier le mot de passe
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+	if user.TOTPEnabledAt != nil {
+		// Le choix "se souvenir de moi" est conservé jusqu'au second facteur
+		claims := s.newClaims(user, uuid.Nil, TokenTypeMFAPending, uuid.New().String(), s.mfaPendingDuration)
+		claims.RememberMe = client.RememberMe
+		mfaToken, err := s.signClaims(claims)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFAToken: mfaToken, User: user}, nil
+	}
+
+	return s.startSession(user, client)
+}
+
+// CompleteMFALogin échange un token "mfa pending" et un code de second facteur
+// (TOTP ou code de secours) contre une paire de tokens. Le token "mfa pending"
+// ne peut être utilisé qu'une fois.
+func (s *authService) CompleteMFALogin(mfaToken, code string, client ClientInfo) (*LoginResult, error) {
+	result, user, err := s.completeMFALogin(mfaToken, code, client)
+	s.recordLogin(AuditEventMFALogin, "", user, result, client, err)
+	return result, err
+}
+
+// completeMFALogin vérifie le second facteur et ouvre la session. L'utilisateur
+// est retourné dès que le token "mfa pending" l'a identifié.
+func (s *authService) completeMFALogin(mfaToken, code string, client ClientInfo) (*LoginResult, *models.User, error) {
+	claims, user, err := s.validateToken(mfaToken, TokenTypeMFAPending)
 	if err != nil {
-		return "", "", nil, ErrInvalidCredentials
+		return nil, user, err
 	}
 
-	// Générer les tokens
-	accessToken, err := s.generateToken(user.ID, user.Email, s.accessTokenDuration)
+	tokenID, err := uuid.Parse(claims.ID)
 	if err != nil {
-		return "", "", nil, err
+		return nil, user, ErrInvalidToken
 	}
 
-	refreshToken, err := s.generateToken(user.ID, user.Email, s.refreshTokenDuration)
+	revoked, err := s.revokedTokenRepo.IsRevoked(tokenID)
 	if err != nil {
-		return "", "", nil, err
+		return nil, user, err
+	}
+	if revoked {
+		return nil, user, ErrInvalidToken
+	}
+
+	// Les codes erronés comptent comme des échecs de connexion du compte
+	if err := s.lockoutService.Check(user.Email, client.IP); err != nil {
+		return nil, user, err
+	}
+
+	if err := s.mfaService.Verify(user, code); err != nil {
+		if errors.Is(err, ErrMFANotEnabled) {
+			// La double authentification a été désactivée entre les deux étapes
+			return nil, user, ErrInvalidToken
+		}
+		if errors.Is(err, ErrInvalidMFACode) {
+			return nil, user, s.loginFailed(user.Email, client, err)
+		}
+		return nil, user, err
+	}
+
+	// Consommer le token "mfa pending"
+	if err := s.revokedTokenRepo.Create(&models.RevokedToken{
+		ID:        tokenID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}); err != nil {
+		return nil, user, err
 	}
 
-	return accessToken, refreshToken, user, nil
+	client.RememberMe = claims.RememberMe
+	result, err := s.startSession(user, client)
+	return result, user, err
+}
+
+// recordLogin journalise une tentative de connexion ; l'utilisateur est nil
+// s'il n'a pas pu être identifié
+func (s *authService) recordLogin(event, email string, user *models.User, result *LoginResult, client ClientInfo, err error) {
+	entry := AuditEntry{Event: event, Email: email, Client: client, Err: err}
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Email = user.Email
+	}
+	if result != nil && result.MFARequired() {
+		entry.Outcome = AuditOutcomeMFARequired
+	}
+	s.auditLogger.Record(entry)
 }
 
-// RefreshToken génère un nouveau access token à partir d'un refresh token valide
-func (s *authService) RefreshToken(refreshToken string) (string, error) {
+// loginFailed comptabilise un échec de connexion et retourne l'erreur à
+// renvoyer au client
+func (s *authService) loginFailed(email string, client ClientInfo, cause error) error {
+	if err := s.lockoutService.RecordFailure(email, client.IP); err != nil {
+		return err
+	}
+	return cause
+}
+
+// startSession ouvre une session avec une nouvelle famille de refresh tokens
+// et génère la première paire de tokens. Les échecs de connexion du compte ne
+// sont oubliés, et une suppression en attente annulée, qu'ici, une fois
+// l'éventuel second facteur validé.
+func (s *authService) startSession(user *models.User, client ClientInfo) (*LoginResult, error) {
+	if err := s.lockoutService.RecordSuccess(user.Email); err != nil {
+		return nil, err
+	}
+
+	// Une connexion pendant le délai de grâce annule la suppression du compte
+	if user.DeletionRequestedAt != nil {
+		if err := s.userRepo.CancelDeletion(user.ID); err != nil {
+			return nil, err
+		}
+		user.DeletionRequestedAt = nil
+	}
+
+	session, err := s.sessionService.Create(user.ID, uuid.New(), client)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := s.issueTokens(user, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken, User: user}, nil
+}
+
+// RefreshToken échange un refresh token valide contre une nouvelle paire de tokens.
+// Le refresh token présenté est consommé : s'il est présenté à nouveau, toute
+// sa famille est révoquée et ErrTokenReused est retourné.
+func (s *authService) RefreshToken(refreshToken string, client ClientInfo) (string, string, error) {
+	accessToken, newRefreshToken, user, err := s.refreshToken(refreshToken)
+
+	entry := AuditEntry{Event: AuditEventTokenRefresh, Client: client, Err: err}
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Email = user.Email
+	}
+	s.auditLogger.Record(entry)
+
+	return accessToken, newRefreshToken, err
+}
+
+// refreshToken effectue la rotation. L'utilisateur est retourné dès que le
+// token l'a identifié.
+func (s *authService) refreshToken(refreshToken string) (string, string, *models.User, error) {
 	// Valider le refresh token
-	claims, err := s.ValidateToken(refreshToken)
+	claims, user, err := s.validateToken(refreshToken, TokenTypeRefresh)
 	if err != nil {
-		return "", err
+		return "", "", user, err
 	}
 
-	// Vérifier que l'utilisateur existe toujours
-	user, err := s.userRepo.FindByID(claims.UserID)
+	tokenID, err := uuid.Parse(claims.ID)
 	if err != nil {
-		return "", err
+		return "", "", user, ErrInvalidToken
 	}
-	if user == nil {
-		return "", ErrInvalidToken
+
+	// Vérifier l'état du token côté serveur
+	stored, err := s.refreshTokenRepo.FindByID(tokenID)
+	if err != nil {
+		return "", "", user, err
+	}
+	if stored == nil || stored.UserID != user.ID || stored.RevokedAt != nil {
+		return "", "", user, ErrInvalidToken
+	}
+	if stored.RotatedAt != nil {
+		return "", "", user, s.revokeReusedFamily(stored.FamilyID)
 	}
 
-	// Générer un nouveau access token
-	accessToken, err := s.generateToken(user.ID, user.Email, s.accessTokenDuration)
+	// Refuser les sessions révoquées et mettre à jour leur dernière utilisation
+	session, err := s.sessionService.Touch(stored.FamilyID)
 	if err != nil {
-		return "", err
+		if errors.Is(err, ErrSessionRevoked) || errors.Is(err, ErrSessionIdle) {
+			return "", "", user, ErrInvalidToken
+		}
+		return "", "", user, err
 	}
 
-	return accessToken, nil
+	// Consommer le token ; un échec signifie qu'une autre requête l'a utilisé avant nous
+	rotated, err := s.refreshTokenRepo.MarkRotated(stored.ID)
+	if err != nil {
+		return "", "", user, err
+	}
+	if !rotated {
+		return "", "", user, s.revokeReusedFamily(stored.FamilyID)
+	}
+
+	// Générer la nouvelle paire dans la même famille
+	accessToken, newRefreshToken, err := s.issueTokens(user, session)
+	return accessToken, newRefreshToken, user, err
+}
+
+// revokeReusedFamily révoque une famille dont un token a été rejoué
+func (s *authService) revokeReusedFamily(familyID uuid.UUID) error {
+	if err := s.refreshTokenRepo.RevokeFamily(familyID); err != nil {
+		return err
+	}
+	return ErrTokenReused
 }
 
-// ValidateToken valide et parse un JWT
-func (s *authService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Vérifier la méthode de signature
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidToken
+// ValidateAccessToken valide un access token : signature, type, expiration,
+// révocation explicite (déconnexion), génération de l'utilisateur (déconnexion
+// partout), état du compte (désactivation par un administrateur) et session
+// (révoquée depuis un autre appareil ou par un changement de mot de passe)
+func (s *authService) ValidateAccessToken(tokenString string) (*JWTClaims, error) {
+	claims, _, err := s.validateToken(tokenString, TokenTypeAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.revokedTokenRepo.IsRevoked(tokenID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	active, err := s.sessionService.IsActive(claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// Logout révoque l'access token présenté et sa session et, s'il est fourni,
+// la famille du refresh token associé
+func (s *authService) Logout(claims *JWTClaims, refreshToken string) error {
+	if refreshToken != "" {
+		refreshClaims, err := s.parseToken(refreshToken, TokenTypeRefresh)
+		if err != nil || refreshClaims.UserID != claims.UserID {
+			return ErrInvalidToken
+		}
+
+		tokenID, err := uuid.Parse(refreshClaims.ID)
+		if err != nil {
+			return ErrInvalidToken
 		}
-		return s.jwtSecret, nil
+
+		stored, err := s.refreshTokenRepo.FindByID(tokenID)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			if err := s.refreshTokenRepo.RevokeFamily(stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.sessionService.Revoke(claims.UserID, claims.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	return s.revokedTokenRepo.Create(&models.RevokedToken{
+		ID:        tokenID,
+		UserID:    claims.UserID,
+		ExpiresAt: claims.ExpiresAt.Time,
 	})
+}
+
+// LogoutEverywhere invalide tous les tokens émis jusqu'ici pour l'utilisateur
+func (s *authService) LogoutEverywhere(userID uuid.UUID) error {
+	if err := s.userRepo.IncrementTokenGeneration(userID); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeAll(userID)
+}
+
+// validateToken vérifie un JWT et charge l'utilisateur associé.
+// Les tokens d'un compte désactivé ou d'une génération antérieure à celle de
+// l'utilisateur sont rejetés ; l'utilisateur est alors retourné avec l'erreur
+// pour le journal d'audit.
+func (s *authService) validateToken(tokenString, tokenType string) (*JWTClaims, *models.User, error) {
+	claims, err := s.parseToken(tokenString, tokenType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Vérifier que l'utilisateur existe toujours
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrInvalidToken
+	}
+	if user.DisabledAt != nil {
+		return nil, user, ErrAccountDisabled
+	}
+	if claims.Generation < user.TokenGeneration {
+		return nil, user, ErrTokenRevoked
+	}
+
+	return claims, user, nil
+}
+
+// parseToken vérifie la signature, l'expiration, l'émetteur, l'audience et
+// le type d'un JWT et retourne ses claims
+func (s *authService) parseToken(tokenString, tokenType string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, s.verificationKey,
+		jwt.WithIssuer(s.issuer), jwt.WithAudience(s.audience), jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid && claims.TokenType == tokenType {
 		return claims, nil
 	}
 
 	return nil, ErrInvalidToken
 }
 
+// verificationKey retourne la clé publique désignée par le kid du token, après
+// avoir vérifié que la méthode de signature est bien celle de la clé.
+// Les tokens HS256 sans kid ne sont acceptés que pendant une migration.
+func (s *authService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok && len(s.legacySecret) > 0 {
+			return s.legacySecret, nil
+		}
+		return nil, ErrInvalidToken
+	}
+
+	key, err := s.keyRing.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+	return key.PublicKey, nil
+}
+
+// issueTokens génère un access token et un nouveau refresh token pour la
+// session. Les rôles sont relus à chaque émission : une modification prend
+// effet au plus tard au renouvellement suivant.
+func (s *authService) issueTokens(user *models.User, session *models.Session) (string, string, error) {
+	authorizations, err := s.roleService.Authorizations(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := s.generateAccessToken(user, session.ID, authorizations)
+	if err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := s.issueRefreshToken(user, session)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// issueRefreshToken enregistre un nouveau refresh token dans la famille de la
+// session et retourne le JWT correspondant. Sa durée de vie dépend du choix
+// "se souvenir de moi" fait à la connexion.
+func (s *authService) issueRefreshToken(user *models.User, session *models.Session) (string, error) {
+	duration := s.refreshTokenDuration
+	if session.RememberMe {
+		duration = s.rememberMeDuration
+	}
+
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  session.FamilyID,
+		ExpiresAt: time.Now().Add(duration),
+	}
+	if err := s.refreshTokenRepo.Create(stored); err != nil {
+		return "", err
+	}
+
+	return s.generateToken(user, session.ID, TokenTypeRefresh, stored.ID.String(), duration)
+}
+
+// generateAccessToken génère un access token portant les rôles et les permissions
+func (s *authService) generateAccessToken(user *models.User, sessionID uuid.UUID, authorizations *Authorizations) (string, error) {
+	claims := s.newClaims(user, sessionID, TokenTypeAccess, uuid.New().String(), s.accessTokenDuration)
+	claims.Roles = authorizations.Roles
+	claims.Permissions = authorizations.Permissions
+	return s.signClaims(claims)
+}
+
 // generateToken génère un JWT avec les claims spécifiés
-func (s *authService) generateToken(userID uuid.UUID, email string, duration time.Duration) (string, error) {
+func (s *authService) generateToken(user *models.User, sessionID uuid.UUID, tokenType, tokenID string, duration time.Duration) (string, error) {
+	return s.signClaims(s.newClaims(user, sessionID, tokenType, tokenID, duration))
+}
+
+// newClaims construit les claims communs à tous les types de tokens
+func (s *authService) newClaims(user *models.User, sessionID uuid.UUID, tokenType, tokenID string, duration time.Duration) JWTClaims {
 	now := time.Now()
-	claims := JWTClaims{
-		UserID: userID,
-		Email:  email,
+	return JWTClaims{
+		UserID:     user.ID,
+		Email:      user.Email,
+		SessionID:  sessionID,
+		TokenType:  tokenType,
+		Generation: user.TokenGeneration,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{s.audience},
 			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+}
+
+// signClaims signe les claims avec la clé active du trousseau
+func (s *authService) signClaims(claims JWTClaims) (string, error) {
+	key, err := s.keyRing.SigningKey()
+	if err != nil {
+		return "", err
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	token := jwt.NewWithClaims(key.Method(), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
 }
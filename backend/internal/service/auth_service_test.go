@@ -1,14 +1,20 @@
 package service
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/password"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -43,264 +49,2200 @@ func (m *MockUserRepository) ExistsByEmail(email string) (bool, error) {
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockUserRepository) Update(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, hashedPassword string) error {
+	args := m.Called(id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConfirmEmailChange(id uuid.UUID, email string, verifiedAt time.Time) (bool, error) {
+	args := m.Called(id, email, verifiedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkDeletionRequested(id uuid.UUID, requestedAt time.Time) error {
+	args := m.Called(id, requestedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CancelDeletion(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) FindDeletionDue(requestedBefore time.Time, limit int) ([]models.User, error) {
+	args := m.Called(requestedBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Purge(id uuid.UUID, requestedBefore time.Time) (bool, error) {
+	args := m.Called(id, requestedBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) RehashPassword(id uuid.UUID, currentHash, newHash string) (bool, error) {
+	args := m.Called(id, currentHash, newHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) IncrementTokenGeneration(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(id uuid.UUID, verifiedAt time.Time) error {
+	args := m.Called(id, verifiedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ClaimUnverified(id uuid.UUID, verifiedAt time.Time) (bool, error) {
+	args := m.Called(id, verifiedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkVerificationSent(id uuid.UUID, notSentSince time.Time) (bool, error) {
+	args := m.Called(id, notSentSince)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) SetPendingTOTPSecret(id uuid.UUID, secret string) (bool, error) {
+	args := m.Called(id, secret)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) EnableTOTP(id uuid.UUID, enabledAt time.Time, step int64) (bool, error) {
+	args := m.Called(id, enabledAt, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) DisableTOTP(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkTOTPStepUsed(id uuid.UUID, step int64) (bool, error) {
+	args := m.Called(id, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) List(filter repository.UserFilter) ([]models.User, int64, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUserRepository) SetDisabled(id uuid.UUID, disabledAt *time.Time) error {
+	args := m.Called(id, disabledAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) RequirePasswordReset(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ExistsByUsername(username string, excludeID uuid.UUID) (bool, error) {
+	args := m.Called(username, excludeID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateAvatar(id uuid.UUID, key, url string) error {
+	args := m.Called(id, key, url)
+	return args.Error(0)
+}
+
+// Mock du RefreshTokenRepository
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) FindByID(id uuid.UUID) (*models.RefreshToken, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) MarkRotated(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) DeleteExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	args := m.Called(familyID)
+	return args.Error(0)
+}
+
+// Mock du RevokedTokenRepository
+type MockRevokedTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRevokedTokenRepository) Create(token *models.RevokedToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockRevokedTokenRepository) IsRevoked(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRevokedTokenRepository) DeleteExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// Mock du SessionService
+type MockSessionService struct {
+	mock.Mock
+}
+
+func (m *MockSessionService) Create(userID, familyID uuid.UUID, client ClientInfo) (*models.Session, error) {
+	args := m.Called(userID, familyID, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) Touch(familyID uuid.UUID) (*models.Session, error) {
+	args := m.Called(familyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) List(userID uuid.UUID) ([]models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionService) IsActive(sessionID uuid.UUID) (bool, error) {
+	args := m.Called(sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionService) Revoke(userID, sessionID uuid.UUID) error {
+	args := m.Called(userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) RevokeOthers(userID, currentSessionID uuid.UUID) error {
+	args := m.Called(userID, currentSessionID)
+	return args.Error(0)
+}
+
+func (m *MockSessionService) RevokeAll(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Helper function pour créer un SessionService dont toutes les sessions sont actives
+func newTestSessions() *MockSessionService {
+	sessions := new(MockSessionService)
+	sessions.On("IsActive", mock.Anything).Return(true, nil).Maybe()
+	return sessions
+}
+
+// Mock du EmailVerificationService
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(user *models.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Resend(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) Verify(token string) (*models.User, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// Mock du MFAService
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Status(userID uuid.UUID) (*MFAStatus, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*MFAStatus), args.Error(1)
+}
+
+func (m *MockMFAService) Enroll(userID uuid.UUID) (*TOTPEnrollment, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*TOTPEnrollment), args.Error(1)
+}
+
+func (m *MockMFAService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) Disable(userID uuid.UUID, password, code string) error {
+	args := m.Called(userID, password, code)
+	return args.Error(0)
+}
+
+func (m *MockMFAService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	args := m.Called(userID, code)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockMFAService) Verify(user *models.User, code string) error {
+	args := m.Called(user, code)
+	return args.Error(0)
+}
+
+// Mock du LockoutService
+type MockLockoutService struct {
+	mock.Mock
+}
+
+func (m *MockLockoutService) Check(email, ip string) error {
+	args := m.Called(email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) RecordFailure(email, ip string) error {
+	args := m.Called(email, ip)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) RecordSuccess(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) Unlock(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) PurgeStale() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockLockoutService) UnlockIP(ip string) error {
+	args := m.Called(ip)
+	return args.Error(0)
+}
+
+// Helper function pour créer un LockoutService qui accepte toutes les tentatives
+func newTestLockout() *MockLockoutService {
+	lockout := new(MockLockoutService)
+	lockout.On("Check", mock.Anything, mock.Anything).Return(nil).Maybe()
+	lockout.On("RecordFailure", mock.Anything, mock.Anything).Return(nil).Maybe()
+	lockout.On("RecordSuccess", mock.Anything).Return(nil).Maybe()
+	return lockout
+}
+
+// Mock du RoleService
+type MockRoleService struct {
+	mock.Mock
+}
+
+func (m *MockRoleService) EnsureDefaults() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockRoleService) Authorizations(userID uuid.UUID) (*Authorizations, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Authorizations), args.Error(1)
+}
+
+func (m *MockRoleService) Grant(email, role string) error {
+	args := m.Called(email, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) Revoke(email, role string) error {
+	args := m.Called(email, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleService) BootstrapAdmin(email string) error {
+	args := m.Called(email)
+	return args.Error(0)
+}
+
+// Helper function pour créer un RoleService sans aucun rôle attribué
+func newTestRoles() *MockRoleService {
+	roles := new(MockRoleService)
+	roles.On("Authorizations", mock.Anything).Return(&Authorizations{}, nil).Maybe()
+	return roles
+}
+
+// staticKeyRing est un trousseau en mémoire pour les tests du service Auth
+type staticKeyRing struct {
+	keys []*SigningKey // la première clé signe, toutes vérifient
+}
+
+// newStaticKeyRing génère un trousseau avec une clé Ed25519
+func newStaticKeyRing() *staticKeyRing {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return &staticKeyRing{keys: []*SigningKey{{ID: uuid.NewString(), Algorithm: SigningAlgorithmEdDSA, PrivateKey: private}}}
+}
+
+func (r *staticKeyRing) SigningKey() (*SigningKey, error) {
+	return r.keys[0], nil
+}
+
+func (r *staticKeyRing) VerificationKey(kid string) (*VerificationKey, error) {
+	for _, key := range r.keys {
+		if key.ID == kid {
+			return &VerificationKey{ID: key.ID, Algorithm: key.Algorithm, PublicKey: key.PrivateKey.Public()}, nil
+		}
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+func (r *staticKeyRing) VerificationKeys() ([]VerificationKey, error) {
+	keys := make([]VerificationKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, VerificationKey{ID: key.ID, Algorithm: key.Algorithm, PublicKey: key.PrivateKey.Public()})
+	}
+	return keys, nil
+}
+
+func (r *staticKeyRing) Rotate() (*models.SigningKey, error) {
+	return nil, errors.New("rotation non prise en charge")
+}
+
+// Configuration JWT utilisée par les tests
+// testPasswordPolicy est la politique de mots de passe utilisée par les tests
+var testPasswordPolicy = password.NewPolicy(password.PolicyConfig{MinLength: 8, MaxLength: 128, ForbidEmail: true})
+
+// testPasswordHasher utilise des paramètres argon2id peu coûteux
+var testPasswordHasher = password.NewHasher(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+var testAuthConfig = AuthConfig{
+	KeyRing:             newStaticKeyRing(),
+	PasswordPolicy:      testPasswordPolicy,
+	PasswordHasher:      testPasswordHasher,
+	Roles:               newTestRoles(),
+	Audit:               discardAuditLogger{},
+	Issuer:              "collec-app-test",
+	Audience:            "collec-app-test-api",
+	AccessTokenTTL:      15 * time.Minute,
+	RefreshTokenTTL:     24 * time.Hour,
+	RememberMeTTL:       720 * time.Hour,
+	MFAPendingTTL:       5 * time.Minute,
+	DeletionGracePeriod: 30 * 24 * time.Hour,
+}
+
 // Helper function pour générer un token de test
-func generateTestToken(authSvc AuthService, userID uuid.UUID, email string, duration time.Duration) (string, error) {
+func generateTestToken(authSvc AuthService, userID uuid.UUID, email, tokenType, tokenID string, duration time.Duration) (string, error) {
 	svc, ok := authSvc.(*authService)
 	if !ok {
 		return "", errors.New("invalid auth service type")
 	}
-	return svc.generateToken(userID, email, duration)
+	return svc.generateToken(&models.User{ID: userID, Email: email}, uuid.New(), tokenType, tokenID, duration)
+}
+
+// Helper function pour lire les claims d'un token émis par le service
+func parseTestToken(authSvc AuthService, token, tokenType string) (*JWTClaims, error) {
+	svc, ok := authSvc.(*authService)
+	if !ok {
+		return nil, errors.New("invalid auth service type")
+	}
+	return svc.parseToken(token, tokenType)
+}
+
+// Tests du service Auth
+
+func TestRegister_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "test@example.com"
+	password := "password123"
+
+	mockRepo.On("ExistsByEmail", email).Return(false, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	mockVerification.On("SendVerification", mock.AnythingOfType("*models.User")).Return(nil)
+
+	// Act
+	user, err := authService.Register(email, password, ClientInfo{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	assert.Equal(t, email, user.Email)
+	assert.NotEmpty(t, user.Password)
+	assert.Nil(t, user.EmailVerifiedAt)
+	mockRepo.AssertExpectations(t)
+	mockVerification.AssertExpectations(t)
+}
+
+func TestRegister_VerificationEmailFailureDoesNotFail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	mockRepo.On("ExistsByEmail", "test@example.com").Return(false, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	mockVerification.On("SendVerification", mock.AnythingOfType("*models.User")).Return(errors.New("smtp down"))
+
+	// Act
+	user, err := authService.Register("test@example.com", "password123", ClientInfo{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+}
+
+func TestRegister_EmailAlreadyExists(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "existing@example.com"
+	password := "password123"
+
+	mockRepo.On("ExistsByEmail", email).Return(true, nil)
+
+	// Act
+	user, err := authService.Register(email, password, ClientInfo{})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, ErrEmailAlreadyExists, err)
+	assert.Nil(t, user)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegister_WeakPassword(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "test@example.com"
+	weakPassword := "weak" // Moins de 8 caractères
+
+	// Act
+	user, err := authService.Register(email, weakPassword, ClientInfo{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	var policyErr *password.PolicyError
+	require.True(t, errors.As(err, &policyErr))
+	assert.Equal(t, password.RuleMinLength, policyErr.Violations[0].Rule)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "ExistsByEmail", mock.Anything)
+}
+
+func TestRegister_PasswordContainsEmail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	// Act
+	user, err := authService.Register("collector@example.com", "Collector2026!", ClientInfo{})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrWeakPassword)
+	assert.Nil(t, user)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLogin_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "test@example.com"
+	password := "password123"
+	hashedPassword, _ := testPasswordHasher.Hash(password)
+
+	existingUser := &models.User{
+		ID:       uuid.New(),
+		Email:    email,
+		Password: hashedPassword,
+	}
+
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"}
+	session := &models.Session{ID: uuid.New(), UserID: existingUser.ID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindByEmail", email).Return(existingUser, nil)
+	mockSessions.On("Create", existingUser.ID, mock.AnythingOfType("uuid.UUID"), client).Return(session, nil)
+	mockTokenRepo.On("Create", mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.UserID == existingUser.ID && token.FamilyID == session.FamilyID
+	})).Return(nil)
+
+	// Act
+	result, err := authService.Login(email, password, client)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, result.MFARequired())
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.NotNil(t, result.User)
+	assert.Equal(t, email, result.User.Email)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+
+	// L'access token porte l'identifiant de la session
+	claims, err := parseTestToken(authService, result.AccessToken, TokenTypeAccess)
+	assert.NoError(t, err)
+	assert.Equal(t, session.ID, claims.SessionID)
+}
+
+func TestLogin_RememberMeExtendsRefreshLifetime(t *testing.T) {
+	cases := []struct {
+		name       string
+		rememberMe bool
+		expected   time.Duration
+	}{
+		{"sans se souvenir de moi", false, testAuthConfig.RefreshTokenTTL},
+		{"avec se souvenir de moi", true, testAuthConfig.RememberMeTTL},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockTokenRepo := new(MockRefreshTokenRepository)
+			mockSessions := new(MockSessionService)
+			authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+			hashedPassword, _ := testPasswordHasher.Hash("password123")
+			user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword}
+			client := ClientInfo{IP: "192.0.2.10", RememberMe: tc.rememberMe}
+			session := &models.Session{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New(), RememberMe: tc.rememberMe}
+			var stored *models.RefreshToken
+
+			mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+			mockSessions.On("Create", user.ID, mock.AnythingOfType("uuid.UUID"), client).Return(session, nil)
+			mockTokenRepo.On("Create", mock.MatchedBy(func(token *models.RefreshToken) bool {
+				stored = token
+				return true
+			})).Return(nil)
+
+			result, err := authService.Login(user.Email, "password123", client)
+
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(tc.expected), stored.ExpiresAt, time.Minute)
+			claims, err := parseTestToken(authService, result.RefreshToken, TokenTypeRefresh)
+			require.NoError(t, err)
+			assert.WithinDuration(t, stored.ExpiresAt, claims.ExpiresAt.Time, time.Minute)
+			mockSessions.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogin_RecordsAuditEvents(t *testing.T) {
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword}
+	mfaUser := &models.User{ID: uuid.New(), Email: "mfa@example.com", Password: hashedPassword, TOTPEnabledAt: &enabledAt}
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"}
+
+	cases := []struct {
+		name            string
+		email, password string
+		expectedUserID  uuid.UUID
+		expectedOutcome string
+		expectedErr     error
+	}{
+		{"email inconnu", "unknown@example.com", "password123", uuid.Nil, AuditOutcomeFailure, ErrInvalidCredentials},
+		{"mot de passe erroné", user.Email, "wrong", user.ID, AuditOutcomeFailure, ErrInvalidCredentials},
+		{"second facteur attendu", mfaUser.Email, "password123", mfaUser.ID, AuditOutcomeMFARequired, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			audit := &recordingAuditLogger{}
+			config := testAuthConfig
+			config.Audit = audit
+			authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), config)
+
+			mockRepo.On("FindByEmail", "unknown@example.com").Return(nil, nil)
+			mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+			mockRepo.On("FindByEmail", mfaUser.Email).Return(mfaUser, nil)
+
+			_, err := authService.Login(tc.email, tc.password, client)
+
+			assert.Equal(t, tc.expectedErr, err)
+			require.Len(t, audit.entries, 1)
+			entry := audit.entries[0]
+			assert.Equal(t, AuditEventLogin, entry.Event)
+			assert.Equal(t, tc.email, entry.Email)
+			assert.Equal(t, tc.expectedUserID, entry.UserID)
+			assert.Equal(t, client, entry.Client)
+			assert.Equal(t, tc.expectedErr, entry.Err)
+			if tc.expectedOutcome == AuditOutcomeMFARequired {
+				assert.Equal(t, AuditOutcomeMFARequired, entry.Outcome)
+			}
+		})
+	}
+}
+
+func TestLogin_UnverifiedEmailRefusedWhenRequired(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	cfg := testAuthConfig
+	cfg.RequireVerifiedEmail = true
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, cfg)
+
+	email := "test@example.com"
+	password := "password123"
+	hashedPassword, _ := testPasswordHasher.Hash(password)
+
+	mockRepo.On("FindByEmail", email).Return(&models.User{ID: uuid.New(), Email: email, Password: hashedPassword}, nil)
+
+	// Act
+	result, err := authService.Login(email, password, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrEmailNotVerified, err)
+	assert.Nil(t, result)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_UnverifiedEmailWrongPasswordStillInvalidCredentials(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	cfg := testAuthConfig
+	cfg.RequireVerifiedEmail = true
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, cfg)
+
+	email := "test@example.com"
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+
+	mockRepo.On("FindByEmail", email).Return(&models.User{ID: uuid.New(), Email: email, Password: hashedPassword}, nil)
+
+	// Act
+	_, err := authService.Login(email, "wrongpassword", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidCredentials, err)
+}
+
+func TestLogin_MFAEnabledReturnsPendingToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "test@example.com"
+	password := "password123"
+	hashedPassword, _ := testPasswordHasher.Hash(password)
+	enabledAt := time.Now()
+
+	existingUser := &models.User{ID: uuid.New(), Email: email, Password: hashedPassword, TOTPEnabledAt: &enabledAt}
+	mockRepo.On("FindByEmail", email).Return(existingUser, nil)
+
+	// Act
+	result, err := authService.Login(email, password, ClientInfo{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	assert.Empty(t, result.AccessToken)
+	assert.Empty(t, result.RefreshToken)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+
+	// Le token "mfa pending" n'est pas utilisable comme access token
+	_, err = parseTestToken(authService, result.MFAToken, TokenTypeAccess)
+	assert.Equal(t, ErrInvalidToken, err)
+	claims, err := parseTestToken(authService, result.MFAToken, TokenTypeMFAPending)
+	assert.NoError(t, err)
+	assert.Equal(t, existingUser.ID, claims.UserID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestCompleteMFALogin_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", TOTPEnabledAt: &enabledAt}
+	tokenID := uuid.New()
+	mfaToken, _ := generateTestToken(authService, user.ID, user.Email, TokenTypeMFAPending, tokenID.String(), 5*time.Minute)
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"}
+	session := &models.Session{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(false, nil)
+	mockMFA.On("Verify", user, "123456").Return(nil)
+	mockRevokedRepo.On("Create", mock.MatchedBy(func(revoked *models.RevokedToken) bool {
+		return revoked.ID == tokenID && revoked.UserID == user.ID
+	})).Return(nil)
+	mockSessions.On("Create", user.ID, mock.AnythingOfType("uuid.UUID"), client).Return(session, nil)
+	mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+	// Act
+	result, err := authService.CompleteMFALogin(mfaToken, "123456", client)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.Equal(t, user, result.User)
+	mockMFA.AssertExpectations(t)
+	mockRevokedRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestCompleteMFALogin_KeepsRememberMe(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockMFA := new(MockMFAService)
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, new(MockEmailVerificationService), mockMFA, newTestLockout(), testAuthConfig)
+
+	enabledAt := time.Now()
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, TOTPEnabledAt: &enabledAt}
+	session := &models.Session{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New(), RememberMe: true}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("uuid.UUID")).Return(false, nil)
+	mockMFA.On("Verify", user, "123456").Return(nil)
+	mockRevokedRepo.On("Create", mock.AnythingOfType("*models.RevokedToken")).Return(nil)
+	mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+
+	// Le choix est fait à la première étape, la seconde ne le renseigne pas
+	pending, err := authService.Login(user.Email, "password123", ClientInfo{IP: "192.0.2.10", RememberMe: true})
+	require.NoError(t, err)
+	require.True(t, pending.MFARequired())
+	mockSessions.On("Create", user.ID, mock.AnythingOfType("uuid.UUID"), ClientInfo{IP: "192.0.2.10", RememberMe: true}).Return(session, nil)
+
+	// Act
+	_, err = authService.CompleteMFALogin(pending.MFAToken, "123456", ClientInfo{IP: "192.0.2.10"})
+
+	// Assert
+	require.NoError(t, err)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestCompleteMFALogin_InvalidCode(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", TOTPEnabledAt: &enabledAt}
+	tokenID := uuid.New()
+	mfaToken, _ := generateTestToken(authService, user.ID, user.Email, TokenTypeMFAPending, tokenID.String(), 5*time.Minute)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(false, nil)
+	mockMFA.On("Verify", user, "000000").Return(ErrInvalidMFACode)
+
+	// Act
+	result, err := authService.CompleteMFALogin(mfaToken, "000000", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidMFACode, err)
+	assert.Nil(t, result)
+	mockLockout.AssertCalled(t, "RecordFailure", user.Email, "")
+	mockRevokedRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCompleteMFALogin_PendingTokenAlreadyUsed(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", TOTPEnabledAt: &enabledAt}
+	tokenID := uuid.New()
+	mfaToken, _ := generateTestToken(authService, user.ID, user.Email, TokenTypeMFAPending, tokenID.String(), 5*time.Minute)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(true, nil)
+
+	// Act
+	result, err := authService.CompleteMFALogin(mfaToken, "123456", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, result)
+	mockMFA.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything)
+}
+
+func TestCompleteMFALogin_RejectsAccessToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	accessToken, _ := generateTestToken(authService, uuid.New(), "test@example.com", TokenTypeAccess, uuid.New().String(), time.Hour)
+
+	// Act
+	result, err := authService.CompleteMFALogin(accessToken, "123456", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, result)
+	mockMFA.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything)
+}
+
+func TestLogin_InvalidCredentials_UserNotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "nonexistent@example.com"
+	password := "password123"
+
+	mockRepo.On("FindByEmail", email).Return(nil, nil)
+
+	// Act
+	result, err := authService.Login(email, password, ClientInfo{})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+	mockLockout.AssertCalled(t, "RecordFailure", email, "")
+}
+
+func TestLogin_InvalidCredentials_WrongPassword(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	email := "test@example.com"
+	password := "password123"
+	wrongPassword := "wrongpassword"
+	hashedPassword, _ := testPasswordHasher.Hash(password)
+
+	existingUser := &models.User{
+		ID:       uuid.New(),
+		Email:    email,
+		Password: hashedPassword,
+	}
+
+	mockRepo.On("FindByEmail", email).Return(existingUser, nil)
+
+	// Act
+	result, err := authService.Login(email, wrongPassword, ClientInfo{IP: "192.0.2.10"})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestValidateAccessToken_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	email := "test@example.com"
+	tokenID := uuid.New()
+
+	// Générer un token valide
+	token, err := generateTestToken(authService, userID, email, TokenTypeAccess, tokenID.String(), 15*time.Minute)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, Email: email}, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(false, nil)
+	mockSessions.On("IsActive", mock.Anything).Return(true, nil)
+
+	// Act
+	claims, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, claims)
+	assert.Equal(t, userID, claims.UserID)
+	assert.Equal(t, email, claims.Email)
+	assert.Equal(t, tokenID.String(), claims.ID)
+}
+
+func TestValidateAccessToken_RevokedToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	tokenID := uuid.New()
+
+	token, err := generateTestToken(authService, userID, "test@example.com", TokenTypeAccess, tokenID.String(), 15*time.Minute)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(true, nil)
+
+	// Act
+	claims, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Nil(t, claims)
+}
+
+func TestValidateAccessToken_RevokedSessionRejected(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	tokenID := uuid.New()
+
+	// La session a été révoquée depuis un autre appareil ou par un changement de mot de passe
+	token, err := generateTestToken(authService, userID, "test@example.com", TokenTypeAccess, tokenID.String(), 15*time.Minute)
+	assert.NoError(t, err)
+	claims, err := parseTestToken(authService, token, TokenTypeAccess)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockRevokedRepo.On("IsRevoked", tokenID).Return(false, nil)
+	mockSessions.On("IsActive", claims.SessionID).Return(false, nil)
+
+	// Act
+	validated, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Nil(t, validated)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestValidateAccessToken_OlderGenerationRejected(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+
+	// Token émis en génération 0, puis l'utilisateur s'est déconnecté partout
+	token, err := generateTestToken(authService, userID, "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, TokenGeneration: 1}, nil)
+
+	// Act
+	claims, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.Equal(t, ErrTokenRevoked, err)
+	assert.Nil(t, claims)
+	mockRevokedRepo.AssertNotCalled(t, "IsRevoked", mock.Anything)
+}
+
+func TestValidateAccessToken_InvalidToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	invalidToken := "invalid.token.here"
+
+	// Act
+	claims, err := authService.ValidateAccessToken(invalidToken)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, claims)
+}
+
+func TestValidateAccessToken_RejectsRefreshToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, uuid.New().String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	// Act
+	claims, err := authService.ValidateAccessToken(refreshToken)
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, claims)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestValidateAccessToken_WrongIssuerOrAudience(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	otherIssuer := testAuthConfig
+	otherIssuer.Issuer = "another-service"
+	otherAudience := testAuthConfig
+	otherAudience.Audience = "another-api"
+
+	for name, cfg := range map[string]AuthConfig{"issuer": otherIssuer, "audience": otherAudience} {
+		t.Run(name, func(t *testing.T) {
+			// Même clé, mais émis pour un autre émetteur / une autre audience
+			foreign := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, cfg)
+			token, err := generateTestToken(foreign, uuid.New(), "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+			assert.NoError(t, err)
+
+			// Act
+			claims, err := authService.ValidateAccessToken(token)
+
+			// Assert
+			assert.Equal(t, ErrInvalidToken, err)
+			assert.Nil(t, claims)
+		})
+	}
+}
+
+func TestRefreshToken_RejectsAccessToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	accessToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+	assert.NoError(t, err)
+
+	// Act
+	newAccessToken, newRefreshToken, err := authService.RefreshToken(accessToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Empty(t, newAccessToken)
+	assert.Empty(t, newRefreshToken)
+	mockTokenRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestRefreshToken_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	email := "test@example.com"
+
+	existingUser := &models.User{
+		ID:    userID,
+		Email: email,
+	}
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(168 * time.Hour),
+	}
+
+	// Générer un refresh token valide
+	refreshToken, err := generateTestToken(authService, userID, email, TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	session := &models.Session{ID: uuid.New(), UserID: userID, FamilyID: stored.FamilyID}
+
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockRepo.On("FindByID", userID).Return(existingUser, nil)
+	mockSessions.On("Touch", stored.FamilyID).Return(session, nil)
+	mockTokenRepo.On("MarkRotated", stored.ID).Return(true, nil)
+	mockTokenRepo.On("Create", mock.MatchedBy(func(token *models.RefreshToken) bool {
+		return token.FamilyID == stored.FamilyID && token.ID != stored.ID
+	})).Return(nil)
+
+	// Act
+	newAccessToken, newRefreshToken, err := authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, newAccessToken)
+	assert.NotEmpty(t, newRefreshToken)
+	assert.NotEqual(t, refreshToken, newRefreshToken)
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestRefreshToken_RecordsAuditEvent(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	audit := &recordingAuditLogger{}
+	config := testAuthConfig
+	config.Audit = audit
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), config)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", TokenGeneration: 1}
+	stored := &models.RefreshToken{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New()}
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"}
+
+	// Token émis avant une déconnexion partout
+	refreshToken, err := generateTestToken(authService, user.ID, user.Email, TokenTypeRefresh, stored.ID.String(), 24*time.Hour)
+	require.NoError(t, err)
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, client)
+
+	// Assert
+	assert.Equal(t, ErrTokenRevoked, err)
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, AuditEntry{
+		Event:  AuditEventTokenRefresh,
+		UserID: user.ID,
+		Email:  user.Email,
+		Client: client,
+		Err:    ErrTokenRevoked,
+	}, audit.entries[0])
+}
+
+func TestRefreshToken_RevokedSession(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	stored := &models.RefreshToken{
+		ID:       uuid.New(),
+		UserID:   userID,
+		FamilyID: uuid.New(),
+	}
+
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockSessions.On("Touch", stored.FamilyID).Return(nil, ErrSessionRevoked)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	mockTokenRepo.AssertNotCalled(t, "MarkRotated", mock.Anything)
+}
+
+func TestRefreshToken_IdleSession(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	userID := uuid.New()
+	stored := &models.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New()}
+
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 24*time.Hour)
+	require.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockSessions.On("Touch", stored.FamilyID).Return(nil, ErrSessionIdle)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	mockTokenRepo.AssertNotCalled(t, "MarkRotated", mock.Anything)
+}
+
+func TestRefreshToken_UserNotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	email := "test@example.com"
+	stored := &models.RefreshToken{
+		ID:       uuid.New(),
+		UserID:   userID,
+		FamilyID: uuid.New(),
+	}
+
+	// Générer un refresh token valide
+	refreshToken, err := generateTestToken(authService, userID, email, TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(nil, nil)
+
+	// Act
+	newAccessToken, newRefreshToken, err := authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Empty(t, newAccessToken)
+	assert.Empty(t, newRefreshToken)
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertNotCalled(t, "FindByID", stored.ID)
+}
+
+func TestRefreshToken_UnknownToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+
+	// Un JWT bien signé mais sans jti ne correspond à aucun token stocké
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, "", 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	mockTokenRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestRefreshToken_ReusedTokenRevokesFamily(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	rotatedAt := time.Now().Add(-time.Minute)
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  uuid.New(),
+		RotatedAt: &rotatedAt,
+	}
+
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockTokenRepo.On("RevokeFamily", stored.FamilyID).Return(nil)
+
+	// Act
+	newAccessToken, newRefreshToken, err := authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrTokenReused, err)
+	assert.Empty(t, newAccessToken)
+	assert.Empty(t, newRefreshToken)
+	mockTokenRepo.AssertExpectations(t)
+	mockTokenRepo.AssertNotCalled(t, "MarkRotated", mock.Anything)
+}
+
+func TestRefreshToken_ConcurrentRotationRevokesFamily(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	stored := &models.RefreshToken{
+		ID:       uuid.New(),
+		UserID:   userID,
+		FamilyID: uuid.New(),
+	}
+
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockSessions.On("Touch", stored.FamilyID).Return(&models.Session{ID: uuid.New(), FamilyID: stored.FamilyID}, nil)
+	// Une autre requête a consommé le token entre la lecture et la mise à jour
+	mockTokenRepo.On("MarkRotated", stored.ID).Return(false, nil)
+	mockTokenRepo.On("RevokeFamily", stored.FamilyID).Return(nil)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrTokenReused, err)
+	mockTokenRepo.AssertExpectations(t)
+	mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestRefreshToken_RevokedToken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  uuid.New(),
+		RevokedAt: &revokedAt,
+	}
+
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	mockTokenRepo.AssertExpectations(t)
+	mockTokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything)
 }
 
-// Tests du service Auth
+func TestRefreshToken_AfterLogoutEverywhere(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
 
-func TestRegister_Success(t *testing.T) {
+	userID := uuid.New()
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, uuid.New().String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, TokenGeneration: 1}, nil)
+
+	// Act
+	_, _, err = authService.RefreshToken(refreshToken, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrTokenRevoked, err)
+	mockTokenRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestLogout_RevokesAccessTokenAndRefreshFamily(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
 
-	email := "test@example.com"
-	password := "password123"
+	userID := uuid.New()
+	accessID := uuid.New()
+	stored := &models.RefreshToken{
+		ID:       uuid.New(),
+		UserID:   userID,
+		FamilyID: uuid.New(),
+	}
+	expiresAt := time.Now().Add(15 * time.Minute)
+	claims := &JWTClaims{
+		UserID:    userID,
+		SessionID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        accessID.String(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
 
-	mockRepo.On("ExistsByEmail", email).Return(false, nil)
-	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, stored.ID.String(), 168*time.Hour)
+	assert.NoError(t, err)
+
+	mockTokenRepo.On("FindByID", stored.ID).Return(stored, nil)
+	mockTokenRepo.On("RevokeFamily", stored.FamilyID).Return(nil)
+	mockSessions.On("Revoke", userID, claims.SessionID).Return(nil)
+	mockRevokedRepo.On("Create", mock.MatchedBy(func(token *models.RevokedToken) bool {
+		return token.ID == accessID && token.UserID == userID && token.ExpiresAt.Unix() == expiresAt.Unix()
+	})).Return(nil)
 
 	// Act
-	user, err := authService.Register(email, password)
+	err = authService.Logout(claims, refreshToken)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotNil(t, user)
-	assert.Equal(t, email, user.Email)
-	assert.NotEmpty(t, user.Password)
-	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+	mockRevokedRepo.AssertExpectations(t)
 }
 
-func TestRegister_EmailAlreadyExists(t *testing.T) {
+func TestLogout_RefreshTokenOfAnotherUser(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	claims := &JWTClaims{
+		UserID: uuid.New(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		},
+	}
+	otherRefreshToken, err := generateTestToken(authService, uuid.New(), "other@example.com", TokenTypeRefresh, uuid.New().String(), 168*time.Hour)
+	assert.NoError(t, err)
 
-	email := "existing@example.com"
-	password := "password123"
+	// Act
+	err = authService.Logout(claims, otherRefreshToken)
 
-	mockRepo.On("ExistsByEmail", email).Return(true, nil)
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	mockTokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything)
+	mockRevokedRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLogoutEverywhere(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
+
+	userID := uuid.New()
+	mockRepo.On("IncrementTokenGeneration", userID).Return(nil)
+	mockSessions.On("RevokeAll", userID).Return(nil)
 
 	// Act
-	user, err := authService.Register(email, password)
+	err := authService.LogoutEverywhere(userID)
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, ErrEmailAlreadyExists, err)
-	assert.Nil(t, user)
+	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
 }
 
-func TestRegister_WeakPassword(t *testing.T) {
+func TestRegister_RepositoryError(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockVerification := new(MockEmailVerificationService)
+	mockMFA := new(MockMFAService)
+	mockLockout := newTestLockout()
+	authService := NewAuthService(mockRepo, mockTokenRepo, mockRevokedRepo, mockSessions, mockVerification, mockMFA, mockLockout, testAuthConfig)
 
 	email := "test@example.com"
-	password := "weak" // Moins de 8 caractères
+	password := "password123"
+	dbError := errors.New("database error")
+
+	mockRepo.On("ExistsByEmail", email).Return(false, dbError)
 
 	// Act
-	user, err := authService.Register(email, password)
+	user, err := authService.Register(email, password, ClientInfo{})
 
 	// Assert
 	assert.Error(t, err)
-	assert.Equal(t, ErrWeakPassword, err)
+	assert.Equal(t, dbError, err)
 	assert.Nil(t, user)
+	mockRepo.AssertExpectations(t)
 }
 
-func TestLogin_Success(t *testing.T) {
+func TestGenerateToken_SetsKeyID(t *testing.T) {
+	// Arrange
+	authService := NewAuthService(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+	signingKey, _ := testAuthConfig.KeyRing.SigningKey()
+
+	// Act
+	token, err := generateTestToken(authService, uuid.New(), "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+
+	// Assert
+	assert.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JWTClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, signingKey.ID, parsed.Header["kid"])
+	assert.Equal(t, SigningAlgorithmEdDSA, parsed.Header["alg"])
+}
+
+func TestValidateAccessToken_RotatedKeyStillVerifies(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	oldRing := newStaticKeyRing()
+	newRing := newStaticKeyRing()
+	newRing.keys = append(newRing.keys, oldRing.keys[0]) // l'ancienne clé est en période de recouvrement
 
-	email := "test@example.com"
-	password := "password123"
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	oldConfig := testAuthConfig
+	oldConfig.KeyRing = oldRing
+	rotatedConfig := testAuthConfig
+	rotatedConfig.KeyRing = newRing
 
-	existingUser := &models.User{
-		ID:       uuid.New(),
-		Email:    email,
-		Password: string(hashedPassword),
-	}
+	before := NewAuthService(mockRepo, new(MockRefreshTokenRepository), mockRevokedRepo, newTestSessions(), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), oldConfig)
+	after := NewAuthService(mockRepo, new(MockRefreshTokenRepository), mockRevokedRepo, newTestSessions(), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), rotatedConfig)
 
-	mockRepo.On("FindByEmail", email).Return(existingUser, nil)
+	userID := uuid.New()
+	token, _ := generateTestToken(before, userID, "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+	mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("uuid.UUID")).Return(false, nil)
 
 	// Act
-	accessToken, refreshToken, user, err := authService.Login(email, password)
+	claims, err := after.ValidateAccessToken(token)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotEmpty(t, accessToken)
-	assert.NotEmpty(t, refreshToken)
-	assert.NotNil(t, user)
-	assert.Equal(t, email, user.Email)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, userID, claims.UserID)
 }
 
-func TestLogin_InvalidCredentials_UserNotFound(t *testing.T) {
+func TestValidateAccessToken_UnknownKeyID(t *testing.T) {
+	// Arrange
+	authService := NewAuthService(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	otherConfig := testAuthConfig
+	otherConfig.KeyRing = newStaticKeyRing()
+	other := NewAuthService(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), otherConfig)
+	token, _ := generateTestToken(other, uuid.New(), "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+
+	// Act
+	claims, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, claims)
+}
+
+func TestValidateAccessToken_AlgorithmMismatch(t *testing.T) {
+	// Arrange
+	authService := NewAuthService(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+	signingKey, _ := testAuthConfig.KeyRing.SigningKey()
+
+	// Token HS256 signé avec la clé publique, annoncé avec le kid d'une clé EdDSA
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
+		UserID:    uuid.New(),
+		TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    testAuthConfig.Issuer,
+			Audience:  jwt.ClaimStrings{testAuthConfig.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		},
+	})
+	token.Header["kid"] = signingKey.ID
+	signed, _ := token.SignedString([]byte(signingKey.PrivateKey.Public().(ed25519.PublicKey)))
+
+	// Act
+	claims, err := authService.ValidateAccessToken(signed)
+
+	// Assert
+	assert.Equal(t, ErrInvalidToken, err)
+	assert.Nil(t, claims)
+}
+
+func TestValidateAccessToken_LegacyHS256(t *testing.T) {
+	userID := uuid.New()
+	legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, JWTClaims{
+		UserID:    userID,
+		TokenType: TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Issuer:    testAuthConfig.Issuer,
+			Audience:  jwt.ClaimStrings{testAuthConfig.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
+		},
+	})
+	signed, _ := legacy.SignedString([]byte("legacy-secret"))
+
+	for name, legacySecret := range map[string]string{"refusé par défaut": "", "accepté pendant la migration": "legacy-secret"} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(MockUserRepository)
+			mockRevokedRepo := new(MockRevokedTokenRepository)
+			cfg := testAuthConfig
+			cfg.LegacySecret = legacySecret
+			authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), mockRevokedRepo, newTestSessions(), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), cfg)
+
+			mockRepo.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+			mockRevokedRepo.On("IsRevoked", mock.AnythingOfType("uuid.UUID")).Return(false, nil)
+
+			// Act
+			claims, err := authService.ValidateAccessToken(signed)
+
+			// Assert
+			if legacySecret == "" {
+				assert.Equal(t, ErrInvalidToken, err)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, userID, claims.UserID)
+			}
+		})
+	}
+}
+
+func TestLogin_LockedAccount(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockLockout := new(MockLockoutService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), mockLockout, testAuthConfig)
 
-	email := "nonexistent@example.com"
-	password := "password123"
+	locked := &ThrottleError{Err: ErrAccountLocked, RetryAfter: 10 * time.Minute}
+	mockLockout.On("Check", "test@example.com", "192.0.2.10").Return(locked)
 
-	mockRepo.On("FindByEmail", email).Return(nil, nil)
+	// Act
+	result, err := authService.Login("test@example.com", "password123", ClientInfo{IP: "192.0.2.10"})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	mockLockout.AssertNotCalled(t, "RecordFailure", mock.Anything, mock.Anything)
+}
+
+func TestLogin_RehashesLegacyBcryptHash(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: string(legacyHash), TOTPEnabledAt: &enabledAt}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockRepo.On("RehashPassword", user.ID, string(legacyHash), mock.MatchedBy(func(hash string) bool {
+		valid, err := testPasswordHasher.Verify("password123", hash)
+		return err == nil && valid && !testPasswordHasher.NeedsRehash(hash)
+	})).Return(true, nil)
 
 	// Act
-	accessToken, refreshToken, user, err := authService.Login(email, password)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, ErrInvalidCredentials, err)
-	assert.Empty(t, accessToken)
-	assert.Empty(t, refreshToken)
-	assert.Nil(t, user)
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
 	mockRepo.AssertExpectations(t)
 }
 
-func TestLogin_InvalidCredentials_WrongPassword(t *testing.T) {
+func TestLogin_CurrentHashIsNotRehashed(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
 
-	email := "test@example.com"
-	password := "password123"
-	wrongPassword := "wrongpassword"
-	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, TOTPEnabledAt: &enabledAt}
 
-	existingUser := &models.User{
-		ID:       uuid.New(),
-		Email:    email,
-		Password: string(hashedPassword),
-	}
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
 
-	mockRepo.On("FindByEmail", email).Return(existingUser, nil)
+	// Act
+	_, err := authService.Login(user.Email, "password123", ClientInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "RehashPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_RehashFailureDoesNotBlockLogin(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	legacyHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: string(legacyHash), TOTPEnabledAt: &enabledAt}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockRepo.On("RehashPassword", user.ID, string(legacyHash), mock.AnythingOfType("string")).Return(false, errors.New("db error"))
 
 	// Act
-	accessToken, refreshToken, user, err := authService.Login(email, wrongPassword)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
+}
+
+func TestLogin_WrongPasswordRecordsFailure(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockLockout := new(MockLockoutService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), mockLockout, testAuthConfig)
+
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword}
+	client := ClientInfo{IP: "192.0.2.10"}
+
+	mockLockout.On("Check", user.Email, client.IP).Return(nil)
+	mockLockout.On("RecordFailure", user.Email, client.IP).Return(nil)
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+
+	// Act
+	result, err := authService.Login(user.Email, "wrongpassword", client)
 
 	// Assert
-	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidCredentials, err)
-	assert.Empty(t, accessToken)
-	assert.Empty(t, refreshToken)
-	assert.Nil(t, user)
-	mockRepo.AssertExpectations(t)
+	assert.Nil(t, result)
+	mockLockout.AssertExpectations(t)
+	mockLockout.AssertNotCalled(t, "RecordSuccess", mock.Anything)
 }
 
-func TestValidateToken_Success(t *testing.T) {
+func TestLogin_MFAPendingDoesNotResetFailures(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockLockout := new(MockLockoutService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), mockLockout, testAuthConfig)
 
-	userID := uuid.New()
-	email := "test@example.com"
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, TOTPEnabledAt: &enabledAt}
 
-	// Générer un token valide
-	token, err := generateTestToken(authService, userID, email, 15*time.Minute)
+	mockLockout.On("Check", user.Email, "").Return(nil)
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+
+	// Act
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
+
+	// Assert : seuls les échecs du second facteur restent comptabilisés
 	assert.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	mockLockout.AssertNotCalled(t, "RecordSuccess", mock.Anything)
+}
+
+func TestLogin_DuringGracePeriodCancelsDeletion(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	requestedAt := time.Now().Add(-24 * time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, DeletionRequestedAt: &requestedAt}
+	session := &models.Session{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockRepo.On("CancelDeletion", user.ID).Return(nil)
+	mockSessions.On("Create", user.ID, mock.AnythingOfType("uuid.UUID"), ClientInfo{}).Return(session, nil)
+	mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
 
 	// Act
-	claims, err := authService.ValidateToken(token)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
 	// Assert
-	assert.NoError(t, err)
-	assert.NotNil(t, claims)
-	assert.Equal(t, userID, claims.UserID)
-	assert.Equal(t, email, claims.Email)
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.Nil(t, user.DeletionRequestedAt)
+	mockRepo.AssertExpectations(t)
 }
 
-func TestValidateToken_InvalidToken(t *testing.T) {
+func TestLogin_MFAPendingDoesNotCancelDeletion(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
 
-	invalidToken := "invalid.token.here"
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	requestedAt := time.Now().Add(-24 * time.Hour)
+	enabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, TOTPEnabledAt: &enabledAt, DeletionRequestedAt: &requestedAt}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
 
 	// Act
-	claims, err := authService.ValidateToken(invalidToken)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, ErrInvalidToken, err)
-	assert.Nil(t, claims)
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	mockRepo.AssertNotCalled(t, "CancelDeletion", mock.Anything)
 }
 
-func TestRefreshToken_Success(t *testing.T) {
+func TestLogin_AfterGracePeriodIsRefused(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
 
-	userID := uuid.New()
-	email := "test@example.com"
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	requestedAt := time.Now().Add(-testAuthConfig.DeletionGracePeriod - time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, DeletionRequestedAt: &requestedAt}
 
-	existingUser := &models.User{
-		ID:    userID,
-		Email: email,
-	}
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
 
-	// Générer un refresh token valide
-	refreshToken, err := generateTestToken(authService, userID, email, 168*time.Hour)
-	assert.NoError(t, err)
+	// Act
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
-	mockRepo.On("FindByID", userID).Return(existingUser, nil)
+	// Assert
+	assert.Nil(t, result)
+	assert.Equal(t, ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "CancelDeletion", mock.Anything)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_AccessTokenCarriesRolesAndPermissions(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionService)
+	mockRoles := new(MockRoleService)
+	config := testAuthConfig
+	config.Roles = mockRoles
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), config)
+
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{ID: uuid.New(), Email: "admin@example.com", Password: hashedPassword}
+	session := &models.Session{ID: uuid.New(), UserID: user.ID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockSessions.On("Create", user.ID, mock.AnythingOfType("uuid.UUID"), ClientInfo{}).Return(session, nil)
+	mockTokenRepo.On("Create", mock.AnythingOfType("*models.RefreshToken")).Return(nil)
+	mockRoles.On("Authorizations", user.ID).Return(&Authorizations{
+		Roles:       []string{RoleAdmin},
+		Permissions: []string{PermissionUsersRead, PermissionUsersWrite},
+	}, nil)
 
 	// Act
-	newAccessToken, err := authService.RefreshToken(refreshToken)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
 	// Assert
-	assert.NoError(t, err)
-	assert.NotEmpty(t, newAccessToken)
-	mockRepo.AssertExpectations(t)
+	require.NoError(t, err)
+	claims, err := parseTestToken(authService, result.AccessToken, TokenTypeAccess)
+	require.NoError(t, err)
+	assert.True(t, claims.HasRole(RoleAdmin))
+	assert.True(t, claims.HasPermission(PermissionUsersWrite))
+	assert.False(t, claims.HasPermission(PermissionRolesWrite))
+
+	// Le refresh token ne porte pas les autorisations, relues à chaque renouvellement
+	refreshClaims, err := parseTestToken(authService, result.RefreshToken, TokenTypeRefresh)
+	require.NoError(t, err)
+	assert.Empty(t, refreshClaims.Roles)
 }
 
-func TestRefreshToken_UserNotFound(t *testing.T) {
+func TestValidateAccessToken_DisabledAccountRejected(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockRevokedRepo := new(MockRevokedTokenRepository)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), mockRevokedRepo, new(MockSessionService), new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
 
 	userID := uuid.New()
-	email := "test@example.com"
+	token, err := generateTestToken(authService, userID, "test@example.com", TokenTypeAccess, uuid.New().String(), 15*time.Minute)
+	require.NoError(t, err)
 
-	// Générer un refresh token valide
-	refreshToken, err := generateTestToken(authService, userID, email, 168*time.Hour)
-	assert.NoError(t, err)
+	// Le compte a été désactivé après l'émission du token
+	disabledAt := time.Now()
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, DisabledAt: &disabledAt}, nil)
 
-	mockRepo.On("FindByID", userID).Return(nil, nil)
+	// Act
+	claims, err := authService.ValidateAccessToken(token)
+
+	// Assert
+	assert.Equal(t, ErrAccountDisabled, err)
+	assert.Nil(t, claims)
+	mockRevokedRepo.AssertNotCalled(t, "IsRevoked", mock.Anything)
+}
+
+func TestRefreshToken_DisabledAccountRejected(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, mockTokenRepo, new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	userID := uuid.New()
+	refreshToken, err := generateTestToken(authService, userID, "test@example.com", TokenTypeRefresh, uuid.New().String(), 168*time.Hour)
+	require.NoError(t, err)
+
+	disabledAt := time.Now()
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, DisabledAt: &disabledAt}, nil)
 
 	// Act
-	newAccessToken, err := authService.RefreshToken(refreshToken)
+	accessToken, newRefreshToken, err := authService.RefreshToken(refreshToken, ClientInfo{})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, ErrInvalidToken, err)
-	assert.Empty(t, newAccessToken)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, ErrAccountDisabled, err)
+	assert.Empty(t, accessToken)
+	assert.Empty(t, newRefreshToken)
+	mockTokenRepo.AssertNotCalled(t, "MarkRotated", mock.Anything)
+	mockSessions.AssertNotCalled(t, "Touch", mock.Anything)
 }
 
-func TestRegister_RepositoryError(t *testing.T) {
+func TestLogin_DisabledAccountRefused(t *testing.T) {
 	// Arrange
 	mockRepo := new(MockUserRepository)
-	authService := NewAuthService(mockRepo, "test-secret-key", 15*time.Minute, 168*time.Hour)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
 
-	email := "test@example.com"
-	password := "password123"
-	dbError := errors.New("database error")
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	disabledAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, DisabledAt: &disabledAt}
 
-	mockRepo.On("ExistsByEmail", email).Return(false, dbError)
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
 
 	// Act
-	user, err := authService.Register(email, password)
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
 
 	// Assert
-	assert.Error(t, err)
-	assert.Equal(t, dbError, err)
-	assert.Nil(t, user)
-	mockRepo.AssertExpectations(t)
+	assert.Nil(t, result)
+	assert.Equal(t, ErrAccountDisabled, err)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogin_PasswordResetRequired(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(mockRepo, new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, PasswordResetRequired: true}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+
+	// Act
+	result, err := authService.Login(user.Email, "password123", ClientInfo{})
+
+	// Assert
+	assert.Nil(t, result)
+	assert.Equal(t, ErrPasswordResetRequired, err)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLoginWithUser_PasswordResetRequired(t *testing.T) {
+	// Arrange : lien de connexion ou fournisseur externe, sans mot de passe
+	mockSessions := new(MockSessionService)
+	authService := NewAuthService(new(MockUserRepository), new(MockRefreshTokenRepository), new(MockRevokedTokenRepository), mockSessions, new(MockEmailVerificationService), new(MockMFAService), newTestLockout(), testAuthConfig)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", PasswordResetRequired: true}
+
+	// Act
+	result, err := authService.LoginWithUser(user, ClientInfo{})
+
+	// Assert
+	assert.Nil(t, result)
+	assert.Equal(t, ErrPasswordResetRequired, err)
+	mockSessions.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
 }
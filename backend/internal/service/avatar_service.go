@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/arnaud-dars/collec-app/internal/avatar"
+	"github.com/arnaud-dars/collec-app/internal/blobstore"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAvatarTooLarge = errors.New("image trop volumineuse")
+	ErrInvalidAvatar  = errors.New("image invalide ou format non supporté")
+)
+
+// AvatarService définit l'interface de gestion de l'avatar de l'utilisateur connecté
+type AvatarService interface {
+	Upload(ctx context.Context, userID uuid.UUID, image io.Reader) (*models.User, error)
+	Remove(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	DeleteFiles(ctx context.Context, avatarKey string)
+}
+
+// avatarService implémente AvatarService
+type avatarService struct {
+	userRepo repository.UserRepository
+	store    blobstore.BlobStore
+	maxSize  int64
+}
+
+// NewAvatarService crée une nouvelle instance de AvatarService. maxSize borne
+// la taille en octets de l'image envoyée.
+func NewAvatarService(userRepo repository.UserRepository, store blobstore.BlobStore, maxSize int64) AvatarService {
+	return &avatarService{
+		userRepo: userRepo,
+		store:    store,
+		maxSize:  maxSize,
+	}
+}
+
+// Upload génère les tailles standard de l'image et les enregistre sous une
+// nouvelle version, puis supprime les fichiers de l'avatar précédent. Chaque
+// version a ses propres URL : les caches n'ont jamais à être invalidés.
+func (s *avatarService) Upload(ctx context.Context, userID uuid.UUID, image io.Reader) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(image, s.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, ErrAvatarTooLarge
+	}
+
+	images, err := avatar.Process(data)
+	if errors.Is(err, avatar.ErrImageTooLarge) {
+		return nil, ErrAvatarTooLarge
+	}
+	if errors.Is(err, avatar.ErrUnsupportedFormat) {
+		return nil, ErrInvalidAvatar
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := "avatars/" + user.ID.String() + "/" + uuid.New().String()
+	for _, size := range avatar.Sizes {
+		if err := s.store.Put(ctx, key+"/"+avatar.FileName(size), images[size], avatar.ContentType); err != nil {
+			s.DeleteFiles(ctx, key)
+			return nil, err
+		}
+	}
+
+	url := s.store.URL(key)
+	if err := s.userRepo.UpdateAvatar(user.ID, key, url); err != nil {
+		s.DeleteFiles(ctx, key)
+		return nil, err
+	}
+
+	previous := user.AvatarKey
+	user.AvatarKey, user.AvatarURL = key, url
+	if previous != "" {
+		s.DeleteFiles(ctx, previous)
+	}
+	return user, nil
+}
+
+// Remove retire l'avatar de l'utilisateur et supprime ses fichiers
+func (s *avatarService) Remove(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AvatarKey == "" {
+		return user, nil
+	}
+
+	if err := s.userRepo.UpdateAvatar(user.ID, "", ""); err != nil {
+		return nil, err
+	}
+
+	s.DeleteFiles(ctx, user.AvatarKey)
+	user.AvatarKey, user.AvatarURL = "", ""
+	return user, nil
+}
+
+// DeleteFiles supprime toutes les tailles d'une version d'avatar. Les échecs
+// sont journalisés sans être retournés : l'avatar n'est déjà plus référencé.
+func (s *avatarService) DeleteFiles(ctx context.Context, avatarKey string) {
+	for _, size := range avatar.Sizes {
+		if err := s.store.Delete(ctx, avatarKey+"/"+avatar.FileName(size)); err != nil {
+			log.Printf("suppression de l'avatar %s (%d px) impossible: %v", avatarKey, size, err)
+		}
+	}
+}
+
+// findUser charge un utilisateur, ErrUserNotFound s'il n'existe pas
+func (s *avatarService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
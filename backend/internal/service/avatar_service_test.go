@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arnaud-dars/collec-app/internal/blobstore"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du AvatarService
+type MockAvatarService struct {
+	mock.Mock
+}
+
+func (m *MockAvatarService) Upload(ctx context.Context, userID uuid.UUID, image io.Reader) (*models.User, error) {
+	args := m.Called(ctx, userID, image)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAvatarService) Remove(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAvatarService) DeleteFiles(ctx context.Context, avatarKey string) {
+	m.Called(ctx, avatarKey)
+}
+
+// memoryBlobStore conserve les blobs en mémoire
+type memoryBlobStore struct {
+	mu    sync.Mutex
+	blobs map[string][]byte
+}
+
+func newMemoryBlobStore() *memoryBlobStore {
+	return &memoryBlobStore{blobs: map[string][]byte{}}
+}
+
+func (s *memoryBlobStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *memoryBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, blobstore.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryBlobStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryBlobStore) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+// keys retourne les clés stockées sous un préfixe
+func (s *memoryBlobStore) keys(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Helper function pour encoder une image PNG unie
+func testAvatarPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			img.Set(x, y, color.RGBA{G: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// testAvatarMaxSize est la taille maximale des images envoyées dans les tests
+const testAvatarMaxSize = 1 << 20
+
+func TestAvatarUpload_StoresSizesAndReplacesPrevious(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	store := newMemoryBlobStore()
+	avatarService := NewAvatarService(mockRepo, store, testAvatarMaxSize)
+	user := &models.User{ID: uuid.New(), AvatarKey: "avatars/previous/v0"}
+	for _, name := range []string{"64.jpg", "128.jpg", "256.jpg"} {
+		store.blobs["avatars/previous/v0/"+name] = []byte("old")
+	}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdateAvatar", user.ID, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+
+	// Act
+	updated, err := avatarService.Upload(context.Background(), user.ID, bytes.NewReader(testAvatarPNG(t)))
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(updated.AvatarKey, "avatars/"+user.ID.String()+"/"))
+	assert.Equal(t, "https://cdn.example.com/"+updated.AvatarKey, updated.AvatarURL)
+	assert.ElementsMatch(t, []string{
+		updated.AvatarKey + "/64.jpg",
+		updated.AvatarKey + "/128.jpg",
+		updated.AvatarKey + "/256.jpg",
+	}, store.keys("avatars/"+user.ID.String()))
+	assert.Empty(t, store.keys("avatars/previous/"), "les fichiers de l'avatar précédent doivent être supprimés")
+	mockRepo.AssertCalled(t, "UpdateAvatar", user.ID, updated.AvatarKey, updated.AvatarURL)
+}
+
+func TestAvatarUpload_TooLarge(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	store := newMemoryBlobStore()
+	data := testAvatarPNG(t)
+	avatarService := NewAvatarService(mockRepo, store, int64(len(data)-1))
+	user := &models.User{ID: uuid.New()}
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	_, err := avatarService.Upload(context.Background(), user.ID, bytes.NewReader(data))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAvatarTooLarge)
+	assert.Empty(t, store.blobs)
+	mockRepo.AssertNotCalled(t, "UpdateAvatar", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAvatarUpload_RejectsNonImageContent(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	avatarService := NewAvatarService(mockRepo, newMemoryBlobStore(), testAvatarMaxSize)
+	user := &models.User{ID: uuid.New()}
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act : un script déguisé, quel que soit le nom du fichier
+	_, err := avatarService.Upload(context.Background(), user.ID, strings.NewReader("<script>alert(1)</script>"))
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidAvatar)
+}
+
+func TestAvatarRemove(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	store := newMemoryBlobStore()
+	avatarService := NewAvatarService(mockRepo, store, testAvatarMaxSize)
+	user := &models.User{ID: uuid.New(), AvatarKey: "avatars/u/v1", AvatarURL: "https://cdn.example.com/avatars/u/v1"}
+	store.blobs["avatars/u/v1/64.jpg"] = []byte("jpeg")
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdateAvatar", user.ID, "", "").Return(nil)
+
+	// Act
+	updated, err := avatarService.Remove(context.Background(), user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, updated.AvatarKey)
+	assert.Empty(t, updated.AvatarURL)
+	assert.Empty(t, store.blobs)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("lien de vérification invalide ou expiré")
+)
+
+// purposeEmailVerification identifie les liens signés de vérification d'email
+const purposeEmailVerification = "email_verification"
+
+// EmailVerificationService définit l'interface de vérification des adresses email
+type EmailVerificationService interface {
+	SendVerification(user *models.User) error
+	Resend(email string) error
+	Verify(token string) (*models.User, error)
+}
+
+// emailVerificationService implémente EmailVerificationService
+type emailVerificationService struct {
+	userRepo       repository.UserRepository
+	mailer         mailer.Mailer
+	signer         linkSigner
+	frontendURL    string
+	linkDuration   time.Duration
+	resendInterval time.Duration
+}
+
+// NewEmailVerificationService crée une nouvelle instance de EmailVerificationService
+func NewEmailVerificationService(
+	userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+	linkSecret string,
+	frontendURL string,
+	linkDuration time.Duration,
+	resendInterval time.Duration,
+) EmailVerificationService {
+	return &emailVerificationService{
+		userRepo:       userRepo,
+		mailer:         mailer,
+		signer:         linkSigner{secret: []byte(linkSecret)},
+		frontendURL:    frontendURL,
+		linkDuration:   linkDuration,
+		resendInterval: resendInterval,
+	}
+}
+
+// SendVerification envoie le lien de vérification à l'adresse de l'utilisateur
+func (s *emailVerificationService) SendVerification(user *models.User) error {
+	if _, err := s.userRepo.MarkVerificationSent(user.ID, time.Now()); err != nil {
+		return err
+	}
+	return s.send(user)
+}
+
+// Resend renvoie le lien de vérification, au plus une fois par intervalle.
+// Les emails inconnus, déjà vérifiés ou trop sollicités sont ignorés sans erreur
+// afin de ne pas révéler quels emails sont inscrits.
+func (s *emailVerificationService) Resend(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	// La mise à jour conditionnelle garantit le throttling entre plusieurs instances
+	allowed, err := s.userRepo.MarkVerificationSent(user.ID, time.Now().Add(-s.resendInterval))
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return nil
+	}
+
+	return s.send(user)
+}
+
+// Verify valide le lien signé et marque l'email de l'utilisateur comme vérifié
+func (s *emailVerificationService) Verify(token string) (*models.User, error) {
+	claims, err := s.signer.verify(purposeEmailVerification, token)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	// Un lien émis pour une ancienne adresse ne vérifie pas la nouvelle
+	if user == nil || user.Email != claims.Email {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+			return nil, err
+		}
+		user.EmailVerifiedAt = &now
+	}
+
+	return user, nil
+}
+
+// send signe un nouveau lien et l'envoie par email
+func (s *emailVerificationService) send(user *models.User) error {
+	token, err := s.signer.sign(purposeEmailVerification, user.ID, user.Email, s.linkDuration)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/verify-email?token=%s", s.frontendURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Confirmez votre adresse email Collec-App",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"Merci pour votre inscription sur Collec-App !\n"+
+			"Pour confirmer votre adresse email, ouvrez le lien suivant (valable %d heures) :\n\n"+
+			"%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette inscription, ignorez cet email.\n",
+			int(s.linkDuration.Hours()), link),
+	})
+}
+
+// claimUnverifiedAccount vérifie l'email d'un compte qui n'avait jamais prouvé
+// la possession de son adresse, au profit de celui qui vient de la prouver
+// (fournisseur externe, lien de connexion). Le compte a pu être créé par un
+// tiers : son mot de passe, ses sessions, ses tokens, sa double
+// authentification et son changement d'email en attente sont supprimés.
+func claimUnverifiedAccount(userRepo repository.UserRepository, user *models.User, now time.Time) error {
+	claimed, err := userRepo.ClaimUnverified(user.ID, now)
+	if err != nil {
+		return err
+	}
+	if claimed {
+		user.Password = ""
+		user.TokenGeneration++
+		user.TOTPSecret = ""
+		user.TOTPEnabledAt = nil
+		user.TOTPLastStep = 0
+		user.PendingEmail = nil
+	}
+	// Vérifié entre-temps par le lien envoyé à l'adresse : rien n'est retiré
+	user.EmailVerifiedAt = &now
+	return nil
+}
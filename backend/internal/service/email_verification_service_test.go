@@ -0,0 +1,157 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Helper function pour construire le service de vérification de test
+func newTestVerificationService(userRepo *MockUserRepository, m *MockMailer) EmailVerificationService {
+	return NewEmailVerificationService(userRepo, m, "test-link-secret", "http://localhost:3000", 48*time.Hour, time.Minute)
+}
+
+// Tests du service EmailVerification
+
+func TestSendVerification_SendsSignedLink(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockMailer := new(MockMailer)
+	verificationService := newTestVerificationService(mockRepo, mockMailer)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	var sent mailer.Message
+
+	mockRepo.On("MarkVerificationSent", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		sent = msg
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	err := verificationService.SendVerification(user)
+
+	// Assert
+	assert.NoError(t, err)
+	mockMailer.AssertExpectations(t)
+	assert.NotEmpty(t, tokenFromEmail(sent.Body, "http://localhost:3000/auth/verify-email"))
+}
+
+func TestVerify_MarksEmailVerified(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockMailer := new(MockMailer)
+	verificationService := newTestVerificationService(mockRepo, mockMailer)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	token, err := linkSigner{secret: []byte("test-link-secret")}.sign(purposeEmailVerification, user.ID, user.Email, time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("MarkEmailVerified", user.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	verified, err := verificationService.Verify(token)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVerify_RejectsInvalidLinks(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	signer := linkSigner{secret: []byte("test-link-secret")}
+
+	expired, _ := signer.sign(purposeEmailVerification, user.ID, user.Email, -time.Minute)
+	otherPurpose, _ := signer.sign("password_reset", user.ID, user.Email, time.Hour)
+	otherSecret, _ := linkSigner{secret: []byte("another-secret")}.sign(purposeEmailVerification, user.ID, user.Email, time.Hour)
+
+	for name, token := range map[string]string{
+		"expiré":       expired,
+		"autre usage":  otherPurpose,
+		"autre secret": otherSecret,
+		"mal formé":    "not-a-token",
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(MockUserRepository)
+			verificationService := newTestVerificationService(mockRepo, new(MockMailer))
+
+			// Act
+			verified, err := verificationService.Verify(token)
+
+			// Assert
+			assert.Equal(t, ErrInvalidVerificationToken, err)
+			assert.Nil(t, verified)
+			mockRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestVerify_LinkForPreviousEmail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	verificationService := newTestVerificationService(mockRepo, new(MockMailer))
+
+	userID := uuid.New()
+	token, err := linkSigner{secret: []byte("test-link-secret")}.sign(purposeEmailVerification, userID, "old@example.com", time.Hour)
+	assert.NoError(t, err)
+
+	mockRepo.On("FindByID", userID).Return(&models.User{ID: userID, Email: "new@example.com"}, nil)
+
+	// Act
+	_, err = verificationService.Verify(token)
+
+	// Assert
+	assert.Equal(t, ErrInvalidVerificationToken, err)
+	mockRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+}
+
+func TestResend_Throttled(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockMailer := new(MockMailer)
+	verificationService := newTestVerificationService(mockRepo, mockMailer)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockRepo.On("MarkVerificationSent", user.ID, mock.MatchedBy(func(notSentSince time.Time) bool {
+		// Le seuil correspond à l'intervalle de renvoi configuré
+		return time.Since(notSentSince) >= time.Minute && time.Since(notSentSince) < time.Minute+time.Second
+	})).Return(false, nil)
+
+	// Act
+	err := verificationService.Resend(user.Email)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestResend_AlreadyVerified(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockMailer := new(MockMailer)
+	verificationService := newTestVerificationService(mockRepo, mockMailer)
+
+	verifiedAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+
+	// Act
+	err := verificationService.Resend(user.Email)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "MarkVerificationSent", mock.Anything, mock.Anything)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything)
+}
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/avatar"
+	"github.com/arnaud-dars/collec-app/internal/blobstore"
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrExportInProgress  = errors.New("un export de vos données est déjà en cours")
+	ErrExportNotFound    = errors.New("aucun export de données demandé")
+	ErrInvalidExportLink = errors.New("lien de téléchargement invalide ou expiré")
+)
+
+// purposeDataExport identifie les liens signés de téléchargement d'une archive
+const purposeDataExport = "data_export"
+
+const (
+	// exportBatchSize limite le nombre d'archives traitées par passage du worker
+	exportBatchSize = 10
+	// exportStaleAfter est le délai après lequel une construction interrompue
+	// (arrêt de l'instance) est reprise par un autre worker
+	exportStaleAfter = 30 * time.Minute
+)
+
+// ExportConfig regroupe les paramètres de l'export des données personnelles
+type ExportConfig struct {
+	Dir        string        // dossier de stockage des archives
+	LinkSecret string        // secret HMAC des liens de téléchargement
+	PublicURL  string        // base publique de l'API, utilisée dans le lien de téléchargement
+	Retention  time.Duration // validité du lien, l'archive est supprimée ensuite
+}
+
+// ExportArchive est l'archive ZIP en cours de construction
+type ExportArchive struct {
+	zip   *zip.Writer
+	files []string
+}
+
+// WriteJSON ajoute à l'archive un fichier JSON indenté
+func (a *ExportArchive) WriteJSON(name string, v interface{}) error {
+	w, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// WriteFile ajoute à l'archive un fichier quelconque (média joint)
+func (a *ExportArchive) WriteFile(name string, r io.Reader) error {
+	w, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// create ouvre un nouveau fichier dans l'archive et l'inscrit au manifeste
+func (a *ExportArchive) create(name string) (io.Writer, error) {
+	w, err := a.zip.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	a.files = append(a.files, name)
+	return w, nil
+}
+
+// ExportSource ajoute à l'archive une partie des données d'un utilisateur.
+// Chaque domaine fonctionnel (compte, collections...) fournit sa source.
+type ExportSource interface {
+	Export(user *models.User, archive *ExportArchive) error
+}
+
+// ExportService définit l'interface de l'export des données personnelles (RGPD)
+type ExportService interface {
+	RequestExport(userID uuid.UUID) (*models.DataExport, error)
+	LatestExport(userID uuid.UUID) (*models.DataExport, string, error)
+	ProcessPendingExports() (int, error)
+	OpenExport(token string) (*models.DataExport, *os.File, error)
+	PurgeExpiredExports() (int, error)
+	DeleteArchives(userID uuid.UUID) error
+}
+
+// exportService implémente ExportService
+type exportService struct {
+	exportRepo repository.DataExportRepository
+	userRepo   repository.UserRepository
+	mailer     mailer.Mailer
+	sources    []ExportSource
+	signer     linkSigner
+	dir        string
+	publicURL  string
+	retention  time.Duration
+}
+
+// NewExportService crée une nouvelle instance de ExportService
+func NewExportService(
+	exportRepo repository.DataExportRepository,
+	userRepo repository.UserRepository,
+	mailer mailer.Mailer,
+	sources []ExportSource,
+	config ExportConfig,
+) ExportService {
+	return &exportService{
+		exportRepo: exportRepo,
+		userRepo:   userRepo,
+		mailer:     mailer,
+		sources:    sources,
+		signer:     linkSigner{secret: []byte(config.LinkSecret)},
+		dir:        config.Dir,
+		publicURL:  config.PublicURL,
+		retention:  config.Retention,
+	}
+}
+
+// RequestExport enregistre une demande d'export. L'archive est construite en
+// tâche de fond par ProcessPendingExports ; l'utilisateur est prévenu par email.
+func (s *exportService) RequestExport(userID uuid.UUID) (*models.DataExport, error) {
+	latest, err := s.exportRepo.FindLatestByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && (latest.Status == models.DataExportPending || latest.Status == models.DataExportProcessing) {
+		return nil, ErrExportInProgress
+	}
+
+	export := &models.DataExport{UserID: userID, Status: models.DataExportPending}
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// LatestExport retourne la dernière demande d'export de l'utilisateur et, si
+// l'archive est disponible, un lien de téléchargement
+func (s *exportService) LatestExport(userID uuid.UUID) (*models.DataExport, string, error) {
+	export, err := s.exportRepo.FindLatestByUserID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if export == nil {
+		return nil, "", ErrExportNotFound
+	}
+
+	if !s.downloadable(export) {
+		return export, "", nil
+	}
+	link, err := s.downloadURL(export)
+	if err != nil {
+		return nil, "", err
+	}
+	return export, link, nil
+}
+
+// ProcessPendingExports construit les archives en attente et prévient leurs
+// propriétaires. Retourne le nombre d'archives construites ; à appeler
+// périodiquement.
+func (s *exportService) ProcessPendingExports() (int, error) {
+	staleBefore := time.Now().Add(-exportStaleAfter)
+	exports, err := s.exportRepo.FindClaimable(staleBefore, exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range exports {
+		export := &exports[i]
+		// Une autre instance a pu prendre l'export en charge entre-temps
+		claimed, err := s.exportRepo.Claim(export.ID, time.Now(), staleBefore)
+		if err != nil {
+			return count, err
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.build(export); err != nil {
+			log.Printf("construction de l'export %s impossible: %v", export.ID, err)
+			if err := s.exportRepo.MarkFailed(export.ID); err != nil {
+				return count, err
+			}
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// OpenExport vérifie le lien de téléchargement et ouvre l'archive correspondante
+func (s *exportService) OpenExport(token string) (*models.DataExport, *os.File, error) {
+	claims, err := s.signer.verify(purposeDataExport, token)
+	if err != nil {
+		return nil, nil, ErrInvalidExportLink
+	}
+
+	exportID, err := uuid.Parse(claims.Resource)
+	if err != nil {
+		return nil, nil, ErrInvalidExportLink
+	}
+
+	export, err := s.exportRepo.FindByID(exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export == nil || export.UserID.String() != claims.Subject || !s.downloadable(export) {
+		return nil, nil, ErrInvalidExportLink
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, export.FileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil, ErrInvalidExportLink
+		}
+		return nil, nil, err
+	}
+	return export, file, nil
+}
+
+// PurgeExpiredExports supprime les archives dont le lien a expiré. Retourne
+// le nombre d'archives supprimées ; à appeler périodiquement.
+func (s *exportService) PurgeExpiredExports() (int, error) {
+	exports, err := s.exportRepo.FindExpired(time.Now(), exportBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, export := range exports {
+		if err := os.Remove(filepath.Join(s.dir, export.FileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, err
+		}
+		if err := s.exportRepo.MarkExpired(export.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// DeleteArchives supprime du disque toutes les archives d'un utilisateur.
+// À appeler avant la suppression définitive du compte, qui efface les
+// demandes d'export à partir desquelles PurgeExpiredExports retrouve les fichiers.
+func (s *exportService) DeleteArchives(userID uuid.UUID) error {
+	exports, err := s.exportRepo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		if export.FileName == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir, export.FileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// build construit l'archive d'un export, l'enregistre comme disponible et
+// envoie le lien de téléchargement à l'utilisateur
+func (s *exportService) build(export *models.DataExport) error {
+	user, err := s.userRepo.FindByID(export.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	fileName, size, err := s.writeArchive(user)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.retention)
+	if err := s.exportRepo.MarkReady(export.ID, fileName, size, now, expiresAt); err != nil {
+		os.Remove(filepath.Join(s.dir, fileName))
+		return err
+	}
+	export.Status = models.DataExportReady
+	export.FileName = fileName
+	export.Size = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+
+	link, err := s.downloadURL(export)
+	if err != nil {
+		return err
+	}
+
+	// L'archive reste accessible depuis l'application si l'email n'arrive pas
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Votre export de données Collec-App est prêt",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"L'archive contenant l'ensemble de vos données Collec-App est prête. "+
+			"Vous pouvez la télécharger jusqu'au %s depuis le lien suivant :\n\n"+
+			"%s\n\n"+
+			"Ce lien donne accès à vos données personnelles : ne le partagez pas.\n",
+			expiresAt.Format("02/01/2006 à 15:04"), link),
+	})
+	if err != nil {
+		log.Printf("envoi du lien de l'export %s impossible: %v", export.ID, err)
+	}
+	return nil
+}
+
+// writeArchive écrit l'archive ZIP de l'utilisateur dans le dossier des
+// exports. Le fichier n'apparaît sous son nom définitif qu'une fois complet.
+func (s *exportService) writeArchive(user *models.User) (string, int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return "", 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, "export-*.zip.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	archive := &ExportArchive{zip: zip.NewWriter(tmp)}
+	for _, source := range s.sources {
+		if err := source.Export(user, archive); err != nil {
+			return "", 0, err
+		}
+	}
+
+	// Le manifeste décrit le contenu de l'archive
+	files := archive.files
+	err = archive.WriteJSON("manifest.json", map[string]interface{}{
+		"userId":     user.ID,
+		"exportedAt": time.Now(),
+		"files":      files,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := archive.zip.Close(); err != nil {
+		return "", 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	fileName := uuid.New().String() + ".zip"
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, fileName)); err != nil {
+		return "", 0, err
+	}
+	return fileName, info.Size(), nil
+}
+
+// downloadable indique si l'archive d'un export peut être téléchargée
+func (s *exportService) downloadable(export *models.DataExport) bool {
+	return export.Status == models.DataExportReady && export.ExpiresAt != nil && time.Now().Before(*export.ExpiresAt)
+}
+
+// downloadURL signe un lien de téléchargement valable jusqu'à l'expiration de l'archive
+func (s *exportService) downloadURL(export *models.DataExport) (string, error) {
+	token, err := s.signer.signResource(purposeDataExport, export.UserID, export.ID.String(), time.Until(*export.ExpiresAt))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/api/users/me/export/download?token=%s", s.publicURL, url.QueryEscape(token)), nil
+}
+
+// accountExportSource exporte le profil, l'avatar, les sessions, les comptes
+// externes liés et les personal access tokens
+type accountExportSource struct {
+	sessionRepo  repository.SessionRepository
+	identityRepo repository.UserIdentityRepository
+	tokenRepo    repository.PersonalAccessTokenRepository
+	store        blobstore.BlobStore
+}
+
+// NewAccountExportSource crée la source d'export des données du compte
+func NewAccountExportSource(
+	sessionRepo repository.SessionRepository,
+	identityRepo repository.UserIdentityRepository,
+	tokenRepo repository.PersonalAccessTokenRepository,
+	store blobstore.BlobStore,
+) ExportSource {
+	return &accountExportSource{
+		sessionRepo:  sessionRepo,
+		identityRepo: identityRepo,
+		tokenRepo:    tokenRepo,
+		store:        store,
+	}
+}
+
+// Export écrit les fichiers account/*.json et les images de l'avatar
+func (s *accountExportSource) Export(user *models.User, archive *ExportArchive) error {
+	profile := map[string]interface{}{
+		"id":                  user.ID,
+		"email":               user.Email,
+		"pendingEmail":        user.PendingEmail,
+		"displayName":         user.DisplayName,
+		"username":            user.Username,
+		"bio":                 user.Bio,
+		"locale":              user.Locale,
+		"timezone":            user.Timezone,
+		"currency":            user.Currency,
+		"emailVerifiedAt":     user.EmailVerifiedAt,
+		"totpEnabledAt":       user.TOTPEnabledAt,
+		"deletionRequestedAt": user.DeletionRequestedAt,
+		"createdAt":           user.CreatedAt,
+		"updatedAt":           user.UpdatedAt,
+	}
+	if err := archive.WriteJSON("account/profile.json", profile); err != nil {
+		return err
+	}
+	if err := s.exportAvatar(user, archive); err != nil {
+		return err
+	}
+
+	sessions, err := s.sessionRepo.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := archive.WriteJSON("account/sessions.json", sessions); err != nil {
+		return err
+	}
+
+	identities, err := s.identityRepo.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	if err := archive.WriteJSON("account/identities.json", identities); err != nil {
+		return err
+	}
+
+	tokens, err := s.tokenRepo.FindByUserID(user.ID)
+	if err != nil {
+		return err
+	}
+	return archive.WriteJSON("account/access_tokens.json", tokens)
+}
+
+// exportAvatar joint à l'archive chaque taille de l'avatar courant. Une
+// taille absente du stockage est ignorée.
+func (s *accountExportSource) exportAvatar(user *models.User, archive *ExportArchive) error {
+	if user.AvatarKey == "" {
+		return nil
+	}
+	for _, size := range avatar.Sizes {
+		file, err := s.store.Get(context.Background(), user.AvatarKey+"/"+avatar.FileName(size))
+		if errors.Is(err, blobstore.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = archive.WriteFile("account/avatar/"+avatar.FileName(size), file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
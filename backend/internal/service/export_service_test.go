@@ -0,0 +1,391 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/avatar"
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du DataExportRepository
+type MockDataExportRepository struct {
+	mock.Mock
+}
+
+func (m *MockDataExportRepository) Create(export *models.DataExport) error {
+	args := m.Called(export)
+	if export.ID == uuid.Nil {
+		export.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) FindByID(id uuid.UUID) (*models.DataExport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) FindLatestByUserID(userID uuid.UUID) (*models.DataExport, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) FindByUserID(userID uuid.UUID) ([]models.DataExport, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) FindClaimable(staleBefore time.Time, limit int) ([]models.DataExport, error) {
+	args := m.Called(staleBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) Claim(id uuid.UUID, startedAt, staleBefore time.Time) (bool, error) {
+	args := m.Called(id, startedAt, staleBefore)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDataExportRepository) MarkReady(id uuid.UUID, fileName string, size int64, completedAt, expiresAt time.Time) error {
+	args := m.Called(id, fileName, size, completedAt, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) MarkFailed(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDataExportRepository) FindExpired(now time.Time, limit int) ([]models.DataExport, error) {
+	args := m.Called(now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.DataExport), args.Error(1)
+}
+
+func (m *MockDataExportRepository) MarkExpired(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// Mock du ExportService
+type MockExportService struct {
+	mock.Mock
+}
+
+func (m *MockExportService) RequestExport(userID uuid.UUID) (*models.DataExport, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DataExport), args.Error(1)
+}
+
+func (m *MockExportService) LatestExport(userID uuid.UUID) (*models.DataExport, string, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*models.DataExport), args.String(1), args.Error(2)
+}
+
+func (m *MockExportService) ProcessPendingExports() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExportService) OpenExport(token string) (*models.DataExport, *os.File, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*models.DataExport), args.Get(1).(*os.File), args.Error(2)
+}
+
+func (m *MockExportService) PurgeExpiredExports() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockExportService) DeleteArchives(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// failingExportSource simule une source de données en erreur
+type failingExportSource struct{}
+
+func (failingExportSource) Export(user *models.User, archive *ExportArchive) error {
+	return errors.New("source indisponible")
+}
+
+// Helper function pour créer le service d'export avec un dossier temporaire
+func newExportTestService(t *testing.T, sources ...ExportSource) (ExportService, *MockDataExportRepository, *MockUserRepository, *MockMailer, string) {
+	t.Helper()
+	dir := t.TempDir()
+	mockExports := new(MockDataExportRepository)
+	mockUsers := new(MockUserRepository)
+	mockMailer := new(MockMailer)
+	exportService := NewExportService(mockExports, mockUsers, mockMailer, sources, ExportConfig{
+		Dir:        dir,
+		LinkSecret: testLinkSecret,
+		PublicURL:  "http://localhost:8080",
+		Retention:  72 * time.Hour,
+	})
+	return exportService, mockExports, mockUsers, mockMailer, dir
+}
+
+// Tests de la demande d'export
+
+func TestRequestExport_CreatesPendingExport(t *testing.T) {
+	// Arrange
+	exportService, mockExports, _, _, _ := newExportTestService(t)
+	userID := uuid.New()
+	expiresAt := time.Now().Add(-time.Hour)
+
+	mockExports.On("FindLatestByUserID", userID).Return(&models.DataExport{Status: models.DataExportExpired, ExpiresAt: &expiresAt}, nil)
+	mockExports.On("Create", mock.MatchedBy(func(e *models.DataExport) bool {
+		return e.UserID == userID && e.Status == models.DataExportPending
+	})).Return(nil)
+
+	// Act
+	export, err := exportService.RequestExport(userID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, models.DataExportPending, export.Status)
+	mockExports.AssertExpectations(t)
+}
+
+func TestRequestExport_AlreadyInProgress(t *testing.T) {
+	// Arrange
+	exportService, mockExports, _, _, _ := newExportTestService(t)
+	userID := uuid.New()
+
+	mockExports.On("FindLatestByUserID", userID).Return(&models.DataExport{UserID: userID, Status: models.DataExportProcessing}, nil)
+
+	// Act
+	_, err := exportService.RequestExport(userID)
+
+	// Assert
+	assert.Equal(t, ErrExportInProgress, err)
+	mockExports.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+// Tests de la construction des archives
+
+func TestProcessPendingExports_BuildsArchiveAndSendsLink(t *testing.T) {
+	// Arrange
+	mockSessions := new(MockSessionRepository)
+	mockIdentities := new(MockUserIdentityRepository)
+	mockTokens := new(MockPersonalAccessTokenRepository)
+	store := newMemoryBlobStore()
+	exportService, mockExports, mockUsers, mockMailer, dir := newExportTestService(t, NewAccountExportSource(mockSessions, mockIdentities, mockTokens, store))
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: "secret-hash", AvatarKey: "avatars/" + uuid.NewString() + "/v1"}
+	for _, size := range avatar.Sizes {
+		require.NoError(t, store.Put(context.Background(), user.AvatarKey+"/"+avatar.FileName(size), []byte("jpeg"), avatar.ContentType))
+	}
+	export := models.DataExport{ID: uuid.New(), UserID: user.ID, Status: models.DataExportPending}
+	var ready models.DataExport
+	var notification mailer.Message
+
+	mockExports.On("FindClaimable", mock.AnythingOfType("time.Time"), exportBatchSize).Return([]models.DataExport{export}, nil)
+	mockExports.On("Claim", export.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+	mockSessions.On("FindByUserID", user.ID).Return([]models.Session{{ID: uuid.New(), UserID: user.ID, UserAgent: "Mozilla/5.0"}}, nil)
+	mockIdentities.On("FindByUserID", user.ID).Return([]models.UserIdentity{}, nil)
+	mockTokens.On("FindByUserID", user.ID).Return([]models.PersonalAccessToken{}, nil)
+	mockExports.On("MarkReady", export.ID, mock.AnythingOfType("string"), mock.AnythingOfType("int64"), mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) {
+			expiresAt := args.Get(4).(time.Time)
+			ready = models.DataExport{
+				ID: export.ID, UserID: user.ID, Status: models.DataExportReady,
+				FileName: args.String(1), Size: args.Get(2).(int64), ExpiresAt: &expiresAt,
+			}
+		}).Return(nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		notification = msg
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	count, err := exportService.ProcessPendingExports()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockExports.AssertExpectations(t)
+
+	archive, err := zip.OpenReader(filepath.Join(dir, ready.FileName))
+	require.NoError(t, err)
+	defer archive.Close()
+	var names []string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"account/profile.json", "account/avatar/64.jpg", "account/avatar/128.jpg", "account/avatar/256.jpg",
+		"account/sessions.json", "account/identities.json", "account/access_tokens.json", "manifest.json",
+	}, names)
+
+	// Le lien reçu par email ouvre l'archive
+	token := tokenFromEmail(notification.Body, "http://localhost:8080/api/users/me/export/download")
+	require.NotEmpty(t, token)
+	mockExports.On("FindByID", export.ID).Return(&ready, nil)
+
+	opened, file, err := exportService.OpenExport(token)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, export.ID, opened.ID)
+}
+
+func TestProcessPendingExports_AlreadyClaimed(t *testing.T) {
+	// Arrange
+	exportService, mockExports, mockUsers, _, _ := newExportTestService(t)
+	export := models.DataExport{ID: uuid.New(), UserID: uuid.New(), Status: models.DataExportPending}
+
+	mockExports.On("FindClaimable", mock.AnythingOfType("time.Time"), exportBatchSize).Return([]models.DataExport{export}, nil)
+	mockExports.On("Claim", export.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(false, nil)
+
+	// Act
+	count, err := exportService.ProcessPendingExports()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	mockUsers.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestProcessPendingExports_SourceFailureMarksExportFailed(t *testing.T) {
+	// Arrange
+	exportService, mockExports, mockUsers, mockMailer, dir := newExportTestService(t, failingExportSource{})
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	export := models.DataExport{ID: uuid.New(), UserID: user.ID, Status: models.DataExportPending}
+
+	mockExports.On("FindClaimable", mock.AnythingOfType("time.Time"), exportBatchSize).Return([]models.DataExport{export}, nil)
+	mockExports.On("Claim", export.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(true, nil)
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+	mockExports.On("MarkFailed", export.ID).Return(nil)
+
+	// Act
+	count, err := exportService.ProcessPendingExports()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	mockExports.AssertExpectations(t)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything)
+
+	// Aucune archive partielle ne reste sur le disque
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// Tests du téléchargement
+
+func TestOpenExport_RejectsInvalidLinks(t *testing.T) {
+	exportID := uuid.New()
+	userID := uuid.New()
+	signer := linkSigner{secret: []byte(testLinkSecret)}
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Hour)
+
+	otherPurpose, _ := signer.signResource(purposeEmailChange, userID, exportID.String(), time.Hour)
+	otherUser, _ := signer.signResource(purposeDataExport, uuid.New(), exportID.String(), time.Hour)
+	current, _ := signer.signResource(purposeDataExport, userID, exportID.String(), time.Hour)
+
+	cases := []struct {
+		name   string
+		token  string
+		export *models.DataExport
+	}{
+		{"lien falsifié", "not-a-token", nil},
+		{"autre usage", otherPurpose, nil},
+		{"autre utilisateur", otherUser, &models.DataExport{ID: exportID, UserID: userID, Status: models.DataExportReady, ExpiresAt: &valid}},
+		{"archive expirée", current, &models.DataExport{ID: exportID, UserID: userID, Status: models.DataExportReady, ExpiresAt: &expired}},
+		{"archive supprimée", current, &models.DataExport{ID: exportID, UserID: userID, Status: models.DataExportExpired, ExpiresAt: &valid}},
+		{"fichier absent", current, &models.DataExport{ID: exportID, UserID: userID, Status: models.DataExportReady, FileName: "missing.zip", ExpiresAt: &valid}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			exportService, mockExports, _, _, _ := newExportTestService(t)
+			mockExports.On("FindByID", exportID).Return(tc.export, nil)
+
+			// Act
+			_, file, err := exportService.OpenExport(tc.token)
+
+			// Assert
+			assert.Equal(t, ErrInvalidExportLink, err)
+			assert.Nil(t, file)
+		})
+	}
+}
+
+func TestPurgeExpiredExports_RemovesArchives(t *testing.T) {
+	// Arrange
+	exportService, mockExports, _, _, dir := newExportTestService(t)
+	export := models.DataExport{ID: uuid.New(), Status: models.DataExportReady, FileName: "archive.zip"}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, export.FileName), []byte("zip"), 0o600))
+
+	mockExports.On("FindExpired", mock.AnythingOfType("time.Time"), exportBatchSize).Return([]models.DataExport{export}, nil)
+	mockExports.On("MarkExpired", export.ID).Return(nil)
+
+	// Act
+	count, err := exportService.PurgeExpiredExports()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoFileExists(t, filepath.Join(dir, export.FileName))
+	mockExports.AssertExpectations(t)
+}
+
+func TestDeleteArchives_RemovesAllArchivesOfUser(t *testing.T) {
+	// Arrange
+	exportService, mockExports, _, _, dir := newExportTestService(t)
+	userID := uuid.New()
+	exports := []models.DataExport{
+		{ID: uuid.New(), UserID: userID, Status: models.DataExportReady, FileName: "recent.zip"},
+		{ID: uuid.New(), UserID: userID, Status: models.DataExportReady, FileName: "already-removed.zip"},
+		{ID: uuid.New(), UserID: userID, Status: models.DataExportPending},
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "recent.zip"), []byte("zip"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other-user.zip"), []byte("zip"), 0o600))
+
+	mockExports.On("FindByUserID", userID).Return(exports, nil)
+
+	// Act
+	err := exportService.DeleteArchives(userID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "recent.zip"))
+	assert.FileExists(t, filepath.Join(dir, "other-user.zip"))
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey            = errors.New("aucune clé de signature active")
+	ErrUnknownSigningKey       = errors.New("clé de signature inconnue")
+	ErrUnsupportedKeyAlgorithm = errors.New("algorithme de signature non pris en charge")
+	ErrInvalidStoredKey        = errors.New("clé de signature stockée invalide")
+)
+
+// Algorithmes de signature asymétriques pris en charge
+const (
+	SigningAlgorithmRS256 = "RS256"
+	SigningAlgorithmEdDSA = "EdDSA"
+)
+
+// rsaKeyBits est la taille des clés RSA générées
+const rsaKeyBits = 2048
+
+// unknownKidReloadInterval limite les rechargements déclenchés par un kid
+// inconnu, pour qu'un token forgé ne provoque pas une requête par appel
+const unknownKidReloadInterval = 10 * time.Second
+
+// SigningKey est la clé privée courante, utilisée pour signer les JWT
+type SigningKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+}
+
+// Method retourne la méthode de signature jwt correspondant à l'algorithme
+func (k *SigningKey) Method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// VerificationKey est une clé publique acceptée pour vérifier les JWT
+type VerificationKey struct {
+	ID        string
+	Algorithm string
+	PublicKey crypto.PublicKey
+}
+
+// KeyRingConfig regroupe les paramètres de génération et de rotation des clés
+type KeyRingConfig struct {
+	Algorithm        string        // algorithme des nouvelles clés (RS256 ou EdDSA)
+	EncryptionKey    string        // clé de chiffrement des clés privées en base
+	PropagationDelay time.Duration // délai entre la publication d'une clé et son utilisation pour signer
+	Overlap          time.Duration // durée pendant laquelle une clé remplacée vérifie encore les tokens
+	ReloadInterval   time.Duration // fréquence de rechargement des clés depuis la base
+}
+
+// KeyRing définit l'interface du trousseau de clés de signature des JWT.
+// Chaque clé est identifiée par un kid placé dans l'en-tête des tokens.
+type KeyRing interface {
+	SigningKey() (*SigningKey, error)
+	VerificationKey(kid string) (*VerificationKey, error)
+	VerificationKeys() ([]VerificationKey, error)
+	Rotate() (*models.SigningKey, error)
+}
+
+// loadedKey est une clé chargée depuis la base avec sa partie privée déchiffrée
+type loadedKey struct {
+	model   models.SigningKey
+	public  crypto.PublicKey
+	private crypto.Signer
+}
+
+// keyRing implémente KeyRing. Les clés sont partagées en base entre les
+// instances et mises en cache en mémoire.
+type keyRing struct {
+	repo             repository.SigningKeyRepository
+	secrets          secretBox
+	algorithm        string
+	propagationDelay time.Duration
+	overlap          time.Duration
+	reloadInterval   time.Duration
+
+	mu       sync.RWMutex
+	keys     []loadedKey // de la plus récemment activée à la plus ancienne
+	loadedAt time.Time
+}
+
+// NewKeyRing crée le trousseau de clés et le charge. Si aucune clé n'est
+// active, une première clé est générée et utilisée immédiatement.
+func NewKeyRing(repo repository.SigningKeyRepository, config KeyRingConfig) (KeyRing, error) {
+	if config.Algorithm != SigningAlgorithmRS256 && config.Algorithm != SigningAlgorithmEdDSA {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeyAlgorithm, config.Algorithm)
+	}
+
+	ring := &keyRing{
+		repo:             repo,
+		secrets:          newSecretBox(config.EncryptionKey),
+		algorithm:        config.Algorithm,
+		propagationDelay: config.PropagationDelay,
+		overlap:          config.Overlap,
+		reloadInterval:   config.ReloadInterval,
+	}
+
+	if err := ring.reload(); err != nil {
+		return nil, err
+	}
+
+	if _, err := ring.SigningKey(); errors.Is(err, ErrNoSigningKey) {
+		if _, err := ring.createKey(time.Now()); err != nil {
+			return nil, err
+		}
+		if err := ring.reload(); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// SigningKey retourne la clé active la plus récente. Une clé créée par une
+// rotation n'est utilisée qu'une fois son délai de propagation écoulé, pour
+// que les vérificateurs aient pu recharger le JWKS entre-temps.
+func (r *keyRing) SigningKey() (*SigningKey, error) {
+	r.refreshIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range r.keys {
+		if key.model.ActivatesAt.After(now) || isExpired(key.model, now) {
+			continue
+		}
+		return &SigningKey{ID: key.model.ID, Algorithm: key.model.Algorithm, PrivateKey: key.private}, nil
+	}
+	return nil, ErrNoSigningKey
+}
+
+// VerificationKey retourne la clé publique correspondant au kid. Un kid inconnu
+// déclenche un rechargement, la clé ayant pu être créée par une autre instance.
+func (r *keyRing) VerificationKey(kid string) (*VerificationKey, error) {
+	r.refreshIfStale()
+
+	if key, ok := r.lookup(kid); ok {
+		return key, nil
+	}
+
+	r.mu.RLock()
+	recent := time.Since(r.loadedAt) < unknownKidReloadInterval
+	r.mu.RUnlock()
+	if recent {
+		return nil, ErrUnknownSigningKey
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	if key, ok := r.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownSigningKey
+}
+
+// VerificationKeys retourne toutes les clés publiées, y compris celles qui ne
+// signent pas encore ou plus
+func (r *keyRing) VerificationKeys() ([]VerificationKey, error) {
+	r.refreshIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]VerificationKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		if isExpired(key.model, now) {
+			continue
+		}
+		keys = append(keys, VerificationKey{ID: key.model.ID, Algorithm: key.model.Algorithm, PublicKey: key.public})
+	}
+	return keys, nil
+}
+
+// Rotate crée une nouvelle clé, publiée immédiatement et utilisée pour signer
+// après le délai de propagation. Les clés existantes continuent de vérifier
+// les tokens pendant la période de recouvrement qui suit cette activation.
+func (r *keyRing) Rotate() (*models.SigningKey, error) {
+	activatesAt := time.Now().Add(r.propagationDelay)
+
+	key, err := r.createKey(activatesAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.repo.ScheduleExpiry(key.ID, activatesAt.Add(r.overlap)); err != nil {
+		return nil, err
+	}
+
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// lookup cherche une clé non expirée dans le cache
+func (r *keyRing) lookup(kid string) (*VerificationKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range r.keys {
+		if key.model.ID == kid && !isExpired(key.model, now) {
+			return &VerificationKey{ID: key.model.ID, Algorithm: key.model.Algorithm, PublicKey: key.public}, true
+		}
+	}
+	return nil, false
+}
+
+// refreshIfStale recharge les clés si le cache a dépassé l'intervalle de
+// rechargement. En cas d'échec, les clés en cache restent utilisées.
+func (r *keyRing) refreshIfStale() {
+	r.mu.RLock()
+	stale := time.Since(r.loadedAt) >= r.reloadInterval
+	r.mu.RUnlock()
+
+	if !stale {
+		return
+	}
+	if err := r.reload(); err != nil {
+		log.Printf("rechargement des clés de signature impossible: %v", err)
+	}
+}
+
+// reload charge les clés non expirées depuis la base
+func (r *keyRing) reload() error {
+	stored, err := r.repo.FindUsable(time.Now())
+	if err != nil {
+		return err
+	}
+
+	keys := make([]loadedKey, 0, len(stored))
+	for _, model := range stored {
+		key, err := r.decode(model)
+		if err != nil {
+			// Une clé illisible signale une clé de chiffrement différente entre
+			// les instances : on refuse plutôt que de diverger silencieusement
+			return fmt.Errorf("%w: %s: %v", ErrInvalidStoredKey, model.ID, err)
+		}
+		keys = append(keys, key)
+	}
+
+	r.mu.Lock()
+	r.keys = keys
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+// createKey génère et enregistre une nouvelle clé active à partir de activatesAt
+func (r *keyRing) createKey(activatesAt time.Time) (*models.SigningKey, error) {
+	private, err := generatePrivateKey(r.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := r.secrets.seal(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	kid, err := generateKeyID()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.SigningKey{
+		ID:          kid,
+		Algorithm:   r.algorithm,
+		PrivateKey:  sealed,
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		ActivatesAt: activatesAt,
+	}
+	if err := r.repo.Create(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// decode déchiffre et décode les clés d'un enregistrement
+func (r *keyRing) decode(model models.SigningKey) (loadedKey, error) {
+	if jwt.GetSigningMethod(model.Algorithm) == nil {
+		return loadedKey{}, ErrUnsupportedKeyAlgorithm
+	}
+
+	privatePEM, err := r.secrets.open(model.PrivateKey)
+	if err != nil {
+		return loadedKey{}, err
+	}
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return loadedKey{}, ErrInvalidStoredKey
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return loadedKey{}, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return loadedKey{}, ErrInvalidStoredKey
+	}
+
+	return loadedKey{model: model, public: private.Public(), private: private}, nil
+}
+
+// isExpired indique si une clé a dépassé sa période de recouvrement
+func isExpired(key models.SigningKey, now time.Time) bool {
+	return key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)
+}
+
+// generatePrivateKey génère une clé privée pour l'algorithme donné
+func generatePrivateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case SigningAlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case SigningAlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	}
+	return nil, ErrUnsupportedKeyAlgorithm
+}
+
+// generateKeyID génère un kid aléatoire
+func generateKeyID() (string, error) {
+	raw := make([]byte, 12)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du SigningKeyRepository
+type MockSigningKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockSigningKeyRepository) Create(key *models.SigningKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockSigningKeyRepository) FindUsable(now time.Time) ([]models.SigningKey, error) {
+	args := m.Called(now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SigningKey), args.Error(1)
+}
+
+func (m *MockSigningKeyRepository) ScheduleExpiry(exceptID string, expiresAt time.Time) error {
+	args := m.Called(exceptID, expiresAt)
+	return args.Error(0)
+}
+
+// testKeyRingConfig est la configuration du trousseau utilisée par les tests
+var testKeyRingConfig = KeyRingConfig{
+	Algorithm:        SigningAlgorithmEdDSA,
+	EncryptionKey:    "test-encryption-key",
+	PropagationDelay: 15 * time.Minute,
+	Overlap:          168 * time.Hour,
+	ReloadInterval:   time.Minute,
+}
+
+// keyRingTestEnv simule la table signing_keys avec un mock à état
+type keyRingTestEnv struct {
+	repo   *MockSigningKeyRepository
+	stored []models.SigningKey
+}
+
+// Helper function pour créer un mock de repository qui conserve les clés créées
+func newKeyRingTestEnv() *keyRingTestEnv {
+	env := &keyRingTestEnv{repo: new(MockSigningKeyRepository)}
+
+	env.repo.On("Create", mock.AnythingOfType("*models.SigningKey")).Run(func(args mock.Arguments) {
+		key := args.Get(0).(*models.SigningKey)
+		key.CreatedAt = time.Now()
+		env.stored = append(env.stored, *key)
+	}).Return(nil)
+
+	env.repo.On("ScheduleExpiry", mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Run(func(args mock.Arguments) {
+		expiresAt := args.Get(1).(time.Time)
+		for i := range env.stored {
+			if env.stored[i].ID != args.String(0) && env.stored[i].ExpiresAt == nil {
+				env.stored[i].ExpiresAt = &expiresAt
+			}
+		}
+	}).Return(nil)
+
+	findUsable := env.repo.On("FindUsable", mock.AnythingOfType("time.Time"))
+	findUsable.Run(func(args mock.Arguments) {
+		now := args.Get(0).(time.Time)
+		usable := []models.SigningKey{}
+		for _, key := range env.stored {
+			if !isExpired(key, now) {
+				usable = append(usable, key)
+			}
+		}
+		sort.Slice(usable, func(i, j int) bool { return usable[i].ActivatesAt.After(usable[j].ActivatesAt) })
+		findUsable.ReturnArguments = mock.Arguments{usable, nil}
+	})
+
+	return env
+}
+
+// Tests du trousseau de clés
+
+func TestNewKeyRing_BootstrapsFirstKey(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+
+	// Act
+	ring, err := NewKeyRing(env.repo, testKeyRingConfig)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, env.stored, 1)
+	assert.NotContains(t, env.stored[0].PrivateKey, "PRIVATE KEY") // chiffrée en base
+	assert.Contains(t, env.stored[0].PublicKey, "PUBLIC KEY")
+
+	key, err := ring.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, env.stored[0].ID, key.ID)
+	assert.Equal(t, jwt.SigningMethodEdDSA, key.Method())
+}
+
+func TestNewKeyRing_ReusesExistingKey(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	first, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+	firstKey, _ := first.SigningKey()
+
+	// Act
+	second, err := NewKeyRing(env.repo, testKeyRingConfig)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, env.stored, 1)
+	secondKey, _ := second.SigningKey()
+	assert.Equal(t, firstKey.ID, secondKey.ID)
+}
+
+func TestNewKeyRing_RSA(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	cfg := testKeyRingConfig
+	cfg.Algorithm = SigningAlgorithmRS256
+
+	// Act
+	ring, err := NewKeyRing(env.repo, cfg)
+
+	// Assert
+	require.NoError(t, err)
+	key, err := ring.SigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, jwt.SigningMethodRS256, key.Method())
+}
+
+func TestNewKeyRing_UnsupportedAlgorithm(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	cfg := testKeyRingConfig
+	cfg.Algorithm = "HS256"
+
+	// Act
+	ring, err := NewKeyRing(env.repo, cfg)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrUnsupportedKeyAlgorithm)
+	assert.Nil(t, ring)
+	env.repo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestRotate_PublishesBeforeSigning(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	ring, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+	current, _ := ring.SigningKey()
+
+	// Act
+	rotated, err := ring.Rotate()
+
+	// Assert
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(testKeyRingConfig.PropagationDelay), rotated.ActivatesAt, time.Second)
+
+	// La nouvelle clé est publiée mais ne signe pas encore
+	signing, _ := ring.SigningKey()
+	assert.Equal(t, current.ID, signing.ID)
+
+	keys, err := ring.VerificationKeys()
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	_, err = ring.VerificationKey(rotated.ID)
+	assert.NoError(t, err)
+}
+
+func TestRotate_OldKeyExpiresAfterOverlap(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	ring, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+	old := env.stored[0].ID
+
+	// Act
+	rotated, err := ring.Rotate()
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, env.stored[0].ExpiresAt)
+	assert.Equal(t, rotated.ActivatesAt.Add(testKeyRingConfig.Overlap), *env.stored[0].ExpiresAt)
+	assert.Nil(t, env.stored[1].ExpiresAt)
+
+	// L'ancienne clé vérifie encore les tokens pendant le recouvrement
+	_, err = ring.VerificationKey(old)
+	assert.NoError(t, err)
+}
+
+func TestSigningKey_UsesActivatedRotation(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	cfg := testKeyRingConfig
+	cfg.ReloadInterval = 0
+	ring, err := NewKeyRing(env.repo, cfg)
+	require.NoError(t, err)
+	rotated, err := ring.Rotate()
+	require.NoError(t, err)
+
+	// Act : la propagation est terminée
+	env.stored[1].ActivatesAt = time.Now()
+	key, err := ring.SigningKey()
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, key.ID)
+}
+
+func TestVerificationKey_ExpiredKeyRejected(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	cfg := testKeyRingConfig
+	cfg.ReloadInterval = 0
+	ring, err := NewKeyRing(env.repo, cfg)
+	require.NoError(t, err)
+	old := env.stored[0].ID
+	_, err = ring.Rotate()
+	require.NoError(t, err)
+
+	// Act : la période de recouvrement est écoulée
+	expired := time.Now().Add(-time.Second)
+	env.stored[0].ExpiresAt = &expired
+	key, err := ring.VerificationKey(old)
+
+	// Assert
+	assert.Equal(t, ErrUnknownSigningKey, err)
+	assert.Nil(t, key)
+	keys, _ := ring.VerificationKeys()
+	assert.Len(t, keys, 1)
+}
+
+func TestVerificationKey_UnknownKidReloadIsRateLimited(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	ring, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+	loads := len(env.repo.Calls)
+
+	// Act
+	key, err := ring.VerificationKey("forged-kid")
+
+	// Assert
+	assert.Equal(t, ErrUnknownSigningKey, err)
+	assert.Nil(t, key)
+	assert.Len(t, env.repo.Calls, loads) // chargement récent : pas de requête supplémentaire
+}
+
+func TestVerificationKey_UnknownKidTriggersReload(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	ring, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+
+	// Une autre instance a effectué une rotation
+	other, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+	rotated, err := other.Rotate()
+	require.NoError(t, err)
+	ring.(*keyRing).loadedAt = time.Now().Add(-unknownKidReloadInterval)
+
+	// Act
+	key, err := ring.VerificationKey(rotated.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, key.ID)
+}
+
+func TestNewKeyRing_WrongEncryptionKey(t *testing.T) {
+	// Arrange
+	env := newKeyRingTestEnv()
+	_, err := NewKeyRing(env.repo, testKeyRingConfig)
+	require.NoError(t, err)
+
+	cfg := testKeyRingConfig
+	cfg.EncryptionKey = "another-key"
+
+	// Act
+	ring, err := NewKeyRing(env.repo, cfg)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidStoredKey)
+	assert.Nil(t, ring)
+	assert.Len(t, env.stored, 1)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/repository"
+)
+
+var (
+	ErrAccountLocked   = errors.New("compte temporairement verrouillé après trop d'échecs de connexion")
+	ErrTooManyAttempts = errors.New("trop de tentatives de connexion, réessayez plus tard")
+	ErrNotLocked       = errors.New("aucun verrouillage en cours")
+)
+
+// ThrottleError accompagne ErrAccountLocked ou ErrTooManyAttempts du délai à
+// attendre avant la prochaine tentative
+type ThrottleError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+// Error implémente l'interface error
+func (e *ThrottleError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap permet d'utiliser errors.Is sur l'erreur sous-jacente
+func (e *ThrottleError) Unwrap() error {
+	return e.Err
+}
+
+// LockoutConfig regroupe les seuils de protection contre la force brute
+type LockoutConfig struct {
+	MaxAccountFailures int           // échecs avant verrouillage du compte
+	MaxIPFailures      int           // échecs avant blocage de l'adresse IP
+	FreeAttempts       int           // échecs tolérés avant les délais progressifs
+	BaseDelay          time.Duration // premier délai, doublé à chaque échec suivant
+	MaxDelay           time.Duration // plafond des délais progressifs
+	Window             time.Duration // fenêtre au-delà de laquelle les échecs sont oubliés
+	LockoutDuration    time.Duration // durée d'un verrouillage
+}
+
+// LockoutService définit l'interface de protection de la connexion contre la
+// force brute, par compte et par adresse IP
+type LockoutService interface {
+	Check(email, ip string) error
+	RecordFailure(email, ip string) error
+	RecordSuccess(email string) error
+	Unlock(email string) error
+	UnlockIP(ip string) error
+	PurgeStale() error
+}
+
+// lockoutService implémente LockoutService
+type lockoutService struct {
+	throttleRepo repository.LoginThrottleRepository
+	config       LockoutConfig
+}
+
+// NewLockoutService crée une nouvelle instance de LockoutService
+func NewLockoutService(throttleRepo repository.LoginThrottleRepository, config LockoutConfig) LockoutService {
+	return &lockoutService{
+		throttleRepo: throttleRepo,
+		config:       config,
+	}
+}
+
+// Check refuse la tentative si le compte ou l'adresse IP est verrouillé, ou si
+// le délai imposé depuis le dernier échec n'est pas écoulé. Les comptes
+// inexistants sont traités comme les autres pour ne pas révéler leur existence.
+func (s *lockoutService) Check(email, ip string) error {
+	if err := s.check(accountThrottleKey(email), ErrAccountLocked); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.check(ipThrottleKey(ip), ErrTooManyAttempts)
+}
+
+// check applique le verrouillage et le délai progressif d'une clé
+func (s *lockoutService) check(key string, lockedErr error) error {
+	throttle, err := s.throttleRepo.Find(key)
+	if err != nil {
+		return err
+	}
+	if throttle == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+		return &ThrottleError{Err: lockedErr, RetryAfter: throttle.LockedUntil.Sub(now)}
+	}
+	if now.Sub(throttle.LastFailureAt) > s.config.Window {
+		return nil
+	}
+
+	nextAttempt := throttle.LastFailureAt.Add(s.delay(throttle.Failures))
+	if now.Before(nextAttempt) {
+		return &ThrottleError{Err: ErrTooManyAttempts, RetryAfter: nextAttempt.Sub(now)}
+	}
+	return nil
+}
+
+// RecordFailure comptabilise un échec pour le compte et l'adresse IP, et les
+// verrouille lorsque leur seuil est atteint
+func (s *lockoutService) RecordFailure(email, ip string) error {
+	if err := s.recordFailure(accountThrottleKey(email), s.config.MaxAccountFailures); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.recordFailure(ipThrottleKey(ip), s.config.MaxIPFailures)
+}
+
+// recordFailure incrémente le compteur d'une clé et la verrouille au seuil
+func (s *lockoutService) recordFailure(key string, threshold int) error {
+	now := time.Now()
+	throttle, err := s.throttleRepo.RecordFailure(key, now, s.config.Window)
+	if err != nil {
+		return err
+	}
+
+	if threshold > 0 && throttle.Failures >= threshold {
+		log.Printf("verrouillage de %s après %d échecs de connexion", key, throttle.Failures)
+		return s.throttleRepo.Lock(key, now.Add(s.config.LockoutDuration))
+	}
+	return nil
+}
+
+// RecordSuccess remet à zéro le compteur du compte. Celui de l'adresse IP est
+// conservé : une connexion réussie ne doit pas blanchir une adresse qui teste
+// d'autres comptes.
+func (s *lockoutService) RecordSuccess(email string) error {
+	_, err := s.throttleRepo.Delete(accountThrottleKey(email))
+	return err
+}
+
+// Unlock lève le verrouillage d'un compte
+func (s *lockoutService) Unlock(email string) error {
+	return s.unlock(accountThrottleKey(email))
+}
+
+// UnlockIP lève le blocage d'une adresse IP
+func (s *lockoutService) UnlockIP(ip string) error {
+	return s.unlock(ipThrottleKey(ip))
+}
+
+// unlock supprime le compteur d'une clé
+func (s *lockoutService) unlock(key string) error {
+	deleted, err := s.throttleRepo.Delete(key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotLocked
+	}
+	return nil
+}
+
+// PurgeStale supprime les compteurs dont les échecs sont sortis de la fenêtre
+// d'observation et qui ne sont plus verrouillés : ils n'ont plus d'effet
+func (s *lockoutService) PurgeStale() error {
+	return s.throttleRepo.DeleteStale(time.Now().Add(-s.config.Window))
+}
+
+// delay calcule le délai imposé après un nombre d'échecs donné : aucun pour
+// les premiers échecs, puis un délai qui double à chaque échec jusqu'au plafond
+func (s *lockoutService) delay(failures int) time.Duration {
+	if failures <= s.config.FreeAttempts || s.config.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := s.config.BaseDelay
+	for i := s.config.FreeAttempts + 1; i < failures && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.config.MaxDelay {
+		return s.config.MaxDelay
+	}
+	return delay
+}
+
+// accountThrottleKey construit la clé du compteur d'un compte
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// ipThrottleKey construit la clé du compteur d'une adresse IP
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du LoginThrottleRepository
+type MockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleRepository) Find(key string) (*models.LoginThrottle, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) RecordFailure(key string, at time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	args := m.Called(key, at, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) Lock(key string, until time.Time) error {
+	args := m.Called(key, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) DeleteStale(lastFailureBefore time.Time) error {
+	args := m.Called(lastFailureBefore)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) Delete(key string) (bool, error) {
+	args := m.Called(key)
+	return args.Bool(0), args.Error(1)
+}
+
+// testLockoutConfig est la configuration de protection utilisée par les tests
+var testLockoutConfig = LockoutConfig{
+	MaxAccountFailures: 10,
+	MaxIPFailures:      50,
+	FreeAttempts:       3,
+	BaseDelay:          time.Second,
+	MaxDelay:           time.Minute,
+	Window:             15 * time.Minute,
+	LockoutDuration:    15 * time.Minute,
+}
+
+// Tests du service de verrouillage
+
+func TestLockoutCheck_NoFailures(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	mockRepo.On("Find", "account:test@example.com").Return(nil, nil)
+	mockRepo.On("Find", "ip:192.0.2.10").Return(nil, nil)
+
+	// Act
+	err := lockout.Check("Test@Example.com ", "192.0.2.10")
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLockoutCheck_LockedAccount(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	mockRepo.On("Find", "account:test@example.com").Return(&models.LoginThrottle{
+		Key: "account:test@example.com", Failures: 10, LastFailureAt: time.Now(), LockedUntil: &lockedUntil,
+	}, nil)
+
+	// Act
+	err := lockout.Check("test@example.com", "192.0.2.10")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	var throttled *ThrottleError
+	require.True(t, errors.As(err, &throttled))
+	assert.InDelta(t, (10 * time.Minute).Seconds(), throttled.RetryAfter.Seconds(), 1)
+	mockRepo.AssertNotCalled(t, "Find", "ip:192.0.2.10")
+}
+
+func TestLockoutCheck_LockedIPDoesNotRevealAccountLock(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	lockedUntil := time.Now().Add(10 * time.Minute)
+	mockRepo.On("Find", "account:test@example.com").Return(nil, nil)
+	mockRepo.On("Find", "ip:192.0.2.10").Return(&models.LoginThrottle{
+		Key: "ip:192.0.2.10", Failures: 50, LastFailureAt: time.Now(), LockedUntil: &lockedUntil,
+	}, nil)
+
+	// Act
+	err := lockout.Check("test@example.com", "192.0.2.10")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.False(t, errors.Is(err, ErrAccountLocked))
+}
+
+func TestLockoutCheck_ExpiredLock(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	lockedUntil := time.Now().Add(-time.Minute)
+	mockRepo.On("Find", "account:test@example.com").Return(&models.LoginThrottle{
+		Failures: 10, LastFailureAt: time.Now().Add(-16 * time.Minute), LockedUntil: &lockedUntil,
+	}, nil)
+
+	// Act
+	err := lockout.Check("test@example.com", "")
+
+	// Assert
+	assert.NoError(t, err)
+}
+
+func TestLockoutCheck_ProgressiveDelay(t *testing.T) {
+	cases := []struct {
+		name     string
+		failures int
+		elapsed  time.Duration
+		allowed  bool
+	}{
+		{"échecs tolérés", 3, 0, true},
+		{"premier délai non écoulé", 4, 500 * time.Millisecond, false},
+		{"premier délai écoulé", 4, 2 * time.Second, true},
+		{"délai doublé", 6, 3 * time.Second, false},
+		{"délai plafonné", 9, 61 * time.Second, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(MockLoginThrottleRepository)
+			lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+			mockRepo.On("Find", "account:test@example.com").Return(&models.LoginThrottle{
+				Failures: tc.failures, LastFailureAt: time.Now().Add(-tc.elapsed),
+			}, nil)
+
+			// Act
+			err := lockout.Check("test@example.com", "")
+
+			// Assert
+			if tc.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrTooManyAttempts)
+			}
+		})
+	}
+}
+
+func TestLockoutRecordFailure_LocksAtThreshold(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	mockRepo.On("RecordFailure", "account:test@example.com", mock.AnythingOfType("time.Time"), testLockoutConfig.Window).
+		Return(&models.LoginThrottle{Failures: 10}, nil)
+	mockRepo.On("RecordFailure", "ip:192.0.2.10", mock.AnythingOfType("time.Time"), testLockoutConfig.Window).
+		Return(&models.LoginThrottle{Failures: 10}, nil)
+	mockRepo.On("Lock", "account:test@example.com", mock.MatchedBy(func(until time.Time) bool {
+		return until.Sub(time.Now()) > 14*time.Minute
+	})).Return(nil)
+
+	// Act
+	err := lockout.RecordFailure("test@example.com", "192.0.2.10")
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Lock", "ip:192.0.2.10", mock.Anything)
+}
+
+func TestLockoutRecordSuccess_ResetsAccountOnly(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	mockRepo.On("Delete", "account:test@example.com").Return(true, nil)
+
+	// Act
+	err := lockout.RecordSuccess("test@example.com")
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestLockoutUnlock(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+
+	mockRepo.On("Delete", "account:test@example.com").Return(true, nil)
+	mockRepo.On("Delete", "ip:192.0.2.10").Return(false, nil)
+
+	// Act & Assert
+	assert.NoError(t, lockout.Unlock("test@example.com"))
+	assert.Equal(t, ErrNotLocked, lockout.UnlockIP("192.0.2.10"))
+}
+
+func TestLockoutPurgeStale_UsesWindow(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockLoginThrottleRepository)
+	lockout := NewLockoutService(mockRepo, testLockoutConfig)
+	before := time.Now()
+
+	mockRepo.On("DeleteStale", mock.MatchedBy(func(lastFailureBefore time.Time) bool {
+		// Les échecs encore dans la fenêtre sont conservés
+		return lastFailureBefore.Before(before.Add(-testLockoutConfig.Window).Add(time.Second)) &&
+			lastFailureBefore.After(before.Add(-testLockoutConfig.Window).Add(-time.Second))
+	})).Return(nil)
+
+	// Act
+	err := lockout.PurgeStale()
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
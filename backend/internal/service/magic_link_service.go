@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+)
+
+var (
+	ErrInvalidMagicLink = errors.New("lien de connexion invalide ou expiré")
+)
+
+// MagicLinkService définit l'interface de la connexion sans mot de passe par lien envoyé par email
+type MagicLinkService interface {
+	RequestLink(email string) error
+	Login(token string, client ClientInfo) (*LoginResult, error)
+}
+
+// magicLinkService implémente MagicLinkService
+type magicLinkService struct {
+	userRepo       repository.UserRepository
+	magicLinkRepo  repository.MagicLinkRepository
+	authService    AuthService
+	lockoutService LockoutService
+	mailer         mailer.Mailer
+	frontendURL    string
+	tokenDuration  time.Duration
+}
+
+// NewMagicLinkService crée une nouvelle instance de MagicLinkService
+func NewMagicLinkService(
+	userRepo repository.UserRepository,
+	magicLinkRepo repository.MagicLinkRepository,
+	authService AuthService,
+	lockoutService LockoutService,
+	mailer mailer.Mailer,
+	frontendURL string,
+	tokenDuration time.Duration,
+) MagicLinkService {
+	return &magicLinkService{
+		userRepo:       userRepo,
+		magicLinkRepo:  magicLinkRepo,
+		authService:    authService,
+		lockoutService: lockoutService,
+		mailer:         mailer,
+		frontendURL:    frontendURL,
+		tokenDuration:  tokenDuration,
+	}
+}
+
+// RequestLink envoie un lien de connexion si l'email correspond à un compte actif.
+// Aucune erreur n'est retournée pour un email inconnu ou un compte désactivé,
+// afin de ne pas révéler quels emails sont inscrits.
+func (s *magicLinkService) RequestLink(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.DisabledAt != nil {
+		return nil
+	}
+
+	// Un seul lien valide à la fois par utilisateur
+	if err := s.magicLinkRepo.InvalidateForUser(user.ID); err != nil {
+		return err
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.magicLinkRepo.Create(&models.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.tokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/magic-link?token=%s", s.frontendURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Votre lien de connexion Collec-App",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"Pour vous connecter à Collec-App sans mot de passe, ouvrez le lien suivant (valable %d minutes, utilisable une seule fois) :\n\n"+
+			"%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet email : personne ne pourra se connecter sans ce lien.\n",
+			int(s.tokenDuration.Minutes()), link),
+	})
+}
+
+// Login consomme le lien de connexion puis applique les mêmes règles que la
+// connexion par mot de passe : verrouillage, compte actif et double
+// authentification si activée. Le lien ayant été reçu à l'adresse du compte,
+// son utilisation vérifie l'email ; un compte encore non vérifié a pu être créé
+// par un tiers et en est repris comme pour un fournisseur externe.
+func (s *magicLinkService) Login(token string, client ClientInfo) (*LoginResult, error) {
+	stored, err := s.magicLinkRepo.FindByHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidMagicLink
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidMagicLink
+	}
+
+	// Un compte verrouillé ne peut pas contourner le verrouillage par email ;
+	// le lien n'est pas consommé et reste utilisable jusqu'à son expiration
+	if err := s.lockoutService.Check(user.Email, client.IP); err != nil {
+		return nil, err
+	}
+
+	used, err := s.magicLinkRepo.MarkUsed(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidMagicLink
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err := claimUnverifiedAccount(s.userRepo, user, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := s.authService.LoginWithUser(user, client)
+	if errors.Is(err, ErrInvalidCredentials) {
+		// Compte dont la suppression attend la purge
+		return nil, ErrInvalidMagicLink
+	}
+	return result, err
+}
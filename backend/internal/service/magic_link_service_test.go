@@ -0,0 +1,311 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du MagicLinkRepository
+type MockMagicLinkRepository struct {
+	mock.Mock
+}
+
+func (m *MockMagicLinkRepository) Create(token *models.MagicLinkToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkRepository) FindByHash(tokenHash string) (*models.MagicLinkToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MagicLinkToken), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMagicLinkRepository) DeleteExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockMagicLinkRepository) InvalidateForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// magicLinkTestEnv regroupe le service de connexion par lien et ses mocks
+type magicLinkTestEnv struct {
+	userRepo      *MockUserRepository
+	magicLinkRepo *MockMagicLinkRepository
+	authService   *MockAuthService
+	lockout       *MockLockoutService
+	mailer        *MockMailer
+	service       MagicLinkService
+}
+
+// Helper function pour créer le service de connexion par lien et ses mocks
+func newMagicLinkTestEnv(lockout *MockLockoutService) *magicLinkTestEnv {
+	env := &magicLinkTestEnv{
+		userRepo:      new(MockUserRepository),
+		magicLinkRepo: new(MockMagicLinkRepository),
+		authService:   new(MockAuthService),
+		lockout:       lockout,
+		mailer:        new(MockMailer),
+	}
+	env.service = NewMagicLinkService(env.userRepo, env.magicLinkRepo, env.authService, env.lockout, env.mailer, "http://localhost:3000", 15*time.Minute)
+	return env
+}
+
+// Helper function pour créer un lien valide en base pour l'utilisateur
+func newStoredMagicLink(token string, userID uuid.UUID) *models.MagicLinkToken {
+	return &models.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+	}
+}
+
+// Tests de la demande de lien
+
+func TestRequestMagicLink_SendsLinkWithStoredHash(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	var storedHash string
+	var sent mailer.Message
+
+	env.userRepo.On("FindByEmail", user.Email).Return(user, nil)
+	env.magicLinkRepo.On("InvalidateForUser", user.ID).Return(nil)
+	env.magicLinkRepo.On("Create", mock.MatchedBy(func(token *models.MagicLinkToken) bool {
+		storedHash = token.TokenHash
+		return token.UserID == user.ID && token.ExpiresAt.Before(time.Now().Add(16*time.Minute))
+	})).Return(nil)
+	env.mailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		sent = msg
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	err := env.service.RequestLink(user.Email)
+
+	// Assert
+	require.NoError(t, err)
+	env.magicLinkRepo.AssertExpectations(t)
+	env.mailer.AssertExpectations(t)
+
+	// Le lien contient le token en clair, seul son hash est stocké
+	token := tokenFromEmail(sent.Body, "http://localhost:3000/auth/magic-link")
+	assert.NotEmpty(t, token)
+	assert.Equal(t, hashToken(token), storedHash)
+}
+
+func TestRequestMagicLink_SilentForUnknownOrDisabledAccount(t *testing.T) {
+	disabledAt := time.Now()
+	cases := []struct {
+		name string
+		user *models.User
+	}{
+		{"email inconnu", nil},
+		{"compte désactivé", &models.User{ID: uuid.New(), Email: "test@example.com", DisabledAt: &disabledAt}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newMagicLinkTestEnv(newTestLockout())
+			if tc.user == nil {
+				env.userRepo.On("FindByEmail", "test@example.com").Return(nil, nil)
+			} else {
+				env.userRepo.On("FindByEmail", "test@example.com").Return(tc.user, nil)
+			}
+
+			err := env.service.RequestLink("test@example.com")
+
+			assert.NoError(t, err)
+			env.magicLinkRepo.AssertNotCalled(t, "Create", mock.Anything)
+			env.mailer.AssertNotCalled(t, "Send", mock.Anything)
+		})
+	}
+}
+
+// Tests de la connexion par lien
+
+func TestMagicLinkLogin_ConsumesLinkAndLogsIn(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	stored := newStoredMagicLink("magic-token", user.ID)
+	client := ClientInfo{IP: "192.0.2.1", RememberMe: true}
+	expected := &LoginResult{AccessToken: "access", RefreshToken: "refresh", User: user}
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.magicLinkRepo.On("MarkUsed", stored.ID).Return(true, nil)
+	env.authService.On("LoginWithUser", user, client).Return(expected, nil)
+
+	// Act
+	result, err := env.service.Login("magic-token", client)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	env.magicLinkRepo.AssertExpectations(t)
+	env.authService.AssertExpectations(t)
+}
+
+func TestMagicLinkLogin_VerifiesEmail(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	// Compte créé par un tiers avec un mot de passe et une double authentification
+	totpEnabledAt := time.Now().Add(-time.Hour)
+	pendingEmail := "attacker@example.com"
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", Password: "attacker-hash", TOTPSecret: "attacker-secret", TOTPEnabledAt: &totpEnabledAt, PendingEmail: &pendingEmail}
+	stored := newStoredMagicLink("magic-token", user.ID)
+	expected := &LoginResult{AccessToken: "access", RefreshToken: "refresh", User: user}
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.magicLinkRepo.On("MarkUsed", stored.ID).Return(true, nil)
+	env.userRepo.On("ClaimUnverified", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	// La vérification exigée de l'email ne fait plus obstacle à la connexion, et
+	// la double authentification du tiers ne s'applique plus
+	env.authService.On("LoginWithUser", mock.MatchedBy(func(u *models.User) bool {
+		return u.EmailVerifiedAt != nil && u.TOTPEnabledAt == nil
+	}), mock.Anything).Return(expected, nil)
+
+	// Act
+	result, err := env.service.Login("magic-token", ClientInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	assert.Empty(t, user.Password)
+	assert.Empty(t, user.TOTPSecret)
+	assert.Nil(t, user.PendingEmail)
+	env.userRepo.AssertExpectations(t)
+	env.userRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+}
+
+func TestMagicLinkLogin_MFARequired(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	stored := newStoredMagicLink("magic-token", user.ID)
+	pending := &LoginResult{MFAToken: "mfa-pending", User: user}
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.magicLinkRepo.On("MarkUsed", stored.ID).Return(true, nil)
+	env.authService.On("LoginWithUser", user, mock.Anything).Return(pending, nil)
+
+	// Act
+	result, err := env.service.Login("magic-token", ClientInfo{})
+
+	// Assert : le second facteur reste exigé
+	require.NoError(t, err)
+	assert.True(t, result.MFARequired())
+	assert.Empty(t, result.AccessToken)
+}
+
+func TestMagicLinkLogin_InvalidLink(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	cases := []struct {
+		name   string
+		stored *models.MagicLinkToken
+	}{
+		{"lien inconnu", nil},
+		{"lien déjà utilisé", &models.MagicLinkToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute), UsedAt: &usedAt}},
+		{"lien expiré", &models.MagicLinkToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			env := newMagicLinkTestEnv(newTestLockout())
+			if tc.stored == nil {
+				env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(nil, nil)
+			} else {
+				env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(tc.stored, nil)
+			}
+
+			result, err := env.service.Login("magic-token", ClientInfo{})
+
+			assert.Nil(t, result)
+			assert.Equal(t, ErrInvalidMagicLink, err)
+			env.authService.AssertNotCalled(t, "LoginWithUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestMagicLinkLogin_ConcurrentUseRefused(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	stored := newStoredMagicLink("magic-token", user.ID)
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.magicLinkRepo.On("MarkUsed", stored.ID).Return(false, nil)
+
+	// Act
+	_, err := env.service.Login("magic-token", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidMagicLink, err)
+	env.authService.AssertNotCalled(t, "LoginWithUser", mock.Anything, mock.Anything)
+}
+
+func TestMagicLinkLogin_LockedAccountKeepsLink(t *testing.T) {
+	// Arrange
+	lockout := new(MockLockoutService)
+	env := newMagicLinkTestEnv(lockout)
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	stored := newStoredMagicLink("magic-token", user.ID)
+	locked := &ThrottleError{Err: ErrAccountLocked, RetryAfter: 10 * time.Minute}
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	lockout.On("Check", user.Email, "192.0.2.1").Return(locked)
+
+	// Act
+	_, err := env.service.Login("magic-token", ClientInfo{IP: "192.0.2.1"})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	env.magicLinkRepo.AssertNotCalled(t, "MarkUsed", mock.Anything)
+	env.authService.AssertNotCalled(t, "LoginWithUser", mock.Anything, mock.Anything)
+}
+
+func TestMagicLinkLogin_AccountAwaitingPurge(t *testing.T) {
+	// Arrange
+	env := newMagicLinkTestEnv(newTestLockout())
+	verifiedAt := time.Now().Add(-time.Hour)
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", EmailVerifiedAt: &verifiedAt}
+	stored := newStoredMagicLink("magic-token", user.ID)
+
+	env.magicLinkRepo.On("FindByHash", hashToken("magic-token")).Return(stored, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.magicLinkRepo.On("MarkUsed", stored.ID).Return(true, nil)
+	env.authService.On("LoginWithUser", user, mock.Anything).Return(nil, ErrInvalidCredentials)
+
+	// Act
+	_, err := env.service.Login("magic-token", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidMagicLink, err)
+}
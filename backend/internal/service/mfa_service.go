@@ -0,0 +1,360 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/password"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/arnaud-dars/collec-app/internal/totp"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidMFACode    = errors.New("code de double authentification invalide")
+	ErrMFAAlreadyEnabled = errors.New("la double authentification est déjà activée")
+	ErrMFANotEnabled     = errors.New("la double authentification n'est pas activée")
+	ErrMFANotEnrolled    = errors.New("aucune configuration de double authentification en attente")
+)
+
+const (
+	// recoveryCodeCount est le nombre de codes de secours générés à la fois
+	recoveryCodeCount = 10
+	// totpSkew est le nombre de périodes de décalage d'horloge tolérées
+	totpSkew = 1
+)
+
+// TOTPEnrollment contient les informations à présenter à l'utilisateur pour
+// configurer son application d'authentification
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// MFAStatus décrit l'état de la double authentification d'un utilisateur
+type MFAStatus struct {
+	Enabled                bool
+	RemainingRecoveryCodes int64
+}
+
+// MFAService définit l'interface de gestion de la double authentification (TOTP)
+type MFAService interface {
+	Status(userID uuid.UUID) (*MFAStatus, error)
+	Enroll(userID uuid.UUID) (*TOTPEnrollment, error)
+	Confirm(userID uuid.UUID, code string) ([]string, error)
+	Disable(userID uuid.UUID, password, code string) error
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error)
+	Verify(user *models.User, code string) error
+}
+
+// mfaService implémente MFAService
+type mfaService struct {
+	userRepo         repository.UserRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	secrets          secretBox
+	passwordHasher   password.Hasher
+	issuer           string
+}
+
+// NewMFAService crée une nouvelle instance de MFAService.
+// Les secrets TOTP sont chiffrés en base avec une clé dérivée de encryptionKey.
+func NewMFAService(
+	userRepo repository.UserRepository,
+	recoveryCodeRepo repository.RecoveryCodeRepository,
+	issuer string,
+	encryptionKey string,
+	passwordHasher password.Hasher,
+) MFAService {
+	return &mfaService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		secrets:          newSecretBox(encryptionKey),
+		passwordHasher:   passwordHasher,
+		issuer:           issuer,
+	}
+}
+
+// Status retourne l'état de la double authentification de l'utilisateur
+func (s *mfaService) Status(userID uuid.UUID) (*MFAStatus, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Enabled: user.TOTPEnabledAt != nil}
+	if status.Enabled {
+		status.RemainingRecoveryCodes, err = s.recoveryCodeRepo.CountRemaining(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// Enroll génère un nouveau secret TOTP en attente de confirmation.
+// Un enrôlement précédent non confirmé est remplacé.
+func (s *mfaService) Enroll(userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.secrets.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.userRepo.SetPendingTOTPSecret(userID, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if !stored {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// Confirm active la double authentification si le code correspond au secret
+// en attente et retourne les codes de secours, affichés une seule fois
+func (s *mfaService) Confirm(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFANotEnrolled
+	}
+
+	secret, err := s.secrets.open(user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, normalizeMFACode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	enabled, err := s.userRepo.EnableTOTP(userID, time.Now(), step)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// Disable désactive la double authentification après vérification du mot de
+// passe et d'un code (TOTP ou code de secours)
+func (s *mfaService) Disable(userID uuid.UUID, password, code string) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	if valid, err := s.passwordHasher.Verify(password, user.Password); err != nil || !valid {
+		return ErrInvalidCredentials
+	}
+
+	if err := s.Verify(user, code); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.DisableTOTP(userID); err != nil {
+		return err
+	}
+	return s.recoveryCodeRepo.DeleteForUser(userID)
+}
+
+// RegenerateRecoveryCodes remplace les codes de secours après vérification d'un code
+func (s *mfaService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.Verify(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// Verify vérifie le second facteur d'un utilisateur : un code TOTP, qui ne peut
+// être utilisé qu'une fois, ou à défaut un code de secours, qui est consommé
+func (s *mfaService) Verify(user *models.User, code string) error {
+	if user.TOTPEnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+
+	code = normalizeMFACode(code)
+
+	if len(code) == totp.Digits {
+		secret, err := s.secrets.open(user.TOTPSecret)
+		if err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+		if !ok || step <= user.TOTPLastStep {
+			return ErrInvalidMFACode
+		}
+
+		// Refuser le rejeu d'un code déjà accepté, y compris par une requête concurrente
+		used, err := s.userRepo.MarkTOTPStepUsed(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	consumed, err := s.recoveryCodeRepo.Consume(user.ID, hashToken(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// findUser charge l'utilisateur authentifié
+func (s *mfaService) findUser(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	return user, nil
+}
+
+// replaceRecoveryCodes génère de nouveaux codes de secours et n'en conserve que les hash
+func (s *mfaService) replaceRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashToken(normalizeMFACode(code))
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// generateRecoveryCode génère un code de secours de 80 bits au format XXXX-XXXX-XXXX-XXXX
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, 10)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	encoded := base32.StdEncoding.EncodeToString(raw)
+	return encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16], nil
+}
+
+// normalizeMFACode supprime les séparateurs et la casse saisis par l'utilisateur
+func normalizeMFACode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
+
+// secretBox chiffre les secrets stockés en base (AES-256-GCM)
+type secretBox struct {
+	key [32]byte
+}
+
+// newSecretBox dérive la clé de chiffrement à partir de la clé configurée
+func newSecretBox(key string) secretBox {
+	return secretBox{key: sha256.Sum256([]byte(key))}
+}
+
+// seal chiffre une valeur et retourne nonce + texte chiffré encodés en base64
+func (b secretBox) seal(plaintext string) (string, error) {
+	gcm, err := b.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open déchiffre une valeur produite par seal
+func (b secretBox) open(ciphertext string) (string, error) {
+	gcm, err := b.aead()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("secret chiffré invalide")
+	}
+
+	plaintext, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// aead construit le chiffrement authentifié à partir de la clé
+func (b secretBox) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(b.key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/totp"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du RecoveryCodeRepository
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	args := m.Called(userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) Consume(userID uuid.UUID, codeHash string) (bool, error) {
+	args := m.Called(userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) CountRemaining(userID uuid.UUID) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRecoveryCodeRepository) DeleteForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+const testMFAKey = "test-mfa-key"
+
+// Helper function pour créer un utilisateur dont la double authentification est activée
+func newTestMFAUser(t *testing.T, secret string) *models.User {
+	sealed, err := newSecretBox(testMFAKey).seal(secret)
+	require.NoError(t, err)
+
+	enabledAt := time.Now()
+	return &models.User{ID: uuid.New(), Email: "test@example.com", TOTPSecret: sealed, TOTPEnabledAt: &enabledAt}
+}
+
+// Tests du service MFA
+
+func TestMFAEnroll_StoresEncryptedSecret(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	var stored string
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("SetPendingTOTPSecret", user.ID, mock.MatchedBy(func(secret string) bool {
+		stored = secret
+		return true
+	})).Return(true, nil)
+
+	// Act
+	enrollment, err := mfaService.Enroll(user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, stored)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/Collec-App:test@example.com?"))
+
+	opened, err := newSecretBox(testMFAKey).open(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, opened)
+}
+
+func TestMFAEnroll_AlreadyEnabled(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := newTestMFAUser(t, "JBSWY3DPEHPK3PXP")
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	enrollment, err := mfaService.Enroll(user.ID)
+
+	// Assert
+	assert.Equal(t, ErrMFAAlreadyEnabled, err)
+	assert.Nil(t, enrollment)
+	mockRepo.AssertNotCalled(t, "SetPendingTOTPSecret", mock.Anything, mock.Anything)
+}
+
+func TestMFAConfirm_EnablesAndReturnsRecoveryCodes(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(mockRepo, mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	secret, _ := totp.GenerateSecret()
+	user := newTestMFAUser(t, secret)
+	user.TOTPEnabledAt = nil // enrôlement en attente
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	var storedHashes []string
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("EnableTOTP", user.ID, mock.AnythingOfType("time.Time"), mock.AnythingOfType("int64")).Return(true, nil)
+	mockCodes.On("ReplaceForUser", user.ID, mock.MatchedBy(func(hashes []string) bool {
+		storedHashes = hashes
+		return true
+	})).Return(nil)
+
+	// Act
+	codes, err := mfaService.Confirm(user.ID, code)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	require.Len(t, storedHashes, recoveryCodeCount)
+
+	// Seuls les hash des codes normalisés sont stockés
+	assert.Equal(t, hashToken(normalizeMFACode(codes[0])), storedHashes[0])
+	assert.NotContains(t, storedHashes, codes[0])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMFAConfirm_InvalidCode(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(mockRepo, mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	secret, _ := totp.GenerateSecret()
+	user := newTestMFAUser(t, secret)
+	user.TOTPEnabledAt = nil
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	codes, err := mfaService.Confirm(user.ID, "not-a-code")
+
+	// Assert
+	assert.Equal(t, ErrInvalidMFACode, err)
+	assert.Nil(t, codes)
+	mockRepo.AssertNotCalled(t, "EnableTOTP", mock.Anything, mock.Anything, mock.Anything)
+	mockCodes.AssertNotCalled(t, "ReplaceForUser", mock.Anything, mock.Anything)
+}
+
+func TestMFAConfirm_NotEnrolled(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	_, err := mfaService.Confirm(user.ID, "123456")
+
+	// Assert
+	assert.Equal(t, ErrMFANotEnrolled, err)
+}
+
+func TestMFAVerify_TOTPCode(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	secret, _ := totp.GenerateSecret()
+	user := newTestMFAUser(t, secret)
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+
+	mockRepo.On("MarkTOTPStepUsed", user.ID, step).Return(true, nil)
+
+	// Act
+	err := mfaService.Verify(user, code[:3]+" "+code[3:])
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMFAVerify_RejectsReplayedTOTPCode(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	secret, _ := totp.GenerateSecret()
+	user := newTestMFAUser(t, secret)
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+	user.TOTPLastStep = step // code déjà utilisé
+
+	// Act
+	err := mfaService.Verify(user, code)
+
+	// Assert
+	assert.Equal(t, ErrInvalidMFACode, err)
+	mockRepo.AssertNotCalled(t, "MarkTOTPStepUsed", mock.Anything, mock.Anything)
+}
+
+func TestMFAVerify_ConcurrentTOTPUse(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mfaService := NewMFAService(mockRepo, new(MockRecoveryCodeRepository), "Collec-App", testMFAKey, testPasswordHasher)
+
+	secret, _ := totp.GenerateSecret()
+	user := newTestMFAUser(t, secret)
+	step := totp.Step(time.Now())
+	code, _ := totp.Code(secret, step)
+
+	mockRepo.On("MarkTOTPStepUsed", user.ID, step).Return(false, nil)
+
+	// Act
+	err := mfaService.Verify(user, code)
+
+	// Assert
+	assert.Equal(t, ErrInvalidMFACode, err)
+}
+
+func TestMFAVerify_RecoveryCode(t *testing.T) {
+	// Arrange
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(new(MockUserRepository), mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := newTestMFAUser(t, "JBSWY3DPEHPK3PXP")
+	mockCodes.On("Consume", user.ID, hashToken("ABCDEFGHIJKLMNOP")).Return(true, nil)
+
+	// Act
+	err := mfaService.Verify(user, "abcd-efgh-ijkl-mnop")
+
+	// Assert
+	assert.NoError(t, err)
+	mockCodes.AssertExpectations(t)
+}
+
+func TestMFAVerify_UsedRecoveryCode(t *testing.T) {
+	// Arrange
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(new(MockUserRepository), mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := newTestMFAUser(t, "JBSWY3DPEHPK3PXP")
+	mockCodes.On("Consume", user.ID, mock.AnythingOfType("string")).Return(false, nil)
+
+	// Act
+	err := mfaService.Verify(user, "ABCD-EFGH-IJKL-MNOP")
+
+	// Assert
+	assert.Equal(t, ErrInvalidMFACode, err)
+}
+
+func TestMFADisable_RequiresPassword(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(mockRepo, mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := newTestMFAUser(t, "JBSWY3DPEHPK3PXP")
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user.Password = hashedPassword
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	err := mfaService.Disable(user.ID, "wrongpassword", "123456")
+
+	// Assert
+	assert.Equal(t, ErrInvalidCredentials, err)
+	mockRepo.AssertNotCalled(t, "DisableTOTP", mock.Anything)
+	mockCodes.AssertNotCalled(t, "DeleteForUser", mock.Anything)
+}
+
+func TestMFADisable_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockCodes := new(MockRecoveryCodeRepository)
+	mfaService := NewMFAService(mockRepo, mockCodes, "Collec-App", testMFAKey, testPasswordHasher)
+
+	user := newTestMFAUser(t, "JBSWY3DPEHPK3PXP")
+	hashedPassword, _ := testPasswordHasher.Hash("password123")
+	user.Password = hashedPassword
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockCodes.On("Consume", user.ID, mock.AnythingOfType("string")).Return(true, nil)
+	mockRepo.On("DisableTOTP", user.ID).Return(nil)
+	mockCodes.On("DeleteForUser", user.ID).Return(nil)
+
+	// Act
+	err := mfaService.Disable(user.ID, "password123", "ABCD-EFGH-IJKL-MNOP")
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockCodes.AssertExpectations(t)
+}
+
+func TestSecretBox_RejectsOtherKey(t *testing.T) {
+	// Arrange
+	sealed, err := newSecretBox("key-a").seal("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+
+	// Act
+	_, err = newSecretBox("key-b").open(sealed)
+
+	// Assert
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/oidc"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+)
+
+var (
+	ErrUnknownProvider      = errors.New("fournisseur d'identité inconnu")
+	ErrInvalidOIDCState     = errors.New("demande de connexion invalide ou expirée")
+	ErrOIDCEmailNotVerified = errors.New("le fournisseur n'a pas confirmé l'adresse email")
+	ErrOIDCLoginFailed      = errors.New("connexion via le fournisseur impossible")
+)
+
+// OIDCAuthorization contient l'URL vers laquelle rediriger l'utilisateur et le
+// state à comparer à celui reçu au retour du fournisseur
+type OIDCAuthorization struct {
+	URL       string
+	State     string
+	ExpiresAt time.Time // fin de validité du state
+}
+
+// OIDCService définit l'interface de connexion via des fournisseurs OpenID Connect
+type OIDCService interface {
+	Providers() []string
+	Authorize(ctx context.Context, provider string) (*OIDCAuthorization, error)
+	Callback(ctx context.Context, provider, code, state string, client ClientInfo) (*LoginResult, error)
+}
+
+// oidcService implémente OIDCService
+type oidcService struct {
+	providers    map[string]*oidc.Provider
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	requestRepo  repository.OIDCAuthRequestRepository
+	authService  AuthService
+	requestTTL   time.Duration
+}
+
+// NewOIDCService crée une nouvelle instance de OIDCService
+func NewOIDCService(
+	providers []*oidc.Provider,
+	userRepo repository.UserRepository,
+	identityRepo repository.UserIdentityRepository,
+	requestRepo repository.OIDCAuthRequestRepository,
+	authService AuthService,
+	requestTTL time.Duration,
+) OIDCService {
+	byName := make(map[string]*oidc.Provider, len(providers))
+	for _, provider := range providers {
+		byName[provider.Name()] = provider
+	}
+
+	return &oidcService{
+		providers:    byName,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		requestRepo:  requestRepo,
+		authService:  authService,
+		requestTTL:   requestTTL,
+	}
+}
+
+// Providers retourne le nom des fournisseurs configurés
+func (s *oidcService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Authorize démarre une connexion : le state, le nonce et le code verifier
+// PKCE sont conservés côté serveur et l'URL d'autorisation est retournée
+func (s *oidcService) Authorize(ctx context.Context, providerName string) (*OIDCAuthorization, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	// Nettoyer les demandes abandonnées ; un échec n'empêche pas la connexion
+	if err := s.requestRepo.DeleteExpired(); err != nil {
+		log.Printf("nettoyage des demandes OIDC expirées impossible: %v", err)
+	}
+
+	state, stateHash, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallengeS256(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	expiresAt := time.Now().Add(s.requestTTL)
+	if err := s.requestRepo.Create(&models.OIDCAuthRequest{
+		StateHash:    stateHash,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    expiresAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &OIDCAuthorization{URL: authURL, State: state, ExpiresAt: expiresAt}, nil
+}
+
+// Callback termine une connexion au retour du fournisseur : le code est échangé,
+// l'identité vérifiée puis rattachée à un utilisateur, qui est connecté selon
+// les mêmes règles qu'une connexion par mot de passe
+func (s *oidcService) Callback(ctx context.Context, providerName, code, state string, client ClientInfo) (*LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	request, err := s.requestRepo.FindByStateHash(hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if request == nil || request.Provider != providerName || time.Now().After(request.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	// Consommer la demande avant l'échange : un state ne sert qu'une fois
+	consumed, err := s.requestRepo.Delete(request.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidOIDCState
+	}
+
+	tokens, err := provider.Exchange(ctx, code, request.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	identity, err := provider.Identity(ctx, tokens, request.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	user, err := s.resolveUser(providerName, identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.LoginWithUser(user, client)
+}
+
+// resolveUser retrouve l'utilisateur lié à l'identité externe. À la première
+// connexion, l'identité est rattachée au compte ayant la même adresse email,
+// à condition que le fournisseur l'ait vérifiée ; à défaut un compte est créé.
+func (s *oidcService) resolveUser(providerName string, identity *oidc.Identity) (*models.User, error) {
+	now := time.Now()
+
+	linked, err := s.identityRepo.FindByProviderSubject(providerName, identity.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if linked != nil {
+		user, err := s.userRepo.FindByID(linked.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrOIDCLoginFailed
+		}
+		if err := s.identityRepo.UpdateLastLogin(linked.ID, now); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.userRepo.FindByEmail(identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		// Pas de mot de passe : l'utilisateur pourra en définir un via "mot de passe oublié"
+		user = &models.User{
+			Email:           identity.Email,
+			EmailVerifiedAt: &now,
+		}
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, err
+		}
+	} else if user.EmailVerifiedAt == nil {
+		// Le compte existant n'a jamais prouvé la possession de l'adresse : il a pu
+		// être créé par un tiers, dont l'accès est retiré avant de le rattacher au
+		// propriétaire vérifié de l'adresse
+		if err := claimUnverifiedAccount(s.userRepo, user, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.identityRepo.Create(&models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    providerName,
+		Subject:     identity.Subject,
+		Email:       identity.Email,
+		LastLoginAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
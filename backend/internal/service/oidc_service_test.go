@@ -0,0 +1,394 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/oidc"
+	"github.com/arnaud-dars/collec-app/internal/oidc/oidctest"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du UserIdentityRepository
+type MockUserIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepository) Create(identity *models.UserIdentity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepository) FindByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	args := m.Called(provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) FindByUserID(userID uuid.UUID) ([]models.UserIdentity, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) UpdateLastLogin(id uuid.UUID, lastLoginAt time.Time) error {
+	args := m.Called(id, lastLoginAt)
+	return args.Error(0)
+}
+
+// Mock du OIDCAuthRequestRepository
+type MockOIDCAuthRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCAuthRequestRepository) Create(request *models.OIDCAuthRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockOIDCAuthRequestRepository) FindByStateHash(stateHash string) (*models.OIDCAuthRequest, error) {
+	args := m.Called(stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OIDCAuthRequest), args.Error(1)
+}
+
+func (m *MockOIDCAuthRequestRepository) Delete(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOIDCAuthRequestRepository) DeleteExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+// Mock du AuthService
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Register(email, password string, client ClientInfo) (*models.User, error) {
+	args := m.Called(email, password, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAuthService) Login(email, password string, client ClientInfo) (*LoginResult, error) {
+	args := m.Called(email, password, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockAuthService) CompleteMFALogin(mfaToken, code string, client ClientInfo) (*LoginResult, error) {
+	args := m.Called(mfaToken, code, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockAuthService) LoginWithUser(user *models.User, client ClientInfo) (*LoginResult, error) {
+	args := m.Called(user, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResult), args.Error(1)
+}
+
+func (m *MockAuthService) RefreshToken(refreshToken string, client ClientInfo) (string, string, error) {
+	args := m.Called(refreshToken, client)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) ValidateAccessToken(token string) (*JWTClaims, error) {
+	args := m.Called(token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*JWTClaims), args.Error(1)
+}
+
+func (m *MockAuthService) Logout(claims *JWTClaims, refreshToken string) error {
+	args := m.Called(claims, refreshToken)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) LogoutEverywhere(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// oidcTestEnv regroupe le fournisseur de test et les mocks du service OIDC
+type oidcTestEnv struct {
+	server       *oidctest.Server
+	userRepo     *MockUserRepository
+	identityRepo *MockUserIdentityRepository
+	requestRepo  *MockOIDCAuthRequestRepository
+	authService  *MockAuthService
+	service      OIDCService
+	stored       *models.OIDCAuthRequest
+}
+
+// Helper function pour créer le service OIDC branché sur un fournisseur de test
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	server := oidctest.NewServer("client-id", "client-secret")
+	t.Cleanup(server.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "mock",
+		Issuer:       server.Issuer(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:3000/auth/oidc/mock/callback",
+	}, server.Client())
+
+	env := &oidcTestEnv{
+		server:       server,
+		userRepo:     new(MockUserRepository),
+		identityRepo: new(MockUserIdentityRepository),
+		requestRepo:  new(MockOIDCAuthRequestRepository),
+		authService:  new(MockAuthService),
+		stored:       &models.OIDCAuthRequest{},
+	}
+	env.service = NewOIDCService([]*oidc.Provider{provider}, env.userRepo, env.identityRepo, env.requestRepo, env.authService, 10*time.Minute)
+
+	// La demande d'autorisation enregistrée est retrouvée par le hash du state
+	env.requestRepo.On("DeleteExpired").Return(nil)
+	env.requestRepo.On("Create", mock.MatchedBy(func(request *models.OIDCAuthRequest) bool {
+		request.ID = uuid.New()
+		*env.stored = *request
+		return true
+	})).Return(nil)
+	env.requestRepo.On("FindByStateHash", mock.MatchedBy(func(stateHash string) bool {
+		return stateHash == env.stored.StateHash
+	})).Return(env.stored, nil)
+	env.requestRepo.On("Delete", mock.AnythingOfType("uuid.UUID")).Return(true, nil).Once()
+
+	return env
+}
+
+// authorize démarre une connexion et simule l'authentification sur le fournisseur
+func (env *oidcTestEnv) authorize(t *testing.T, claims oidctest.Claims) (code, state string) {
+	authorization, err := env.service.Authorize(context.Background(), "mock")
+	require.NoError(t, err)
+
+	code, state, err = env.server.Authorize(authorization.URL, claims)
+	require.NoError(t, err)
+	assert.Equal(t, authorization.State, state)
+	return code, state
+}
+
+// Tests du service OIDC
+
+func TestOIDCAuthorize_StoresRequestServerSide(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+
+	// Act
+	authorization, err := env.service.Authorize(context.Background(), "mock")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, hashToken(authorization.State), env.stored.StateHash)
+	assert.Equal(t, "mock", env.stored.Provider)
+	assert.NotEmpty(t, env.stored.CodeVerifier)
+	assert.NotContains(t, authorization.URL, env.stored.CodeVerifier)
+	assert.Contains(t, authorization.URL, "code_challenge="+oidc.CodeChallengeS256(env.stored.CodeVerifier))
+}
+
+func TestOIDCAuthorize_UnknownProvider(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+
+	// Act
+	authorization, err := env.service.Authorize(context.Background(), "unknown")
+
+	// Assert
+	assert.Equal(t, ErrUnknownProvider, err)
+	assert.Nil(t, authorization)
+}
+
+func TestOIDCCallback_CreatesAccountForNewUser(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1", Email: "new@example.com", EmailVerified: true})
+	client := ClientInfo{IP: "192.0.2.10"}
+	expected := &LoginResult{AccessToken: "access", RefreshToken: "refresh"}
+
+	env.identityRepo.On("FindByProviderSubject", "mock", "sub-1").Return(nil, nil)
+	env.userRepo.On("FindByEmail", "new@example.com").Return(nil, nil)
+	env.userRepo.On("Create", mock.MatchedBy(func(user *models.User) bool {
+		user.ID = uuid.New()
+		return user.Email == "new@example.com" && user.EmailVerifiedAt != nil && user.Password == ""
+	})).Return(nil)
+	env.identityRepo.On("Create", mock.MatchedBy(func(identity *models.UserIdentity) bool {
+		return identity.Provider == "mock" && identity.Subject == "sub-1"
+	})).Return(nil)
+	env.authService.On("LoginWithUser", mock.AnythingOfType("*models.User"), client).Return(expected, nil)
+
+	// Act
+	result, err := env.service.Callback(context.Background(), "mock", code, state, client)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+	env.userRepo.AssertExpectations(t)
+	env.identityRepo.AssertExpectations(t)
+	env.requestRepo.AssertExpectations(t)
+}
+
+func TestOIDCCallback_LinkedIdentity(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1", Email: "other@example.com"})
+
+	user := &models.User{ID: uuid.New(), Email: "collector@example.com"}
+	linked := &models.UserIdentity{ID: uuid.New(), UserID: user.ID, Provider: "mock", Subject: "sub-1"}
+
+	env.identityRepo.On("FindByProviderSubject", "mock", "sub-1").Return(linked, nil)
+	env.userRepo.On("FindByID", user.ID).Return(user, nil)
+	env.identityRepo.On("UpdateLastLogin", linked.ID, mock.AnythingOfType("time.Time")).Return(nil)
+	env.authService.On("LoginWithUser", user, ClientInfo{}).Return(&LoginResult{User: user}, nil)
+
+	// Act
+	_, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	env.authService.AssertExpectations(t)
+	env.userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+}
+
+func TestOIDCCallback_LinksVerifiedEmailToExistingAccount(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1", Email: "collector@example.com", EmailVerified: true})
+
+	verifiedAt := time.Now()
+	user := &models.User{ID: uuid.New(), Email: "collector@example.com", Password: "hash", EmailVerifiedAt: &verifiedAt}
+
+	env.identityRepo.On("FindByProviderSubject", "mock", "sub-1").Return(nil, nil)
+	env.userRepo.On("FindByEmail", "collector@example.com").Return(user, nil)
+	env.identityRepo.On("Create", mock.MatchedBy(func(identity *models.UserIdentity) bool {
+		return identity.UserID == user.ID
+	})).Return(nil)
+	env.authService.On("LoginWithUser", user, ClientInfo{}).Return(&LoginResult{User: user}, nil)
+
+	// Act
+	_, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "hash", user.Password)
+	env.identityRepo.AssertExpectations(t)
+	env.userRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestOIDCCallback_UnverifiedLocalAccountIsSecuredBeforeLinking(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1", Email: "collector@example.com", EmailVerified: true})
+
+	totpEnabledAt := time.Now()
+	pendingEmail := "attacker@example.com"
+	user := &models.User{
+		ID: uuid.New(), Email: "collector@example.com", Password: "hash-set-by-someone-else",
+		TOTPSecret: "secret", TOTPEnabledAt: &totpEnabledAt, PendingEmail: &pendingEmail,
+	}
+
+	env.identityRepo.On("FindByProviderSubject", "mock", "sub-1").Return(nil, nil)
+	env.userRepo.On("FindByEmail", "collector@example.com").Return(user, nil)
+	env.userRepo.On("ClaimUnverified", user.ID, mock.AnythingOfType("time.Time")).Return(true, nil)
+	env.identityRepo.On("Create", mock.AnythingOfType("*models.UserIdentity")).Return(nil)
+	env.authService.On("LoginWithUser", user, ClientInfo{}).Return(&LoginResult{User: user}, nil)
+
+	// Act
+	_, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert : l'accès laissé par le créateur du compte est retiré
+	require.NoError(t, err)
+	env.userRepo.AssertExpectations(t)
+	assert.Empty(t, user.Password)
+	assert.Nil(t, user.TOTPEnabledAt)
+	assert.Nil(t, user.PendingEmail)
+	assert.NotNil(t, user.EmailVerifiedAt)
+}
+
+func TestOIDCCallback_UnverifiedProviderEmail(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1", Email: "collector@example.com", EmailVerified: false})
+
+	env.identityRepo.On("FindByProviderSubject", "mock", "sub-1").Return(nil, nil)
+
+	// Act
+	result, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrOIDCEmailNotVerified, err)
+	assert.Nil(t, result)
+	env.userRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	env.authService.AssertNotCalled(t, "LoginWithUser", mock.Anything, mock.Anything)
+}
+
+func TestOIDCCallback_UnknownState(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, _ := env.authorize(t, oidctest.Claims{Subject: "sub-1"})
+
+	env.requestRepo.On("FindByStateHash", hashToken("forged-state")).Return(nil, nil)
+
+	// Act
+	result, err := env.service.Callback(context.Background(), "mock", code, "forged-state", ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidOIDCState, err)
+	assert.Nil(t, result)
+}
+
+func TestOIDCCallback_StateAlreadyUsed(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1"})
+
+	env.requestRepo.ExpectedCalls = nil
+	env.requestRepo.On("FindByStateHash", hashToken(state)).Return(env.stored, nil)
+	env.requestRepo.On("Delete", env.stored.ID).Return(false, nil)
+
+	// Act
+	result, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidOIDCState, err)
+	assert.Nil(t, result)
+}
+
+func TestOIDCCallback_ExpiredRequest(t *testing.T) {
+	// Arrange
+	env := newOIDCTestEnv(t)
+	code, state := env.authorize(t, oidctest.Claims{Subject: "sub-1"})
+	env.stored.ExpiresAt = time.Now().Add(-time.Minute)
+
+	// Act
+	result, err := env.service.Callback(context.Background(), "mock", code, state, ClientInfo{})
+
+	// Assert
+	assert.Equal(t, ErrInvalidOIDCState, err)
+	assert.Nil(t, result)
+	env.requestRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/password"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("lien de réinitialisation invalide ou expiré")
+)
+
+// PasswordResetService définit l'interface du flux "mot de passe oublié"
+type PasswordResetService interface {
+	RequestReset(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+// passwordResetService implémente PasswordResetService
+type passwordResetService struct {
+	userRepo       repository.UserRepository
+	resetRepo      repository.PasswordResetRepository
+	sessionService SessionService
+	mailer         mailer.Mailer
+	passwordPolicy *password.Policy
+	passwordHasher password.Hasher
+	frontendURL    string
+	tokenDuration  time.Duration
+}
+
+// NewPasswordResetService crée une nouvelle instance de PasswordResetService
+func NewPasswordResetService(
+	userRepo repository.UserRepository,
+	resetRepo repository.PasswordResetRepository,
+	sessionService SessionService,
+	mailer mailer.Mailer,
+	passwordPolicy *password.Policy,
+	passwordHasher password.Hasher,
+	frontendURL string,
+	tokenDuration time.Duration,
+) PasswordResetService {
+	return &passwordResetService{
+		userRepo:       userRepo,
+		resetRepo:      resetRepo,
+		sessionService: sessionService,
+		mailer:         mailer,
+		passwordPolicy: passwordPolicy,
+		passwordHasher: passwordHasher,
+		frontendURL:    frontendURL,
+		tokenDuration:  tokenDuration,
+	}
+}
+
+// RequestReset envoie un lien de réinitialisation si l'email correspond à un compte.
+// Aucune erreur n'est retournée pour un email inconnu, afin de ne pas révéler
+// quels emails sont inscrits.
+func (s *passwordResetService) RequestReset(email string) error {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// Un seul lien valide à la fois par utilisateur
+	if err := s.resetRepo.InvalidateForUser(user.ID); err != nil {
+		return err
+	}
+
+	token, tokenHash, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = s.resetRepo.Create(&models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(s.tokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/auth/reset-password?token=%s", s.frontendURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Réinitialisation de votre mot de passe Collec-App",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"Une demande de réinitialisation du mot de passe de votre compte Collec-App a été effectuée.\n"+
+			"Pour choisir un nouveau mot de passe, ouvrez le lien suivant (valable %d minutes) :\n\n"+
+			"%s\n\n"+
+			"Si vous n'êtes pas à l'origine de cette demande, ignorez cet email : votre mot de passe reste inchangé.\n",
+			int(s.tokenDuration.Minutes()), link),
+	})
+}
+
+// ResetPassword consomme le token de réinitialisation, remplace le mot de passe
+// et invalide toutes les sessions existantes de l'utilisateur
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	stored, err := s.resetRepo.FindByHash(hashToken(token))
+	if err != nil {
+		return err
+	}
+	if stored == nil || stored.UsedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	// Valider avant de consommer le lien, pour que l'utilisateur puisse
+	// réessayer avec un autre mot de passe
+	if err := s.passwordPolicy.Validate(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	used, err := s.resetRepo.MarkUsed(stored.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := s.passwordHasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(stored.UserID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Déconnecter tous les appareils : tokens déjà émis et sessions
+	if err := s.userRepo.IncrementTokenGeneration(stored.UserID); err != nil {
+		return err
+	}
+	return s.sessionService.RevokeAll(stored.UserID)
+}
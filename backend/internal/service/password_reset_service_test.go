@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/mailer"
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock du PasswordResetRepository
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(token *models.PasswordResetToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteExpired() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) InvalidateForUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// Mock du Mailer
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(msg mailer.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+// Helper function pour extraire le token d'un lien envoyé par email
+func tokenFromEmail(body, path string) string {
+	start := strings.Index(body, path+"?token=")
+	if start < 0 {
+		return ""
+	}
+	token := body[start+len(path+"?token="):]
+	return token[:strings.IndexAny(token, "\n& ")]
+}
+
+// Tests du service PasswordReset
+
+func TestRequestReset_SendsLinkWithStoredHash(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessions := new(MockSessionService)
+	mockMailer := new(MockMailer)
+	resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	var storedHash string
+	var sent mailer.Message
+
+	mockRepo.On("FindByEmail", user.Email).Return(user, nil)
+	mockResetRepo.On("InvalidateForUser", user.ID).Return(nil)
+	mockResetRepo.On("Create", mock.MatchedBy(func(token *models.PasswordResetToken) bool {
+		storedHash = token.TokenHash
+		return token.UserID == user.ID && token.ExpiresAt.After(time.Now().Add(59*time.Minute))
+	})).Return(nil)
+	mockMailer.On("Send", mock.MatchedBy(func(msg mailer.Message) bool {
+		sent = msg
+		return msg.To == user.Email
+	})).Return(nil)
+
+	// Act
+	err := resetService.RequestReset(user.Email)
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+
+	// Le lien contient le token en clair, seul son hash est stocké
+	token := tokenFromEmail(sent.Body, "http://localhost:3000/auth/reset-password")
+	assert.NotEmpty(t, token)
+	assert.NotEqual(t, token, storedHash)
+	assert.Equal(t, hashToken(token), storedHash)
+}
+
+func TestRequestReset_UnknownEmail(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessions := new(MockSessionService)
+	mockMailer := new(MockMailer)
+	resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+	mockRepo.On("FindByEmail", "unknown@example.com").Return(nil, nil)
+
+	// Act
+	err := resetService.RequestReset("unknown@example.com")
+
+	// Assert
+	assert.NoError(t, err)
+	mockResetRepo.AssertNotCalled(t, "Create", mock.Anything)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything)
+}
+
+func TestResetPassword_Success(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessions := new(MockSessionService)
+	mockMailer := new(MockMailer)
+	resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+	token := "reset-token"
+	newPassword := "new-password123"
+	stored := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(30 * time.Minute),
+	}
+
+	mockResetRepo.On("FindByHash", hashToken(token)).Return(stored, nil)
+	mockRepo.On("FindByID", stored.UserID).Return(&models.User{ID: stored.UserID, Email: "test@example.com"}, nil)
+	mockResetRepo.On("MarkUsed", stored.ID).Return(true, nil)
+	mockRepo.On("UpdatePassword", stored.UserID, mock.MatchedBy(func(hash string) bool {
+		valid, err := testPasswordHasher.Verify(newPassword, hash)
+		return err == nil && valid
+	})).Return(nil)
+	mockRepo.On("IncrementTokenGeneration", stored.UserID).Return(nil)
+	mockSessions.On("RevokeAll", stored.UserID).Return(nil)
+
+	// Act
+	err := resetService.ResetPassword(token, newPassword)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockResetRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestResetPassword_InvalidTokens(t *testing.T) {
+	usedAt := time.Now().Add(-time.Minute)
+	cases := map[string]*models.PasswordResetToken{
+		"inconnu": nil,
+		"expiré":  {ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)},
+		"utilisé": {ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt},
+	}
+
+	for name, stored := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(MockUserRepository)
+			mockResetRepo := new(MockPasswordResetRepository)
+			mockSessions := new(MockSessionService)
+			mockMailer := new(MockMailer)
+			resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+			if stored == nil {
+				mockResetRepo.On("FindByHash", hashToken("token")).Return(nil, nil)
+			} else {
+				mockResetRepo.On("FindByHash", hashToken("token")).Return(stored, nil)
+			}
+
+			// Act
+			err := resetService.ResetPassword("token", "new-password123")
+
+			// Assert
+			assert.Equal(t, ErrInvalidResetToken, err)
+			mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestResetPassword_TokenConsumedConcurrently(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	mockResetRepo := new(MockPasswordResetRepository)
+	mockSessions := new(MockSessionService)
+	mockMailer := new(MockMailer)
+	resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+	stored := &models.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockResetRepo.On("FindByHash", hashToken("token")).Return(stored, nil)
+	mockRepo.On("FindByID", stored.UserID).Return(&models.User{ID: stored.UserID, Email: "test@example.com"}, nil)
+	mockResetRepo.On("MarkUsed", stored.ID).Return(false, nil)
+
+	// Act
+	err := resetService.ResetPassword("token", "new-password123")
+
+	// Assert
+	assert.Equal(t, ErrInvalidResetToken, err)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+}
+
+func TestResetPassword_WeakPassword(t *testing.T) {
+	cases := map[string]string{
+		"trop court":       "weak",
+		"contient l'email": "collector-2026",
+	}
+
+	for name, newPassword := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			mockRepo := new(MockUserRepository)
+			mockResetRepo := new(MockPasswordResetRepository)
+			mockSessions := new(MockSessionService)
+			mockMailer := new(MockMailer)
+			resetService := NewPasswordResetService(mockRepo, mockResetRepo, mockSessions, mockMailer, testPasswordPolicy, testPasswordHasher, "http://localhost:3000", time.Hour)
+
+			stored := &models.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+			mockResetRepo.On("FindByHash", hashToken("token")).Return(stored, nil)
+			mockRepo.On("FindByID", stored.UserID).Return(&models.User{ID: stored.UserID, Email: "collector@example.com"}, nil)
+
+			// Act
+			err := resetService.ResetPassword("token", newPassword)
+
+			// Assert
+			assert.True(t, errors.Is(err, ErrWeakPassword))
+			// Le lien n'est pas consommé : l'utilisateur peut réessayer
+			mockResetRepo.AssertNotCalled(t, "MarkUsed", mock.Anything)
+			mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidScope                = errors.New("portée inconnue ou non accordée")
+	ErrInvalidTokenExpiry          = errors.New("la date d'expiration doit être dans le futur")
+	ErrPersonalAccessTokenNotFound = errors.New("token d'accès introuvable")
+)
+
+// PersonalAccessTokenPrefix préfixe la valeur des personal access tokens, pour
+// les distinguer des JWT et les repérer s'ils fuitent
+const PersonalAccessTokenPrefix = "cpat_"
+
+// Portées générales d'un personal access token. Un token peut aussi porter les
+// permissions de l'utilisateur (ex. users:read), qui ne sont accordées que tant
+// que l'utilisateur les possède.
+const (
+	ScopeRead  = "read"  // requêtes de lecture (GET, HEAD)
+	ScopeWrite = "write" // toutes les requêtes, lecture comprise
+)
+
+// lastUsedUpdateInterval limite les écritures de la date de dernière utilisation
+const lastUsedUpdateInterval = time.Minute
+
+// PersonalAccessTokenService définit l'interface de gestion des personal
+// access tokens, utilisés par les scripts et intégrations à la place des JWT
+type PersonalAccessTokenService interface {
+	Create(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.PersonalAccessToken, string, error)
+	List(userID uuid.UUID) ([]models.PersonalAccessToken, error)
+	Revoke(userID, tokenID uuid.UUID) error
+	Authenticate(token string) (*JWTClaims, error)
+}
+
+// personalAccessTokenService implémente PersonalAccessTokenService
+type personalAccessTokenService struct {
+	tokenRepo   repository.PersonalAccessTokenRepository
+	userRepo    repository.UserRepository
+	roleService RoleService
+}
+
+// NewPersonalAccessTokenService crée une nouvelle instance de PersonalAccessTokenService
+func NewPersonalAccessTokenService(
+	tokenRepo repository.PersonalAccessTokenRepository,
+	userRepo repository.UserRepository,
+	roleService RoleService,
+) PersonalAccessTokenService {
+	return &personalAccessTokenService{
+		tokenRepo:   tokenRepo,
+		userRepo:    userRepo,
+		roleService: roleService,
+	}
+}
+
+// Create crée un token pour l'utilisateur et retourne sa valeur, qui n'est
+// plus consultable ensuite. Les permissions demandées en portée doivent être
+// accordées à l'utilisateur.
+func (s *personalAccessTokenService) Create(userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*models.PersonalAccessToken, string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrInvalidToken
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrInvalidTokenExpiry
+	}
+
+	scopes, err = s.validateScopes(user.ID, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Le hash porte sur la valeur préfixée, telle que présentée par le client
+	secret, _, err := generateOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	token := PersonalAccessTokenPrefix + secret
+
+	stored := &models.PersonalAccessToken{
+		UserID:     user.ID,
+		Name:       strings.TrimSpace(name),
+		TokenHash:  hashToken(token),
+		Scopes:     strings.Join(scopes, " "),
+		Generation: user.TokenGeneration,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.tokenRepo.Create(stored); err != nil {
+		return nil, "", err
+	}
+
+	return stored, token, nil
+}
+
+// validateScopes dédoublonne les portées demandées et vérifie que chacune est
+// une portée générale ou une permission de l'utilisateur
+func (s *personalAccessTokenService) validateScopes(userID uuid.UUID, scopes []string) ([]string, error) {
+	authorizations, err := s.roleService.Authorizations(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope != ScopeRead && scope != ScopeWrite && !slices.Contains(authorizations.Permissions, scope) {
+			return nil, ErrInvalidScope
+		}
+		if !slices.Contains(valid, scope) {
+			valid = append(valid, scope)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return valid, nil
+}
+
+// List retourne les tokens utilisables de l'utilisateur
+func (s *personalAccessTokenService) List(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	return s.tokenRepo.FindActiveByUserID(userID)
+}
+
+// Revoke révoque un token de l'utilisateur
+func (s *personalAccessTokenService) Revoke(userID, tokenID uuid.UUID) error {
+	revoked, err := s.tokenRepo.Revoke(userID, tokenID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrPersonalAccessTokenNotFound
+	}
+	return nil
+}
+
+// Authenticate vérifie un personal access token et retourne des claims
+// équivalents à ceux d'un access token. Les permissions accordées sont les
+// portées que l'utilisateur possède encore. Comme les JWT, le token est refusé
+// si le compte est désactivé ou après une déconnexion partout.
+func (s *personalAccessTokenService) Authenticate(token string) (*JWTClaims, error) {
+	if !strings.HasPrefix(token, PersonalAccessTokenPrefix) {
+		return nil, ErrInvalidToken
+	}
+
+	stored, err := s.tokenRepo.FindByHash(hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if stored == nil || stored.RevokedAt != nil || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidToken
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	if stored.Generation < user.TokenGeneration {
+		return nil, ErrTokenRevoked
+	}
+
+	authorizations, err := s.roleService.Authorizations(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := stored.ScopeList()
+	claims := &JWTClaims{
+		UserID:     user.ID,
+		Email:      user.Email,
+		TokenType:  TokenTypePersonalAccess,
+		Generation: stored.Generation,
+		Scopes:     scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      stored.ID.String(),
+			Subject: user.ID.String(),
+		},
+	}
+	for _, scope := range scopes {
+		if slices.Contains(authorizations.Permissions, scope) {
+			claims.Permissions = append(claims.Permissions, scope)
+		}
+	}
+	if stored.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*stored.ExpiresAt)
+	}
+
+	// Un échec n'empêche pas la requête : la date sera mise à jour à la suivante
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedUpdateInterval {
+		if err := s.tokenRepo.UpdateLastUsed(stored.ID, now); err != nil {
+			log.Printf("mise à jour de la dernière utilisation du token %s impossible: %v", stored.ID, err)
+		}
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du PersonalAccessTokenRepository
+type MockPersonalAccessTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPersonalAccessTokenRepository) Create(token *models.PersonalAccessToken) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) FindByHash(tokenHash string) (*models.PersonalAccessToken, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) FindActiveByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) FindByUserID(userID uuid.UUID) ([]models.PersonalAccessToken, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.PersonalAccessToken), args.Error(1)
+}
+
+func (m *MockPersonalAccessTokenRepository) UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error {
+	args := m.Called(id, lastUsedAt)
+	return args.Error(0)
+}
+
+func (m *MockPersonalAccessTokenRepository) Revoke(userID, id uuid.UUID) (bool, error) {
+	args := m.Called(userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// Helper function pour créer le service des personal access tokens et ses
+// mocks ; l'utilisateur possède la permission users:read
+func newPersonalAccessTokenTestService() (PersonalAccessTokenService, *MockPersonalAccessTokenRepository, *MockUserRepository) {
+	mockTokens := new(MockPersonalAccessTokenRepository)
+	mockUsers := new(MockUserRepository)
+	mockRoles := new(MockRoleService)
+	mockRoles.On("Authorizations", mock.Anything).Return(&Authorizations{
+		Roles:       []string{RoleAdmin},
+		Permissions: []string{PermissionUsersRead},
+	}, nil)
+	return NewPersonalAccessTokenService(mockTokens, mockUsers, mockRoles), mockTokens, mockUsers
+}
+
+// Tests de la création
+
+func TestCreatePersonalAccessToken_StoresOnlyHash(t *testing.T) {
+	// Arrange
+	tokenService, mockTokens, mockUsers := newPersonalAccessTokenTestService()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", TokenGeneration: 2}
+	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+	var stored *models.PersonalAccessToken
+
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+	mockTokens.On("Create", mock.MatchedBy(func(token *models.PersonalAccessToken) bool {
+		stored = token
+		return true
+	})).Return(nil)
+
+	// Act
+	token, value, err := tokenService.Create(user.ID, " deploy script ", []string{ScopeRead, PermissionUsersRead, ScopeRead}, &expiresAt)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, PersonalAccessTokenPrefix))
+	assert.Equal(t, stored, token)
+	assert.Equal(t, "deploy script", stored.Name)
+	assert.Equal(t, hashToken(value), stored.TokenHash)
+	assert.NotContains(t, stored.TokenHash, value)
+	assert.Equal(t, []string{ScopeRead, PermissionUsersRead}, stored.ScopeList())
+	assert.Equal(t, 2, stored.Generation)
+}
+
+func TestCreatePersonalAccessToken_Rejected(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name      string
+		scopes    []string
+		expiresAt *time.Time
+		expected  error
+	}{
+		{"portée inconnue", []string{"admin"}, nil, ErrInvalidScope},
+		{"permission non accordée", []string{PermissionUsersWrite}, nil, ErrInvalidScope},
+		{"aucune portée", []string{}, nil, ErrInvalidScope},
+		{"expiration passée", []string{ScopeRead}, &past, ErrInvalidTokenExpiry},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenService, mockTokens, mockUsers := newPersonalAccessTokenTestService()
+			userID := uuid.New()
+			mockUsers.On("FindByID", userID).Return(&models.User{ID: userID}, nil)
+
+			_, _, err := tokenService.Create(userID, "script", tc.scopes, tc.expiresAt)
+
+			assert.Equal(t, tc.expected, err)
+			mockTokens.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+// Tests de l'authentification
+
+func TestAuthenticatePersonalAccessToken_Success(t *testing.T) {
+	// Arrange
+	tokenService, mockTokens, mockUsers := newPersonalAccessTokenTestService()
+	user := &models.User{ID: uuid.New(), Email: "test@example.com"}
+	value := PersonalAccessTokenPrefix + "secret"
+	stored := &models.PersonalAccessToken{ID: uuid.New(), UserID: user.ID, Scopes: "read users:read users:write"}
+
+	mockTokens.On("FindByHash", hashToken(value)).Return(stored, nil)
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+	mockTokens.On("UpdateLastUsed", stored.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	claims, err := tokenService.Authenticate(value)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, TokenTypePersonalAccess, claims.TokenType)
+	assert.True(t, claims.AllowsMethod("GET"))
+	assert.False(t, claims.AllowsMethod("POST"))
+	// users:write a été retirée à l'utilisateur depuis la création du token
+	assert.Equal(t, []string{PermissionUsersRead}, claims.Permissions)
+	mockTokens.AssertExpectations(t)
+}
+
+func TestAuthenticatePersonalAccessToken_RecentUseNotRecorded(t *testing.T) {
+	// Arrange
+	tokenService, mockTokens, mockUsers := newPersonalAccessTokenTestService()
+	user := &models.User{ID: uuid.New()}
+	value := PersonalAccessTokenPrefix + "secret"
+	lastUsedAt := time.Now().Add(-10 * time.Second)
+	stored := &models.PersonalAccessToken{ID: uuid.New(), UserID: user.ID, Scopes: ScopeWrite, LastUsedAt: &lastUsedAt}
+
+	mockTokens.On("FindByHash", hashToken(value)).Return(stored, nil)
+	mockUsers.On("FindByID", user.ID).Return(user, nil)
+
+	// Act
+	claims, err := tokenService.Authenticate(value)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, claims.AllowsMethod("DELETE"))
+	mockTokens.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+}
+
+func TestAuthenticatePersonalAccessToken_Rejected(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	revoked := time.Now().Add(-time.Hour)
+	disabled := time.Now()
+
+	cases := []struct {
+		name     string
+		token    models.PersonalAccessToken
+		user     models.User
+		expected error
+	}{
+		{"expiré", models.PersonalAccessToken{Scopes: ScopeRead, ExpiresAt: &expired}, models.User{}, ErrInvalidToken},
+		{"révoqué", models.PersonalAccessToken{Scopes: ScopeRead, RevokedAt: &revoked}, models.User{}, ErrInvalidToken},
+		{"déconnexion partout", models.PersonalAccessToken{Scopes: ScopeRead}, models.User{TokenGeneration: 1}, ErrTokenRevoked},
+		{"compte désactivé", models.PersonalAccessToken{Scopes: ScopeRead}, models.User{DisabledAt: &disabled}, ErrAccountDisabled},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenService, mockTokens, mockUsers := newPersonalAccessTokenTestService()
+			value := PersonalAccessTokenPrefix + "secret"
+			user := tc.user
+			user.ID = uuid.New()
+			stored := tc.token
+			stored.ID = uuid.New()
+			stored.UserID = user.ID
+
+			mockTokens.On("FindByHash", hashToken(value)).Return(&stored, nil)
+			mockUsers.On("FindByID", user.ID).Return(&user, nil)
+
+			claims, err := tokenService.Authenticate(value)
+
+			assert.Nil(t, claims)
+			assert.Equal(t, tc.expected, err)
+			mockTokens.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// Tests de la révocation
+
+func TestRevokePersonalAccessToken_NotFound(t *testing.T) {
+	// Arrange
+	tokenService, mockTokens, _ := newPersonalAccessTokenTestService()
+	userID, tokenID := uuid.New(), uuid.New()
+	mockTokens.On("Revoke", userID, tokenID).Return(false, nil)
+
+	// Act
+	err := tokenService.Revoke(userID, tokenID)
+
+	// Assert
+	assert.Equal(t, ErrPersonalAccessTokenNotFound, err)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidUsername = errors.New("identifiant invalide : 3 à 30 lettres, chiffres ou underscores")
+	ErrUsernameTaken   = errors.New("identifiant déjà utilisé")
+)
+
+// usernamePattern décrit les identifiants acceptés
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,30}$`)
+
+// ProfileUpdate décrit une modification partielle du profil : les champs nil
+// sont conservés, une chaîne vide efface la valeur
+type ProfileUpdate struct {
+	DisplayName *string
+	Username    *string
+	Bio         *string
+	Locale      *string
+	Timezone    *string
+	Currency    *string
+}
+
+// ProfileService définit l'interface de consultation et de modification du
+// profil de l'utilisateur connecté
+type ProfileService interface {
+	GetProfile(userID uuid.UUID) (*models.User, error)
+	UpdateProfile(userID uuid.UUID, update ProfileUpdate) (*models.User, error)
+}
+
+// profileService implémente ProfileService
+type profileService struct {
+	userRepo repository.UserRepository
+}
+
+// NewProfileService crée une nouvelle instance de ProfileService
+func NewProfileService(userRepo repository.UserRepository) ProfileService {
+	return &profileService{userRepo: userRepo}
+}
+
+// GetProfile charge l'utilisateur, ErrUserNotFound s'il n'existe pas
+func (s *profileService) GetProfile(userID uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile applique les champs fournis et enregistre le profil. Le
+// format des champs libres est validé par le handler ; l'identifiant doit
+// respecter usernamePattern et ne pas être utilisé par un autre compte, quelle
+// que soit la casse.
+func (s *profileService) UpdateProfile(userID uuid.UUID, update ProfileUpdate) (*models.User, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Username != nil {
+		username := strings.TrimSpace(*update.Username)
+		if username == "" {
+			user.Username = nil
+		} else {
+			if !usernamePattern.MatchString(username) {
+				return nil, ErrInvalidUsername
+			}
+			taken, err := s.userRepo.ExistsByUsername(username, user.ID)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				return nil, ErrUsernameTaken
+			}
+			user.Username = &username
+		}
+	}
+
+	applyProfileField(&user.DisplayName, update.DisplayName)
+	applyProfileField(&user.Bio, update.Bio)
+	applyProfileField(&user.Locale, update.Locale)
+	applyProfileField(&user.Timezone, update.Timezone)
+	applyProfileField(&user.Currency, update.Currency)
+
+	// La vérification préalable ne couvre pas deux demandes simultanées :
+	// l'index unique tranche alors
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		if errors.Is(err, repository.ErrUsernameConflict) {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// applyProfileField remplace la valeur d'un champ si elle est fournie
+func applyProfileField(field *string, value *string) {
+	if value != nil {
+		*field = strings.TrimSpace(*value)
+	}
+}
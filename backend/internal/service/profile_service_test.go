@@ -0,0 +1,132 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Helper function pour obtenir un pointeur vers une chaîne
+func stringPtr(value string) *string {
+	return &value
+}
+
+func TestGetProfile_UserNotFound(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	profileService := NewProfileService(mockRepo)
+	userID := uuid.New()
+	mockRepo.On("FindByID", userID).Return(nil, nil)
+
+	// Act
+	user, err := profileService.GetProfile(userID)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Nil(t, user)
+}
+
+func TestUpdateProfile_AppliesProvidedFields(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	profileService := NewProfileService(mockRepo)
+	user := &models.User{ID: uuid.New(), DisplayName: "Alice", Bio: "Collectionneuse", Currency: "EUR"}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("ExistsByUsername", "Alice_42", user.ID).Return(false, nil)
+	mockRepo.On("UpdateProfile", user).Return(nil)
+
+	// Act
+	updated, err := profileService.UpdateProfile(user.ID, ProfileUpdate{
+		DisplayName: stringPtr("  Alice Martin "),
+		Username:    stringPtr("Alice_42"),
+		Bio:         stringPtr(""),
+		Timezone:    stringPtr("Europe/Paris"),
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Alice Martin", updated.DisplayName)
+	require.NotNil(t, updated.Username)
+	assert.Equal(t, "Alice_42", *updated.Username)
+	assert.Empty(t, updated.Bio, "une chaîne vide efface le champ")
+	assert.Equal(t, "Europe/Paris", updated.Timezone)
+	assert.Equal(t, "EUR", updated.Currency, "un champ absent est conservé")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateProfile_UsernameTaken(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	profileService := NewProfileService(mockRepo)
+	user := &models.User{ID: uuid.New()}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	// L'unicité ne tient pas compte de la casse : le dépôt compare en minuscules
+	mockRepo.On("ExistsByUsername", "BOB", user.ID).Return(true, nil)
+
+	// Act
+	_, err := profileService.UpdateProfile(user.ID, ProfileUpdate{Username: stringPtr("BOB")})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+	mockRepo.AssertNotCalled(t, "UpdateProfile", mock.Anything)
+}
+
+func TestUpdateProfile_UsernameTakenConcurrently(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	profileService := NewProfileService(mockRepo)
+	user := &models.User{ID: uuid.New()}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	// L'identifiant était libre à la vérification, l'index unique le refuse à l'écriture
+	mockRepo.On("ExistsByUsername", "bob", user.ID).Return(false, nil)
+	mockRepo.On("UpdateProfile", user).Return(repository.ErrUsernameConflict)
+
+	// Act
+	updated, err := profileService.UpdateProfile(user.ID, ProfileUpdate{Username: stringPtr("bob")})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+	assert.Nil(t, updated)
+}
+
+func TestUpdateProfile_InvalidUsername(t *testing.T) {
+	for _, username := range []string{"ab", "avec espace", "accentué", "trop_long_pour_etre_un_identifiant"} {
+		t.Run(username, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			profileService := NewProfileService(mockRepo)
+			user := &models.User{ID: uuid.New()}
+			mockRepo.On("FindByID", user.ID).Return(user, nil)
+
+			_, err := profileService.UpdateProfile(user.ID, ProfileUpdate{Username: stringPtr(username)})
+
+			assert.ErrorIs(t, err, ErrInvalidUsername)
+			mockRepo.AssertNotCalled(t, "ExistsByUsername", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateProfile_ClearsUsername(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockUserRepository)
+	profileService := NewProfileService(mockRepo)
+	user := &models.User{ID: uuid.New(), Username: stringPtr("alice")}
+
+	mockRepo.On("FindByID", user.ID).Return(user, nil)
+	mockRepo.On("UpdateProfile", user).Return(nil)
+
+	// Act
+	updated, err := profileService.UpdateProfile(user.ID, ProfileUpdate{Username: stringPtr("")})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, updated.Username)
+	mockRepo.AssertNotCalled(t, "ExistsByUsername", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserNotFound      = errors.New("utilisateur introuvable")
+	ErrUnknownRole       = errors.New("rôle inconnu")
+	ErrRoleNotGranted    = errors.New("l'utilisateur ne possède pas ce rôle")
+	ErrAdminBootstrapped = errors.New("un administrateur existe déjà")
+)
+
+// Rôles prédéfinis
+const (
+	RoleAdmin = "admin"
+)
+
+// Permissions prédéfinies, au format "<ressource>:<action>"
+const (
+	PermissionUsersRead  = "users:read"
+	PermissionUsersWrite = "users:write"
+	PermissionRolesWrite = "roles:write"
+	PermissionAuditRead  = "audit:read"
+)
+
+// defaultPermissions décrit les permissions connues de l'application
+var defaultPermissions = []struct {
+	name        string
+	description string
+}{
+	{PermissionUsersRead, "Consulter les comptes utilisateurs"},
+	{PermissionUsersWrite, "Modifier, désactiver ou réactiver les comptes utilisateurs"},
+	{PermissionRolesWrite, "Attribuer et retirer des rôles"},
+	{PermissionAuditRead, "Consulter le journal d'audit"},
+}
+
+// defaultRoles décrit les rôles prédéfinis et leurs permissions
+var defaultRoles = []struct {
+	name        string
+	description string
+	permissions []string
+}{
+	{RoleAdmin, "Administration de l'application", []string{PermissionUsersRead, PermissionUsersWrite, PermissionRolesWrite, PermissionAuditRead}},
+}
+
+// Authorizations regroupe les rôles d'un utilisateur et les permissions qu'ils accordent
+type Authorizations struct {
+	Roles       []string
+	Permissions []string
+}
+
+// RoleService définit l'interface de gestion des rôles et des permissions
+type RoleService interface {
+	EnsureDefaults() error
+	Authorizations(userID uuid.UUID) (*Authorizations, error)
+	Grant(email, role string) error
+	Revoke(email, role string) error
+	BootstrapAdmin(email string) error
+}
+
+// roleService implémente RoleService
+type roleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+}
+
+// NewRoleService crée une nouvelle instance de RoleService
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) RoleService {
+	return &roleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// EnsureDefaults crée les permissions et les rôles prédéfinis s'ils manquent.
+// Appelé au démarrage ; sans effet sur les attributions existantes.
+func (s *roleService) EnsureDefaults() error {
+	permissionIDs := make(map[string]uuid.UUID, len(defaultPermissions))
+	for _, p := range defaultPermissions {
+		permission, err := s.roleRepo.UpsertPermission(p.name, p.description)
+		if err != nil {
+			return err
+		}
+		permissionIDs[p.name] = permission.ID
+	}
+
+	for _, r := range defaultRoles {
+		role, err := s.roleRepo.UpsertRole(r.name, r.description)
+		if err != nil {
+			return err
+		}
+		for _, name := range r.permissions {
+			if err := s.roleRepo.AttachPermission(role.ID, permissionIDs[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Authorizations retourne les rôles et les permissions d'un utilisateur
+func (s *roleService) Authorizations(userID uuid.UUID) (*Authorizations, error) {
+	roles, err := s.roleRepo.FindRoleNamesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.roleRepo.FindPermissionNamesByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &Authorizations{Roles: roles, Permissions: permissions}, nil
+}
+
+// Grant attribue un rôle à l'utilisateur désigné par son email. Le rôle
+// apparaît dans ses access tokens au prochain renouvellement.
+func (s *roleService) Grant(email, role string) error {
+	userID, roleID, err := s.resolve(email, role)
+	if err != nil {
+		return err
+	}
+	return s.roleRepo.Grant(userID, roleID, time.Now())
+}
+
+// Revoke retire un rôle à l'utilisateur désigné par son email. Les access
+// tokens déjà émis le portent jusqu'à leur expiration.
+func (s *roleService) Revoke(email, role string) error {
+	userID, roleID, err := s.resolve(email, role)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := s.roleRepo.Revoke(userID, roleID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrRoleNotGranted
+	}
+	return nil
+}
+
+// BootstrapAdmin attribue le rôle admin à l'utilisateur désigné, uniquement
+// si aucun administrateur n'existe encore. Permet de créer le premier
+// administrateur d'une installation sans accès à la base.
+func (s *roleService) BootstrapAdmin(email string) error {
+	role, err := s.roleRepo.FindByName(RoleAdmin)
+	if err != nil {
+		return err
+	}
+	if role == nil {
+		return ErrUnknownRole
+	}
+
+	count, err := s.roleRepo.CountUsers(role.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAdminBootstrapped
+	}
+
+	return s.Grant(email, RoleAdmin)
+}
+
+// resolve retrouve l'utilisateur et le rôle désignés par leur nom
+func (s *roleService) resolve(email, roleName string) (uuid.UUID, uuid.UUID, error) {
+	user, err := s.userRepo.FindByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if user == nil {
+		return uuid.Nil, uuid.Nil, ErrUserNotFound
+	}
+
+	role, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if role == nil {
+		return uuid.Nil, uuid.Nil, ErrUnknownRole
+	}
+	return user.ID, role.ID, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du RoleRepository
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) UpsertRole(name, description string) (*models.Role, error) {
+	args := m.Called(name, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) UpsertPermission(name, description string) (*models.Permission, error) {
+	args := m.Called(name, description)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Permission), args.Error(1)
+}
+
+func (m *MockRoleRepository) AttachPermission(roleID, permissionID uuid.UUID) error {
+	args := m.Called(roleID, permissionID)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) FindByName(name string) (*models.Role, error) {
+	args := m.Called(name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) FindRoleNamesByUserID(userID uuid.UUID) ([]string, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRoleRepository) FindPermissionNamesByUserID(userID uuid.UUID) ([]string, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockRoleRepository) Grant(userID, roleID uuid.UUID, grantedAt time.Time) error {
+	args := m.Called(userID, roleID, grantedAt)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) Revoke(userID, roleID uuid.UUID) (bool, error) {
+	args := m.Called(userID, roleID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepository) CountUsers(roleID uuid.UUID) (int64, error) {
+	args := m.Called(roleID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Tests du service de rôles
+
+func TestEnsureDefaults_GrantsAllPermissionsToAdmin(t *testing.T) {
+	// Arrange
+	mockRoles := new(MockRoleRepository)
+	roleService := NewRoleService(mockRoles, new(MockUserRepository))
+	admin := &models.Role{ID: uuid.New(), Name: RoleAdmin}
+
+	for _, p := range defaultPermissions {
+		mockRoles.On("UpsertPermission", p.name, p.description).Return(&models.Permission{ID: uuid.New(), Name: p.name}, nil)
+	}
+	mockRoles.On("UpsertRole", RoleAdmin, mock.AnythingOfType("string")).Return(admin, nil)
+	mockRoles.On("AttachPermission", admin.ID, mock.AnythingOfType("uuid.UUID")).Return(nil)
+
+	// Act
+	err := roleService.EnsureDefaults()
+
+	// Assert
+	require.NoError(t, err)
+	mockRoles.AssertNumberOfCalls(t, "AttachPermission", len(defaultPermissions))
+	mockRoles.AssertNotCalled(t, "AttachPermission", admin.ID, uuid.Nil)
+}
+
+func TestGrantRole(t *testing.T) {
+	// Arrange
+	mockRoles := new(MockRoleRepository)
+	mockUsers := new(MockUserRepository)
+	roleService := NewRoleService(mockRoles, mockUsers)
+	user := &models.User{ID: uuid.New(), Email: "admin@example.com"}
+	admin := &models.Role{ID: uuid.New(), Name: RoleAdmin}
+
+	mockUsers.On("FindByEmail", user.Email).Return(user, nil)
+	mockRoles.On("FindByName", RoleAdmin).Return(admin, nil)
+	mockRoles.On("Grant", user.ID, admin.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	err := roleService.Grant(" admin@example.com ", RoleAdmin)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRoles.AssertExpectations(t)
+}
+
+func TestGrantRole_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     *models.User
+		role     *models.Role
+		expected error
+	}{
+		{"utilisateur inconnu", nil, &models.Role{ID: uuid.New(), Name: RoleAdmin}, ErrUserNotFound},
+		{"rôle inconnu", &models.User{ID: uuid.New()}, nil, ErrUnknownRole},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockRoles := new(MockRoleRepository)
+			mockUsers := new(MockUserRepository)
+			roleService := NewRoleService(mockRoles, mockUsers)
+
+			mockUsers.On("FindByEmail", "someone@example.com").Return(tc.user, nil)
+			mockRoles.On("FindByName", "moderator").Return(tc.role, nil)
+
+			// Act
+			err := roleService.Grant("someone@example.com", "moderator")
+
+			// Assert
+			assert.Equal(t, tc.expected, err)
+			mockRoles.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRevokeRole_NotGranted(t *testing.T) {
+	// Arrange
+	mockRoles := new(MockRoleRepository)
+	mockUsers := new(MockUserRepository)
+	roleService := NewRoleService(mockRoles, mockUsers)
+	user := &models.User{ID: uuid.New(), Email: "user@example.com"}
+	admin := &models.Role{ID: uuid.New(), Name: RoleAdmin}
+
+	mockUsers.On("FindByEmail", user.Email).Return(user, nil)
+	mockRoles.On("FindByName", RoleAdmin).Return(admin, nil)
+	mockRoles.On("Revoke", user.ID, admin.ID).Return(false, nil)
+
+	// Act
+	err := roleService.Revoke(user.Email, RoleAdmin)
+
+	// Assert
+	assert.Equal(t, ErrRoleNotGranted, err)
+}
+
+func TestBootstrapAdmin(t *testing.T) {
+	cases := []struct {
+		name     string
+		admins   int64
+		expected error
+	}{
+		{"premier administrateur", 0, nil},
+		{"administrateur existant", 1, ErrAdminBootstrapped},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			mockRoles := new(MockRoleRepository)
+			mockUsers := new(MockUserRepository)
+			roleService := NewRoleService(mockRoles, mockUsers)
+			user := &models.User{ID: uuid.New(), Email: "admin@example.com"}
+			admin := &models.Role{ID: uuid.New(), Name: RoleAdmin}
+
+			mockRoles.On("FindByName", RoleAdmin).Return(admin, nil)
+			mockRoles.On("CountUsers", admin.ID).Return(tc.admins, nil)
+			mockUsers.On("FindByEmail", user.Email).Return(user, nil)
+			mockRoles.On("Grant", user.ID, admin.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+			// Act
+			err := roleService.BootstrapAdmin(user.Email)
+
+			// Assert
+			assert.Equal(t, tc.expected, err)
+			if tc.expected != nil {
+				mockRoles.AssertNotCalled(t, "Grant", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/arnaud-dars/collec-app/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session introuvable")
+	ErrSessionRevoked  = errors.New("session révoquée")
+	ErrSessionIdle     = errors.New("session expirée après inactivité")
+)
+
+// ClientInfo décrit le client à l'origine d'une requête d'authentification
+type ClientInfo struct {
+	UserAgent  string
+	IP         string
+	RememberMe bool // "se souvenir de moi" : session longue, sans délai d'inactivité
+}
+
+// SessionService définit l'interface de gestion des sessions utilisateur
+type SessionService interface {
+	Create(userID, familyID uuid.UUID, client ClientInfo) (*models.Session, error)
+	Touch(familyID uuid.UUID) (*models.Session, error)
+	List(userID uuid.UUID) ([]models.Session, error)
+	IsActive(sessionID uuid.UUID) (bool, error)
+	Revoke(userID, sessionID uuid.UUID) error
+	RevokeOthers(userID, currentSessionID uuid.UUID) error
+	RevokeAll(userID uuid.UUID) error
+}
+
+// sessionService implémente SessionService
+type sessionService struct {
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	idleTimeout      time.Duration
+}
+
+// NewSessionService crée une nouvelle instance de SessionService. Les sessions
+// ouvertes sans "se souvenir de moi" expirent si elles ne sont pas renouvelées
+// pendant idleTimeout (0 pour désactiver).
+func NewSessionService(
+	sessionRepo repository.SessionRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	idleTimeout time.Duration,
+) SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		idleTimeout:      idleTimeout,
+	}
+}
+
+// Create ouvre une nouvelle session pour la famille de refresh tokens donnée
+func (s *sessionService) Create(userID, familyID uuid.UUID, client ClientInfo) (*models.Session, error) {
+	session := &models.Session{
+		UserID:     userID,
+		FamilyID:   familyID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		RememberMe: client.RememberMe,
+		LastUsedAt: time.Now(),
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Touch enregistre l'utilisation d'une session lors d'un refresh.
+// Retourne ErrSessionRevoked si la session n'existe pas ou a été révoquée, et
+// ErrSessionIdle (après l'avoir révoquée) si elle est restée inactive trop longtemps.
+func (s *sessionService) Touch(familyID uuid.UUID) (*models.Session, error) {
+	session, err := s.sessionRepo.FindByFamilyID(familyID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	now := time.Now()
+	if s.idle(session, now) {
+		if err := s.revoke(session); err != nil {
+			return nil, err
+		}
+		return nil, ErrSessionIdle
+	}
+
+	session.LastUsedAt = now
+	if err := s.sessionRepo.UpdateLastUsed(session.ID, session.LastUsedAt); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// List retourne les sessions actives d'un utilisateur. Les sessions restées
+// inactives trop longtemps, révoquées seulement à leur prochain refresh, sont omises.
+func (s *sessionService) List(userID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]models.Session, 0, len(sessions))
+	for i := range sessions {
+		if s.active(&sessions[i], now) {
+			active = append(active, sessions[i])
+		}
+	}
+	return active, nil
+}
+
+// Revoke révoque une session de l'utilisateur et sa famille de refresh tokens
+func (s *sessionService) Revoke(userID, sessionID uuid.UUID) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.revoke(session)
+}
+
+// IsActive indique si la session existe et n'est ni révoquée, ni expirée, ni
+// inactive depuis trop longtemps. Les access tokens d'une telle session sont
+// ainsi refusés avant leur expiration.
+func (s *sessionService) IsActive(sessionID uuid.UUID) (bool, error) {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return false, err
+	}
+	return session != nil && s.active(session, time.Now()), nil
+}
+
+// RevokeOthers révoque toutes les sessions de l'utilisateur sauf la session courante
+func (s *sessionService) RevokeOthers(userID, currentSessionID uuid.UUID) error {
+	sessions, err := s.sessionRepo.FindActiveByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		if sessions[i].ID == currentSessionID {
+			continue
+		}
+		if err := s.revoke(&sessions[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeAll révoque toutes les sessions de l'utilisateur
+func (s *sessionService) RevokeAll(userID uuid.UUID) error {
+	return s.RevokeOthers(userID, uuid.Nil)
+}
+
+// active indique si la session est encore utilisable à la date donnée
+func (s *sessionService) active(session *models.Session, now time.Time) bool {
+	if session.RevokedAt != nil {
+		return false
+	}
+	if session.ExpiresAt != nil && !now.Before(*session.ExpiresAt) {
+		return false
+	}
+	return !s.idle(session, now)
+}
+
+// idle indique si une session sans "se souvenir de moi" n'a pas été renouvelée
+// pendant le délai d'inactivité
+func (s *sessionService) idle(session *models.Session, now time.Time) bool {
+	return !session.RememberMe && s.idleTimeout > 0 && now.Sub(session.LastUsedAt) > s.idleTimeout
+}
+
+// revoke marque la session comme révoquée et invalide ses refresh tokens
+func (s *sessionService) revoke(session *models.Session) error {
+	if err := s.refreshTokenRepo.RevokeFamily(session.FamilyID); err != nil {
+		return err
+	}
+	return s.sessionRepo.Revoke(session.ID)
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/arnaud-dars/collec-app/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// Mock du SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session *models.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) FindByID(id uuid.UUID) (*models.Session, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindByFamilyID(familyID uuid.UUID) (*models.Session, error) {
+	args := m.Called(familyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindActiveByUserID(userID uuid.UUID) ([]models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) FindByUserID(userID uuid.UUID) ([]models.Session, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) UpdateLastUsed(id uuid.UUID, lastUsedAt time.Time) error {
+	args := m.Called(id, lastUsedAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) Revoke(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// testSessionIdleTimeout est le délai d'inactivité des sessions de test
+const testSessionIdleTimeout = 2 * time.Hour
+
+// Tests du service Session
+
+func TestSessionCreate_RecordsClientInfo(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	userID := uuid.New()
+	familyID := uuid.New()
+	client := ClientInfo{UserAgent: "Mozilla/5.0", IP: "192.0.2.10"}
+
+	mockRepo.On("Create", mock.MatchedBy(func(session *models.Session) bool {
+		return session.UserID == userID && session.FamilyID == familyID &&
+			session.UserAgent == client.UserAgent && session.IP == client.IP &&
+			!session.LastUsedAt.IsZero()
+	})).Return(nil)
+
+	// Act
+	session, err := sessionService.Create(userID, familyID, client)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotNil(t, session)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSessionTouch_UpdatesLastUsed(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	session := &models.Session{ID: uuid.New(), FamilyID: uuid.New(), LastUsedAt: time.Now().Add(-time.Hour)}
+
+	mockRepo.On("FindByFamilyID", session.FamilyID).Return(session, nil)
+	mockRepo.On("UpdateLastUsed", session.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	touched, err := sessionService.Touch(session.FamilyID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), touched.LastUsedAt, time.Second)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSessionTouch_RevokedSession(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	revokedAt := time.Now()
+	session := &models.Session{ID: uuid.New(), FamilyID: uuid.New(), RevokedAt: &revokedAt}
+
+	mockRepo.On("FindByFamilyID", session.FamilyID).Return(session, nil)
+
+	// Act
+	touched, err := sessionService.Touch(session.FamilyID)
+
+	// Assert
+	assert.Equal(t, ErrSessionRevoked, err)
+	assert.Nil(t, touched)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+}
+
+func TestSessionTouch_IdleSessionRevoked(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	session := &models.Session{ID: uuid.New(), FamilyID: uuid.New(), LastUsedAt: time.Now().Add(-3 * time.Hour)}
+
+	mockRepo.On("FindByFamilyID", session.FamilyID).Return(session, nil)
+	mockTokenRepo.On("RevokeFamily", session.FamilyID).Return(nil)
+	mockRepo.On("Revoke", session.ID).Return(nil)
+
+	// Act
+	touched, err := sessionService.Touch(session.FamilyID)
+
+	// Assert
+	assert.Equal(t, ErrSessionIdle, err)
+	assert.Nil(t, touched)
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+}
+
+func TestSessionTouch_RememberMeIgnoresIdleTimeout(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	session := &models.Session{ID: uuid.New(), FamilyID: uuid.New(), RememberMe: true, LastUsedAt: time.Now().Add(-72 * time.Hour)}
+
+	mockRepo.On("FindByFamilyID", session.FamilyID).Return(session, nil)
+	mockRepo.On("UpdateLastUsed", session.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	// Act
+	touched, err := sessionService.Touch(session.FamilyID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, session, touched)
+	mockTokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything)
+}
+
+func TestSessionList_OmitsIdleSessions(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	sessionService := NewSessionService(mockRepo, new(MockRefreshTokenRepository), testSessionIdleTimeout)
+	userID := uuid.New()
+	recent := models.Session{ID: uuid.New(), UserID: userID, LastUsedAt: time.Now()}
+	idle := models.Session{ID: uuid.New(), UserID: userID, LastUsedAt: time.Now().Add(-testSessionIdleTimeout - time.Minute)}
+
+	mockRepo.On("FindActiveByUserID", userID).Return([]models.Session{recent, idle}, nil)
+
+	// Act
+	sessions, err := sessionService.List(userID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []models.Session{recent}, sessions)
+}
+
+func TestSessionIsActive(t *testing.T) {
+	now := time.Now()
+	expiredAt := now.Add(-time.Minute)
+	expiresAt := now.Add(time.Hour)
+	idleSince := now.Add(-testSessionIdleTimeout - time.Minute)
+	cases := []struct {
+		name     string
+		session  *models.Session
+		expected bool
+	}{
+		{"session active", &models.Session{ID: uuid.New(), LastUsedAt: now, ExpiresAt: &expiresAt}, true},
+		{"session révoquée", &models.Session{ID: uuid.New(), LastUsedAt: now, RevokedAt: &now}, false},
+		{"session expirée", &models.Session{ID: uuid.New(), LastUsedAt: now, ExpiresAt: &expiredAt}, false},
+		{"session inactive", &models.Session{ID: uuid.New(), LastUsedAt: idleSince}, false},
+		{"session inactive mémorisée", &models.Session{ID: uuid.New(), LastUsedAt: idleSince, RememberMe: true}, true},
+		{"session inconnue", nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockSessionRepository)
+			sessionService := NewSessionService(mockRepo, new(MockRefreshTokenRepository), testSessionIdleTimeout)
+			sessionID := uuid.New()
+			if tc.session == nil {
+				mockRepo.On("FindByID", sessionID).Return(nil, nil)
+			} else {
+				mockRepo.On("FindByID", sessionID).Return(tc.session, nil)
+			}
+
+			active, err := sessionService.IsActive(sessionID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, active)
+		})
+	}
+}
+
+func TestSessionRevoke_RevokesRefreshFamily(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	userID := uuid.New()
+	session := &models.Session{ID: uuid.New(), UserID: userID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindByID", session.ID).Return(session, nil)
+	mockTokenRepo.On("RevokeFamily", session.FamilyID).Return(nil)
+	mockRepo.On("Revoke", session.ID).Return(nil)
+
+	// Act
+	err := sessionService.Revoke(userID, session.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+func TestSessionRevoke_SessionOfAnotherUser(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	session := &models.Session{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+
+	mockRepo.On("FindByID", session.ID).Return(session, nil)
+
+	// Act
+	err := sessionService.Revoke(uuid.New(), session.ID)
+
+	// Assert
+	assert.Equal(t, ErrSessionNotFound, err)
+	mockRepo.AssertNotCalled(t, "Revoke", mock.Anything)
+	mockTokenRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything)
+}
+
+func TestSessionRevokeOthers_KeepsCurrentSession(t *testing.T) {
+	// Arrange
+	mockRepo := new(MockSessionRepository)
+	mockTokenRepo := new(MockRefreshTokenRepository)
+	sessionService := NewSessionService(mockRepo, mockTokenRepo, testSessionIdleTimeout)
+
+	userID := uuid.New()
+	current := models.Session{ID: uuid.New(), UserID: userID, FamilyID: uuid.New()}
+	other := models.Session{ID: uuid.New(), UserID: userID, FamilyID: uuid.New()}
+
+	mockRepo.On("FindActiveByUserID", userID).Return([]models.Session{current, other}, nil)
+	mockTokenRepo.On("RevokeFamily", other.FamilyID).Return(nil)
+	mockRepo.On("Revoke", other.ID).Return(nil)
+
+	// Act
+	err := sessionService.RevokeOthers(userID, current.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Revoke", current.ID)
+	mockTokenRepo.AssertNotCalled(t, "RevokeFamily", current.FamilyID)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// generateOpaqueToken génère un token aléatoire de 256 bits, encodé pour être
+// utilisé dans une URL, ainsi que son hash à stocker en base de données
+func generateOpaqueToken() (token, tokenHash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+// hashToken retourne le hash SHA-256 (hex) d'un token opaque
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// linkClaims représente le contenu d'un lien signé envoyé par email
+type linkClaims struct {
+	Purpose  string `json:"purpose"`
+	Email    string `json:"email"`
+	Resource string `json:"resource,omitempty"` // objet désigné par le lien (ex. une archive d'export)
+	jwt.RegisteredClaims
+}
+
+// linkSigner signe et vérifie les liens envoyés par email (HMAC-SHA256).
+// Le claim purpose empêche d'utiliser un lien pour un autre usage que
+// celui pour lequel il a été émis.
+type linkSigner struct {
+	secret []byte
+}
+
+// sign émet un lien signé pour l'utilisateur, valable pendant la durée donnée
+func (l linkSigner) sign(purpose string, userID uuid.UUID, email string, ttl time.Duration) (string, error) {
+	return l.issue(linkClaims{Purpose: purpose, Email: email}, userID, ttl)
+}
+
+// signResource émet un lien signé donnant accès à un objet de l'utilisateur
+func (l linkSigner) signResource(purpose string, userID uuid.UUID, resource string, ttl time.Duration) (string, error) {
+	return l.issue(linkClaims{Purpose: purpose, Resource: resource}, userID, ttl)
+}
+
+// issue complète les claims enregistrés et signe le lien
+func (l linkSigner) issue(claims linkClaims, userID uuid.UUID, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   userID.String(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(l.secret)
+}
+
+// verify vérifie la signature, l'expiration et l'usage d'un lien signé
+func (l linkSigner) verify(purpose, token string) (*linkClaims, error) {
+	parsed, err := jwt.ParseWithClaims(token, &linkClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return l.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := parsed.Claims.(*linkClaims)
+	if !ok || !parsed.Valid || claims.Purpose != purpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,81 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Secret des vecteurs de test de la RFC 6238 (annexe B, SHA1)
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// Les vecteurs de la RFC sont sur 8 chiffres : on compare les 6 derniers
+	vectors := map[int64]string{
+		59:          "94287082",
+		1111111109:  "07081804",
+		1111111111:  "14050471",
+		1234567890:  "89005924",
+		2000000000:  "69279037",
+		20000000000: "65353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := Code(rfcSecret, Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected[2:], code, "t=%d", unix)
+	}
+}
+
+func TestValidate_SkewWindow(t *testing.T) {
+	// Arrange
+	now := time.Unix(1111111111, 0)
+	previous, err := Code(rfcSecret, Step(now)-1)
+	require.NoError(t, err)
+	tooOld, err := Code(rfcSecret, Step(now)-2)
+	require.NoError(t, err)
+
+	// Act
+	step, ok := Validate(rfcSecret, previous, now, 1)
+	_, tooOldOK := Validate(rfcSecret, tooOld, now, 1)
+
+	// Assert
+	assert.True(t, ok)
+	assert.Equal(t, Step(now)-1, step)
+	assert.False(t, tooOldOK)
+}
+
+func TestValidate_RejectsMalformedCodes(t *testing.T) {
+	now := time.Now()
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		_, ok := Validate(rfcSecret, code, now, 1)
+		assert.False(t, ok, code)
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	// Act
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	// Assert
+	_, err = Code(secret, 1)
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32) // 20 octets en base32 sans padding
+}
+
+func TestURI(t *testing.T) {
+	// Act
+	uri := URI("Collec App", "user@example.com", "JBSWY3DPEHPK3PXP")
+
+	// Assert
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Collec%20App:user@example.com?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Collec+App")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
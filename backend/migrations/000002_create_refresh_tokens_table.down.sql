@@ -0,0 +1,7 @@
+-- Migration rollback : Suppression de la table refresh_tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+DROP TABLE IF EXISTS refresh_tokens;
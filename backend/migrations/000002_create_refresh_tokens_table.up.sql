@@ -0,0 +1,23 @@
+-- Migration : Création de la table refresh_tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    rotated_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE refresh_tokens IS 'Refresh tokens émis, regroupés par famille de rotation';
+COMMENT ON COLUMN refresh_tokens.id IS 'Identifiant du token (claim jti du JWT)';
+COMMENT ON COLUMN refresh_tokens.family_id IS 'Famille de rotation, commune à tous les tokens issus d''une même connexion';
+COMMENT ON COLUMN refresh_tokens.rotated_at IS 'Date à laquelle le token a été échangé contre un nouveau';
+COMMENT ON COLUMN refresh_tokens.revoked_at IS 'Date de révocation (réutilisation détectée ou déconnexion)';
@@ -0,0 +1,9 @@
+-- Migration rollback : Révocation des access tokens et génération de tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_revoked_tokens_expires_at;
+DROP INDEX IF EXISTS idx_revoked_tokens_user_id;
+DROP TABLE IF EXISTS revoked_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS token_generation;
@@ -0,0 +1,21 @@
+-- Migration : Révocation des access tokens et génération de tokens par utilisateur
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_generation INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.token_generation IS 'Génération courante des tokens ; tout token d''une génération antérieure est rejeté';
+COMMENT ON TABLE revoked_tokens IS 'Access tokens révoqués avant leur expiration (déconnexion)';
+COMMENT ON COLUMN revoked_tokens.id IS 'Claim jti du token révoqué';
+COMMENT ON COLUMN revoked_tokens.expires_at IS 'Expiration du token, après laquelle l''entrée peut être purgée';
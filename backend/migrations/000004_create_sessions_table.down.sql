@@ -0,0 +1,7 @@
+-- Migration rollback : Suppression de la table sessions
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_sessions_family_id;
+DROP INDEX IF EXISTS idx_sessions_user_id;
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,24 @@
+-- Migration : Création de la table sessions
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id UUID NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_family_id ON sessions(family_id);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE sessions IS 'Connexions actives des utilisateurs (une par appareil / navigateur)';
+COMMENT ON COLUMN sessions.family_id IS 'Famille de refresh tokens associée à la session';
+COMMENT ON COLUMN sessions.user_agent IS 'User-Agent du client au moment de la connexion';
+COMMENT ON COLUMN sessions.ip IS 'Adresse IP du client au moment de la connexion';
+COMMENT ON COLUMN sessions.last_used_at IS 'Date du dernier refresh de la session';
@@ -0,0 +1,7 @@
+-- Migration rollback : Suppression de la table password_reset_tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_password_reset_tokens_user_id;
+DROP INDEX IF EXISTS idx_password_reset_tokens_token_hash;
+DROP TABLE IF EXISTS password_reset_tokens;
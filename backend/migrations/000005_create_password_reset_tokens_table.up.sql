@@ -0,0 +1,20 @@
+-- Migration : Création de la table password_reset_tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_password_reset_tokens_token_hash ON password_reset_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE password_reset_tokens IS 'Demandes de réinitialisation de mot de passe (usage unique)';
+COMMENT ON COLUMN password_reset_tokens.token_hash IS 'Hash SHA-256 (hex) du token envoyé par email';
+COMMENT ON COLUMN password_reset_tokens.used_at IS 'Date d''utilisation ou d''invalidation du token';
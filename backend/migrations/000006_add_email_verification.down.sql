@@ -0,0 +1,6 @@
+-- Migration rollback : Vérification de l'adresse email
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS verification_sent_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,14 @@
+-- Migration : Vérification de l'adresse email
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMP WITH TIME ZONE;
+
+-- Les comptes existants sont considérés comme vérifiés pour ne pas les bloquer
+-- lorsque REQUIRE_EMAIL_VERIFICATION est activé
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.email_verified_at IS 'Date de vérification de l''adresse email (NULL si non vérifiée)';
+COMMENT ON COLUMN users.verification_sent_at IS 'Dernier envoi du lien de vérification, pour limiter les renvois';
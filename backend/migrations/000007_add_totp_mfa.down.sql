@@ -0,0 +1,10 @@
+-- Migration rollback : Suppression de la double authentification TOTP
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,24 @@
+-- Migration : Double authentification TOTP et codes de secours
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes(user_id);
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.totp_secret IS 'Secret TOTP chiffré (AES-GCM), en attente de confirmation tant que totp_enabled_at est NULL';
+COMMENT ON COLUMN users.totp_enabled_at IS 'Date d''activation de la double authentification (NULL si inactive)';
+COMMENT ON COLUMN users.totp_last_step IS 'Dernière période TOTP acceptée, pour refuser le rejeu d''un code';
+COMMENT ON TABLE recovery_codes IS 'Codes de secours à usage unique de la double authentification';
+COMMENT ON COLUMN recovery_codes.code_hash IS 'Hash SHA-256 (hex) du code normalisé';
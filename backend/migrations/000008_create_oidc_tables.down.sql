@@ -0,0 +1,10 @@
+-- Migration rollback : Suppression des tables de connexion OIDC
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_oidc_auth_requests_state_hash;
+DROP TABLE IF EXISTS oidc_auth_requests;
+
+DROP INDEX IF EXISTS idx_user_identities_user_id;
+DROP INDEX IF EXISTS idx_user_identities_provider_subject;
+DROP TABLE IF EXISTS user_identities;
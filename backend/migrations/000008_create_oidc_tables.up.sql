@@ -0,0 +1,35 @@
+-- Migration : Connexion via fournisseurs d'identité (OpenID Connect)
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities(provider, subject);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+CREATE TABLE IF NOT EXISTS oidc_auth_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    state_hash VARCHAR(64) NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_auth_requests_state_hash ON oidc_auth_requests(state_hash);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE user_identities IS 'Comptes des fournisseurs d''identité externes rattachés aux utilisateurs';
+COMMENT ON COLUMN user_identities.subject IS 'Identifiant de l''utilisateur chez le fournisseur (claim sub)';
+COMMENT ON TABLE oidc_auth_requests IS 'Demandes d''autorisation OIDC en cours (usage unique)';
+COMMENT ON COLUMN oidc_auth_requests.state_hash IS 'Hash SHA-256 (hex) du paramètre state';
+COMMENT ON COLUMN oidc_auth_requests.code_verifier IS 'Code verifier PKCE, jamais transmis au navigateur';
@@ -0,0 +1,6 @@
+-- Migration rollback : Suppression du trousseau de clés de signature
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_signing_keys_activates_at;
+DROP TABLE IF EXISTS signing_keys;
@@ -0,0 +1,22 @@
+-- Migration : Clés de signature asymétriques des JWT
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id VARCHAR(32) PRIMARY KEY,
+    algorithm VARCHAR(10) NOT NULL,
+    private_key TEXT NOT NULL,
+    public_key TEXT NOT NULL,
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_activates_at ON signing_keys(activates_at);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE signing_keys IS 'Trousseau de clés de signature des JWT, publiées sur /.well-known/jwks.json';
+COMMENT ON COLUMN signing_keys.id IS 'Identifiant de la clé (kid) placé dans l''en-tête des tokens';
+COMMENT ON COLUMN signing_keys.private_key IS 'Clé privée PKCS#8 chiffrée (AES-256-GCM)';
+COMMENT ON COLUMN signing_keys.activates_at IS 'Date à partir de laquelle la clé signe les tokens (après propagation du JWKS)';
+COMMENT ON COLUMN signing_keys.expires_at IS 'Fin de la période de recouvrement après rotation, NULL pour la clé courante';
@@ -0,0 +1,5 @@
+-- Migration rollback : Suppression des compteurs d'échecs de connexion
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP TABLE IF EXISTS login_throttles;
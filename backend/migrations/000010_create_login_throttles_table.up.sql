@@ -0,0 +1,16 @@
+-- Migration : Protection de la connexion contre la force brute
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE login_throttles IS 'Échecs de connexion récents par compte et par adresse IP, partagés entre les instances';
+COMMENT ON COLUMN login_throttles.key IS 'account:<email> ou ip:<adresse>';
+COMMENT ON COLUMN login_throttles.failures IS 'Échecs consécutifs dans la fenêtre d''observation';
+COMMENT ON COLUMN login_throttles.locked_until IS 'Fin du verrouillage, NULL si la clé n''est pas verrouillée';
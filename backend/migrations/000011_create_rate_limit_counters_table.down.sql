@@ -0,0 +1,6 @@
+-- Migration rollback : Suppression des compteurs de limitation de débit
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_rate_limit_counters_reset_at;
+DROP TABLE IF EXISTS rate_limit_counters;
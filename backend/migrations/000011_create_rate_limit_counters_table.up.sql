@@ -0,0 +1,16 @@
+-- Migration : Compteurs de limitation de débit partagés entre instances
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS rate_limit_counters (
+    key VARCHAR(255) PRIMARY KEY,
+    count INTEGER NOT NULL,
+    reset_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_reset_at ON rate_limit_counters(reset_at);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE rate_limit_counters IS 'Requêtes par client et par politique sur la fenêtre courante (RATE_LIMIT_STORE=postgres)';
+COMMENT ON COLUMN rate_limit_counters.key IS '<politique>:ip:<adresse>, <politique>:user:<id> ou <politique>:key:<empreinte>';
+COMMENT ON COLUMN rate_limit_counters.reset_at IS 'Fin de la fenêtre courante';
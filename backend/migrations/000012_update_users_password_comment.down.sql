@@ -0,0 +1,5 @@
+-- Migration rollback : Empreintes argon2id des mots de passe
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+COMMENT ON COLUMN users.password IS 'Hash bcrypt du mot de passe';
@@ -0,0 +1,7 @@
+-- Migration : Empreintes argon2id des mots de passe
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+-- Les empreintes bcrypt existantes restent valides et sont remplacées par des
+-- empreintes argon2id à la connexion suivante de chaque utilisateur
+COMMENT ON COLUMN users.password IS 'Empreinte du mot de passe au format PHC ($argon2id$...) ou bcrypt héritée ($2a$...), vide pour un compte créé via un fournisseur externe';
@@ -0,0 +1,5 @@
+-- Migration rollback : Changement d'adresse email confirmé par lien
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
@@ -0,0 +1,8 @@
+-- Migration : Changement d'adresse email confirmé par lien
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255);
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.pending_email IS 'Nouvelle adresse email en attente de confirmation (NULL si aucune demande en cours)';
@@ -0,0 +1,6 @@
+-- Migration rollback : Suppression de compte avec délai de grâce
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_users_deletion_requested_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
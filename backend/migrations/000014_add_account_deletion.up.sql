@@ -0,0 +1,10 @@
+-- Migration : Suppression de compte avec délai de grâce
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deletion_requested_at ON users(deletion_requested_at);
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.deletion_requested_at IS 'Date de la demande de suppression (NULL si aucune) ; le compte est purgé à la fin du délai de grâce, une connexion l''annule';
@@ -0,0 +1,24 @@
+-- Migration : Export des données personnelles (RGPD)
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX IF NOT EXISTS idx_data_exports_status ON data_exports(status);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE data_exports IS 'Demandes d''export des données personnelles, construites en tâche de fond';
+COMMENT ON COLUMN data_exports.status IS 'pending, processing, ready, failed ou expired';
+COMMENT ON COLUMN data_exports.file_name IS 'Nom de l''archive ZIP dans le dossier des exports (EXPORT_DIR)';
+COMMENT ON COLUMN data_exports.expires_at IS 'Fin de validité du lien de téléchargement ; l''archive est ensuite supprimée';
@@ -0,0 +1,6 @@
+-- Migration rollback : Désactivation des comptes et réinitialisation imposée par un administrateur
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
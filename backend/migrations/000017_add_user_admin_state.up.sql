@@ -0,0 +1,10 @@
+-- Migration : Désactivation des comptes et réinitialisation imposée par un administrateur
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.disabled_at IS 'Date de désactivation par un administrateur (NULL si actif) ; connexion et tokens refusés';
+COMMENT ON COLUMN users.password_reset_required IS 'Toute connexion refusée jusqu''à la prochaine réinitialisation du mot de passe';
@@ -0,0 +1,6 @@
+-- Migration rollback : Suppression des personal access tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DROP INDEX IF EXISTS idx_personal_access_tokens_user_id;
+DROP TABLE IF EXISTS personal_access_tokens;
@@ -0,0 +1,24 @@
+-- Migration : Personal access tokens pour les scripts et intégrations
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes VARCHAR(500) NOT NULL DEFAULT '',
+    generation INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens(user_id);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE personal_access_tokens IS 'Tokens d''API créés par les utilisateurs (seul le hash SHA-256 est stocké)';
+COMMENT ON COLUMN personal_access_tokens.scopes IS 'Portées accordées au token, séparées par des espaces';
+COMMENT ON COLUMN personal_access_tokens.generation IS 'Génération de tokens de l''utilisateur à la création ; le token est refusé après une déconnexion partout';
+COMMENT ON COLUMN personal_access_tokens.expires_at IS 'Date d''expiration (NULL : sans expiration)';
@@ -0,0 +1,5 @@
+-- Migration rollback : Option "se souvenir de moi" des sessions
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS remember_me;
@@ -0,0 +1,8 @@
+-- Migration : Option "se souvenir de moi" des sessions
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS remember_me BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN sessions.remember_me IS 'Session longue sans expiration après inactivité ; sinon refusée si non renouvelée pendant JWT_REFRESH_IDLE_TIMEOUT';
@@ -0,0 +1,9 @@
+-- Migration rollback : Journal d'audit des événements d'authentification
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+DELETE FROM permissions WHERE name = 'audit:read';
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,47 @@
+-- Migration : Journal d'audit des événements d'authentification
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    event VARCHAR(50) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    error_code VARCHAR(50) NOT NULL DEFAULT '',
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_id ON audit_events(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_event ON audit_events(event);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
+
+-- Le journal est en ajout seul : toute modification ou suppression est refusée
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events est en ajout seul';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+-- Permission de consultation du journal, accordée au rôle admin
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Consulter le journal d''audit')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin' AND p.name = 'audit:read'
+ON CONFLICT DO NOTHING;
+
+-- Commentaires pour documentation
+COMMENT ON TABLE audit_events IS 'Journal en ajout seul des événements d''authentification, conservé après la suppression des comptes';
+COMMENT ON COLUMN audit_events.user_id IS 'Utilisateur concerné (NULL si non identifié) ; sans clé étrangère pour survivre à la purge du compte';
+COMMENT ON COLUMN audit_events.email IS 'Email présenté lors de la tentative, même sans compte correspondant';
+COMMENT ON COLUMN audit_events.outcome IS 'Issue de l''événement : success, failure ou mfa_required';
+COMMENT ON COLUMN audit_events.error_code IS 'Code d''erreur applicatif (ERR_*) en cas d''échec';
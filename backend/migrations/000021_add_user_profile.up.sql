@@ -0,0 +1,21 @@
+-- Migration : Profil utilisateur (nom affiché, identifiant, bio, langue, fuseau horaire, devise)
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(30);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '';
+
+-- Unicité de l'identifiant sans tenir compte de la casse ; plusieurs comptes peuvent ne pas en avoir
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username));
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.display_name IS 'Nom affiché, libre';
+COMMENT ON COLUMN users.username IS 'Identifiant public (@handle), unique sans tenir compte de la casse, NULL si non choisi';
+COMMENT ON COLUMN users.bio IS 'Présentation libre de l''utilisateur';
+COMMENT ON COLUMN users.locale IS 'Langue préférée, tag BCP 47 (ex. fr-FR)';
+COMMENT ON COLUMN users.timezone IS 'Fuseau horaire IANA (ex. Europe/Paris)';
+COMMENT ON COLUMN users.currency IS 'Devise préférée, code ISO 4217 (ex. EUR)';
@@ -0,0 +1,6 @@
+-- Migration rollback : Avatar des utilisateurs
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_url;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_key;
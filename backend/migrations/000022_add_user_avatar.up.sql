@@ -0,0 +1,10 @@
+-- Migration : Avatar des utilisateurs
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT NOT NULL DEFAULT '';
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN users.avatar_key IS 'Préfixe des images de l''avatar dans le stockage de fichiers (avatars/<user>/<version>), vide sans avatar';
+COMMENT ON COLUMN users.avatar_url IS 'URL publique du préfixe avatar_key ; chaque taille est servie sous <avatar_url>/<taille>.jpg';
@@ -0,0 +1,20 @@
+-- Migration : Création de la table magic_link_tokens
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE TABLE IF NOT EXISTS magic_link_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_magic_link_tokens_token_hash ON magic_link_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_magic_link_tokens_user_id ON magic_link_tokens(user_id);
+
+-- Commentaires pour documentation
+COMMENT ON TABLE magic_link_tokens IS 'Liens de connexion sans mot de passe (usage unique)';
+COMMENT ON COLUMN magic_link_tokens.token_hash IS 'Hash SHA-256 (hex) du token envoyé par email';
+COMMENT ON COLUMN magic_link_tokens.used_at IS 'Date d''utilisation ou d''invalidation du lien';
@@ -0,0 +1,13 @@
+-- Migration : Expiration des sessions
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+
+-- Les sessions existantes expirent avec le dernier refresh token de leur famille
+UPDATE sessions SET expires_at = (
+    SELECT MAX(refresh_tokens.expires_at) FROM refresh_tokens WHERE refresh_tokens.family_id = sessions.family_id
+) WHERE expires_at IS NULL;
+
+-- Commentaires pour documentation
+COMMENT ON COLUMN sessions.expires_at IS 'Expiration du dernier refresh token émis pour la session, reportée à chaque rotation ; au-delà, la session n''est plus active';
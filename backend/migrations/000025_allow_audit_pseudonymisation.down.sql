@@ -0,0 +1,15 @@
+-- Migration rollback : Pseudonymisation du journal d'audit à la purge des comptes
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events est en ajout seul';
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE audit_events ALTER COLUMN user_agent TYPE TEXT;
+
+COMMENT ON TABLE audit_events IS 'Journal en ajout seul des événements d''authentification, conservé après la suppression des comptes';
+COMMENT ON COLUMN audit_events.user_id IS 'Utilisateur concerné (NULL si non identifié) ; sans clé étrangère pour survivre à la purge du compte';
+COMMENT ON COLUMN audit_events.user_agent IS NULL;
@@ -0,0 +1,32 @@
+-- Migration : Pseudonymisation du journal d'audit à la purge des comptes
+-- Version : 0.3.0
+-- Date : 2026-10-16
+
+-- Les user agents sont tronqués à l'enregistrement ; les lignes existantes sont
+-- réécrites par ALTER TABLE, sans déclencher le trigger d'ajout seul
+ALTER TABLE audit_events ALTER COLUMN user_agent TYPE VARCHAR(512) USING LEFT(user_agent, 512);
+
+-- Le journal reste en ajout seul, à une exception près : la pseudonymisation,
+-- qui efface les données personnelles d'un événement sans toucher au reste
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.event = OLD.event
+        AND NEW.outcome = OLD.outcome
+        AND NEW.error_code = OLD.error_code
+        AND NEW.created_at = OLD.created_at
+        AND NEW.user_id IS NULL
+        AND NEW.email = ''
+        AND NEW.ip = ''
+        AND NEW.user_agent = '' THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_events est en ajout seul';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Commentaires pour documentation
+COMMENT ON TABLE audit_events IS 'Journal en ajout seul des événements d''authentification, conservé et pseudonymisé à la purge des comptes';
+COMMENT ON COLUMN audit_events.user_id IS 'Utilisateur concerné (NULL si non identifié ou compte purgé) ; sans clé étrangère pour survivre à la purge du compte';
+COMMENT ON COLUMN audit_events.user_agent IS 'User agent du client, tronqué à 512 caractères';
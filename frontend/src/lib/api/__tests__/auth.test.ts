@@ -138,13 +138,17 @@ describe('authApi', () => {
         json: async () => ({ message: 'Déconnexion réussie' }),
       });
 
-      await authApi.logout();
+      await authApi.logout('access-token', 'refresh-token');
 
       expect(global.fetch).toHaveBeenCalledWith(
         'http://localhost:8080/api/auth/logout',
         {
           method: 'POST',
-          headers: { 'Content-Type': 'application/json' },
+          headers: {
+            'Content-Type': 'application/json',
+            Authorization: 'Bearer access-token',
+          },
+          body: JSON.stringify({ refreshToken: 'refresh-token' }),
         }
       );
     });
@@ -154,6 +158,7 @@ describe('authApi', () => {
     it('devrait refresh le token avec succès', async () => {
       const mockResponse = {
         accessToken: 'new-access-token',
+        refreshToken: 'new-refresh-token',
       };
 
       (global.fetch as jest.Mock).mockResolvedValueOnce({
@@ -63,14 +63,16 @@ export const authApi = {
   },
 
   /**
-   * Déconnexion
+   * Déconnexion (révoque l'access token et la session du refresh token)
    */
-  async logout(): Promise<void> {
+  async logout(accessToken: string, refreshToken?: string): Promise<void> {
     const response = await fetch(`${API_URL}/api/auth/logout`, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
+        Authorization: `Bearer ${accessToken}`,
       },
+      body: JSON.stringify({ refreshToken }),
     });
     if (!response.ok) {
       console.error('Logout failed');
@@ -93,7 +93,10 @@ export const useAuthStore = create<AuthState>()(
 
       logout: async () => {
         try {
-          await authApi.logout();
+          const { accessToken, refreshToken } = get();
+          if (accessToken) {
+            await authApi.logout(accessToken, refreshToken ?? undefined);
+          }
         } catch (error) {
           console.error('Erreur lors de la déconnexion:', error);
         } finally {